@@ -8,6 +8,7 @@ import (
 
 	apiclients "github.com/Levap123/api_gateway/internal/api_clients"
 	"github.com/Levap123/api_gateway/internal/configs"
+	"github.com/Levap123/api_gateway/internal/guest"
 	"github.com/Levap123/api_gateway/internal/handler"
 	"github.com/Levap123/api_gateway/pkg/server"
 
@@ -54,16 +55,33 @@ func main() {
 	}
 	defer connBooksrv.Close()
 
+	ctxOrdersrv, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+
+	orderServiceAddress := cfg.OrderService.Addr
+
+	connOrdersrv, err := grpc.DialContext(ctxOrdersrv, orderServiceAddress, opts...)
+	if err != nil {
+		log.Fatalf("fatal in connect to order service: %v", err)
+	}
+	defer connOrdersrv.Close()
+
 	userServiceClient := apiclients.InitUserClient(connUsersrv, log)
 	bookServiceClient := apiclients.InitBookClient(connBooksrv, log)
+	orderServiceClient := apiclients.InitOrderClient(connOrdersrv, log)
+	cartServiceClient := apiclients.InitCartClient(connOrdersrv, log)
+
+	apiclients := apiclients.NewApiClients(bookServiceClient, userServiceClient, orderServiceClient, cartServiceClient)
 
-	apiclients := apiclients.NewApiClients(bookServiceClient, userServiceClient)
+	guestSigner := guest.NewSigner(cfg.GuestCart.Sign, cfg.GuestCart.TTL)
 
-	handler := handler.NewHandler(log, apiclients)
+	handler := handler.NewHandler(log, apiclients, guestSigner, handler.Config{
+		RequireVerifiedEmail: cfg.Orders.RequireVerifiedEmail,
+	})
 
 	server := new(server.Server)
 
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 
 	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
 
@@ -0,0 +1,65 @@
+package apiclients
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/Levap123/api_gateway/internal/entity"
+	"github.com/Levap123/api_gateway/proto"
+	"github.com/Levap123/utils/apperror"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/status"
+)
+
+// BookExport receives exported books from the book service as it streams
+// them.
+type BookExport struct {
+	stream proto.Book_ExportBooksClient
+	log    *logrus.Logger
+}
+
+func (bc *BookClient) ExportBooks(ctx context.Context, params map[string][]string) (*BookExport, error) {
+	req := &proto.ExportRequest{
+		Author:    params["author"],
+		Genre:     params["genre"],
+		Language:  params["language"],
+		Publisher: params["publisher"],
+	}
+
+	stream, err := bc.cl.ExportBooks(ctx, req)
+	if err != nil {
+		bc.log.Errorf("error from book service: %v", err)
+		return nil, err
+	}
+
+	return &BookExport{
+		stream: stream,
+		log:    bc.log,
+	}, nil
+}
+
+// Next returns io.EOF after the last book.
+func (be *BookExport) Next() (entity.Book, error) {
+	resp, err := be.stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return entity.Book{}, io.EOF
+	}
+	if err != nil {
+		be.log.Errorf("error from book service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.Book{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.Book{}, err
+		}
+
+		return entity.Book{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.FromBookRequestToBook(resp), nil
+}
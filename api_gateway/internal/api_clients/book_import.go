@@ -0,0 +1,78 @@
+package apiclients
+
+import (
+	"context"
+
+	"github.com/Levap123/api_gateway/internal/dto"
+	"github.com/Levap123/api_gateway/internal/entity"
+	"github.com/Levap123/api_gateway/proto"
+	"github.com/Levap123/utils/apperror"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/status"
+)
+
+// BookImport streams books to the book service, which reports on all of them
+// once the import is closed.
+type BookImport struct {
+	stream proto.Book_ImportBooksClient
+	dryRun bool
+	sent   bool
+	log    *logrus.Logger
+}
+
+func (bc *BookClient) ImportBooks(ctx context.Context, dryRun bool) (*BookImport, error) {
+	stream, err := bc.cl.ImportBooks(ctx)
+	if err != nil {
+		bc.log.Errorf("error from book service: %v", err)
+		return nil, err
+	}
+
+	return &BookImport{
+		stream: stream,
+		dryRun: dryRun,
+		log:    bc.log,
+	}, nil
+}
+
+// Send returns io.EOF when the book service has ended the import, in which
+// case Close returns the reason.
+func (bi *BookImport) Send(row uint32, book dto.CreateBookDTO) error {
+	req := &proto.ImportBookRequest{
+		Row:  row,
+		Book: dto.FromDtoToRequest(book),
+	}
+
+	if !bi.sent {
+		req.DryRun = bi.dryRun
+		bi.sent = true
+	}
+
+	return bi.stream.Send(req)
+}
+
+func (bi *BookImport) Close() (entity.ImportReport, error) {
+	if !bi.sent {
+		// The service reads dry run from the first book, so an empty import
+		// has to say it explicitly.
+		return entity.ImportReport{Rows: []entity.ImportRow{}, DryRun: bi.dryRun}, bi.stream.CloseSend()
+	}
+
+	resp, err := bi.stream.CloseAndRecv()
+	if err != nil {
+		bi.log.Errorf("error from book service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.ImportReport{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.ImportReport{}, err
+		}
+
+		return entity.ImportReport{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.FromImportReportResponse(resp), nil
+}
@@ -2,15 +2,16 @@ package apiclients
 
 import (
 	"context"
+	"time"
 
 	"github.com/Levap123/api_gateway/internal/dto"
 	"github.com/Levap123/api_gateway/internal/entity"
 	"github.com/Levap123/api_gateway/proto"
 	"github.com/Levap123/utils/apperror"
-	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type BookClient struct {
@@ -48,6 +49,81 @@ func (bc *BookClient) Create(ctx context.Context, createBookDTO dto.CreateBookDT
 	return resp.BookID, nil
 }
 
+func (bc *BookClient) Update(ctx context.Context, bookID string, updateBookDTO dto.UpdateBookDTO) (entity.Book, error) {
+	req := dto.FromUpdateDtoToRequest(bookID, updateBookDTO)
+
+	resp, err := bc.cl.Update(ctx, req)
+	if err != nil {
+		bc.log.Errorf("error from book service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.Book{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.Book{}, err
+		}
+
+		return entity.Book{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.FromBookRequestToBook(resp), nil
+}
+
+func (bc *BookClient) SetStock(ctx context.Context, bookID string, quantity int64) (entity.Book, error) {
+	req := &proto.SetStockRequest{
+		BookID:   bookID,
+		Quantity: quantity,
+	}
+
+	resp, err := bc.cl.SetStock(ctx, req)
+	if err != nil {
+		bc.log.Errorf("error from book service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.Book{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.Book{}, err
+		}
+
+		return entity.Book{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.FromBookRequestToBook(resp), nil
+}
+
+func (bc *BookClient) GetPriceAt(ctx context.Context, bookID string, at time.Time) (entity.Price, error) {
+	req := &proto.PriceAtRequest{
+		BookID: bookID,
+		At:     timestamppb.New(at),
+	}
+
+	resp, err := bc.cl.GetPriceAt(ctx, req)
+	if err != nil {
+		bc.log.Errorf("error from book service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.Price{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.Price{}, err
+		}
+
+		return entity.Price{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.FromPriceResponseToPrice(resp), nil
+}
+
 func (bc *BookClient) GetByID(ctx context.Context, bookID string) (entity.Book, error) {
 	req := &proto.GetBookRequset{
 		BookID: bookID,
@@ -73,6 +149,31 @@ func (bc *BookClient) GetByID(ctx context.Context, bookID string) (entity.Book,
 	return entity.FromBookRequestToBook(resp), nil
 }
 
+func (bc *BookClient) GetByISBN(ctx context.Context, isbn string) (entity.Book, error) {
+	req := &proto.GetByISBNRequest{
+		Isbn: isbn,
+	}
+
+	resp, err := bc.cl.GetByISBN(ctx, req)
+	if err != nil {
+		bc.log.Errorf("error from book service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.Book{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.Book{}, err
+		}
+
+		return entity.Book{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.FromBookRequestToBook(resp), nil
+}
+
 func (bc *BookClient) Delete(ctx context.Context, bookID string) (string, error) {
 	req := &proto.DeleteBookRequestResponse{
 		BookID: bookID,
@@ -98,51 +199,194 @@ func (bc *BookClient) Delete(ctx context.Context, bookID string) (string, error)
 	return resp.BookID, err
 }
 
-func (bc *BookClient) GetAll(ctx context.Context) ([]entity.Book, error) {
-	resp, err := bc.cl.GetAll(ctx, &empty.Empty{})
+func (bc *BookClient) GetAll(ctx context.Context, pageDTO dto.PageDTO) (entity.BookPage, error) {
+	resp, err := bc.cl.GetAll(ctx, dto.FromPageDtoToRequest(pageDTO))
 	if err != nil {
 		bc.log.Errorf("error from book service: %v", err)
 
 		status, ok := status.FromError(err)
 		if !ok {
-			return nil, err
+			return entity.BookPage{}, err
 		}
 
 		statusCode := gRPCToHTTP(status.Code())
 		if statusCode == -1 {
-			return nil, err
+			return entity.BookPage{}, err
 		}
 
-		return nil, apperror.NewError(status.Err(), status.Message(), statusCode)
-	}
-	bookArr := make([]entity.Book, 0, len(resp.Arr))
-
-	for _, bookReq := range resp.Arr {
-		bookArr = append(bookArr, entity.FromBookRequestToBook(bookReq))
+		return entity.BookPage{}, apperror.NewError(status.Err(), status.Message(), statusCode)
 	}
 
-	return bookArr, nil
+	return entity.BookPage{
+		Books:         fromBookInfoArray(resp),
+		NextPageToken: resp.NextPageToken,
+	}, nil
 }
 
-func (bc *BookClient) GetByFiltering(ctx context.Context, params map[string][]string) ([]entity.Book, error) {
+func (bc *BookClient) GetByFiltering(ctx context.Context, params map[string][]string, pageDTO dto.PageDTO) (entity.BookPage, error) {
 	filter := &proto.Filter{
 		Author:    params["author"],
 		Genre:     params["genre"],
 		Language:  params["language"],
 		Publsiher: params["publisher"],
+		Page:      dto.FromPageDtoToRequest(pageDTO),
 	}
 
 	resp, err := bc.cl.GetWithFilter(ctx, filter)
 	if err != nil {
-		return nil, err
+		bc.log.Errorf("error from book service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.BookPage{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.BookPage{}, err
+		}
+
+		return entity.BookPage{}, apperror.NewError(status.Err(), status.Message(), statusCode)
 	}
 
+	return entity.BookPage{
+		Books:         fromBookInfoArray(resp),
+		NextPageToken: resp.NextPageToken,
+	}, nil
+}
+
+func (bc *BookClient) Search(ctx context.Context, query string, limit uint32) ([]entity.Book, error) {
+	req := &proto.SearchRequest{
+		Query: query,
+		Limit: limit,
+	}
+
+	resp, err := bc.cl.Search(ctx, req)
+	if err != nil {
+		bc.log.Errorf("error from book service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return nil, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return nil, err
+		}
+
+		return nil, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return fromBookInfoArray(resp), nil
+}
+
+func (bc *BookClient) GetByAuthor(ctx context.Context, author string) ([]entity.Book, error) {
+	req := &proto.GetByAuthorRequest{
+		Author: author,
+	}
+
+	resp, err := bc.cl.GetByAuthor(ctx, req)
+	if err != nil {
+		bc.log.Errorf("error from book service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return nil, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return nil, err
+		}
+
+		return nil, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return fromBookInfoArray(resp), nil
+}
+
+func (bc *BookClient) GetByPublisher(ctx context.Context, publisher string) ([]entity.Book, error) {
+	req := &proto.GetByPublisherRequest{
+		Publisher: publisher,
+	}
+
+	resp, err := bc.cl.GetByPublisher(ctx, req)
+	if err != nil {
+		bc.log.Errorf("error from book service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return nil, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return nil, err
+		}
+
+		return nil, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return fromBookInfoArray(resp), nil
+}
+
+func (bc *BookClient) GetByGenre(ctx context.Context, genre string) ([]entity.Book, error) {
+	req := &proto.GetByGenreRequest{
+		Genre: genre,
+	}
+
+	resp, err := bc.cl.GetByGenre(ctx, req)
+	if err != nil {
+		bc.log.Errorf("error from book service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return nil, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return nil, err
+		}
+
+		return nil, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return fromBookInfoArray(resp), nil
+}
+
+func (bc *BookClient) GetByLanguage(ctx context.Context, language string) ([]entity.Book, error) {
+	req := &proto.GetByLanguageRequest{
+		Language: language,
+	}
+
+	resp, err := bc.cl.GetByLanguage(ctx, req)
+	if err != nil {
+		bc.log.Errorf("error from book service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return nil, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return nil, err
+		}
+
+		return nil, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return fromBookInfoArray(resp), nil
+}
+
+func fromBookInfoArray(resp *proto.BookInfoArray) []entity.Book {
 	bookArr := make([]entity.Book, 0, len(resp.Arr))
 
 	for _, bookReq := range resp.Arr {
 		bookArr = append(bookArr, entity.FromBookRequestToBook(bookReq))
 	}
 
-	return bookArr, nil
+	return bookArr
 }
-
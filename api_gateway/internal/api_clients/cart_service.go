@@ -0,0 +1,185 @@
+package apiclients
+
+import (
+	"context"
+
+	"github.com/Levap123/api_gateway/internal/dto"
+	"github.com/Levap123/api_gateway/internal/entity"
+	"github.com/Levap123/api_gateway/proto"
+	"github.com/Levap123/utils/apperror"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// CartClient talks to the carts, which live in the order service.
+type CartClient struct {
+	cl  proto.CartClient
+	log *logrus.Logger
+}
+
+func InitCartClient(conn *grpc.ClientConn, log *logrus.Logger) *CartClient {
+	cl := proto.NewCartClient(conn)
+	return &CartClient{
+		cl:  cl,
+		log: log,
+	}
+}
+
+func (cc *CartClient) Get(ctx context.Context, owner dto.CartOwner) (entity.Cart, error) {
+	resp, err := cc.cl.GetCart(ctx, &proto.GetCartRequest{UserId: owner.UserID, GuestId: owner.GuestID})
+	if err != nil {
+		cc.log.Errorf("error from order service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.Cart{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.Cart{}, err
+		}
+
+		return entity.Cart{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.FromCartResponse(resp), nil
+}
+
+func (cc *CartClient) AddItem(ctx context.Context, owner dto.CartOwner, itemDTO dto.CartItemDTO) (entity.Cart, error) {
+	req := &proto.CartItemRequest{
+		UserId:   owner.UserID,
+		GuestId:  owner.GuestID,
+		BookId:   itemDTO.BookID,
+		Quantity: itemDTO.Quantity,
+	}
+
+	resp, err := cc.cl.AddItem(ctx, req)
+	if err != nil {
+		cc.log.Errorf("error from order service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.Cart{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.Cart{}, err
+		}
+
+		return entity.Cart{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.FromCartResponse(resp), nil
+}
+
+func (cc *CartClient) SetQuantity(ctx context.Context, owner dto.CartOwner, bookID string, quantity uint32) (entity.Cart, error) {
+	req := &proto.CartItemRequest{
+		UserId:   owner.UserID,
+		GuestId:  owner.GuestID,
+		BookId:   bookID,
+		Quantity: quantity,
+	}
+
+	resp, err := cc.cl.SetQuantity(ctx, req)
+	if err != nil {
+		cc.log.Errorf("error from order service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.Cart{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.Cart{}, err
+		}
+
+		return entity.Cart{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.FromCartResponse(resp), nil
+}
+
+func (cc *CartClient) RemoveItem(ctx context.Context, owner dto.CartOwner, bookID string) (entity.Cart, error) {
+	req := &proto.RemoveCartItemRequest{
+		UserId:  owner.UserID,
+		GuestId: owner.GuestID,
+		BookId:  bookID,
+	}
+
+	resp, err := cc.cl.RemoveItem(ctx, req)
+	if err != nil {
+		cc.log.Errorf("error from order service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.Cart{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.Cart{}, err
+		}
+
+		return entity.Cart{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.FromCartResponse(resp), nil
+}
+
+func (cc *CartClient) Checkout(ctx context.Context, userID uint64, idempotencyKey string, checkoutDTO dto.CheckoutDTO) (uint64, error) {
+	req := &proto.CheckoutRequest{
+		UserId:         userID,
+		Currency:       checkoutDTO.Currency,
+		IdempotencyKey: idempotencyKey,
+	}
+
+	resp, err := cc.cl.Checkout(ctx, req)
+	if err != nil {
+		cc.log.Errorf("error from order service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return 0, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return 0, err
+		}
+
+		return 0, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return resp.OrderId, nil
+}
+
+// MergeGuestCart moves the cart of the guest into the cart of the user.
+func (cc *CartClient) MergeGuestCart(ctx context.Context, guestID string, userID uint64) (entity.CartMergeReport, error) {
+	req := &proto.MergeGuestCartRequest{
+		GuestId: guestID,
+		UserId:  userID,
+	}
+
+	resp, err := cc.cl.MergeGuestCart(ctx, req)
+	if err != nil {
+		cc.log.Errorf("error from order service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.CartMergeReport{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.CartMergeReport{}, err
+		}
+
+		return entity.CartMergeReport{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.FromMergeReport(resp), nil
+}
@@ -1,13 +1,17 @@
 package apiclients
 
 type ApiClients struct {
-	BookClient *BookClient
-	UserClient *UserClient
+	BookClient  *BookClient
+	UserClient  *UserClient
+	OrderClient *OrderClient
+	CartClient  *CartClient
 }
 
-func NewApiClients(bc *BookClient, uc *UserClient) *ApiClients {
+func NewApiClients(bc *BookClient, uc *UserClient, oc *OrderClient, cc *CartClient) *ApiClients {
 	return &ApiClients{
-		BookClient: bc,
-		UserClient: uc,
+		BookClient:  bc,
+		UserClient:  uc,
+		OrderClient: oc,
+		CartClient:  cc,
 	}
 }
@@ -0,0 +1,219 @@
+package apiclients
+
+import (
+	"context"
+
+	"github.com/Levap123/api_gateway/internal/dto"
+	"github.com/Levap123/api_gateway/internal/entity"
+	"github.com/Levap123/api_gateway/proto"
+	"github.com/Levap123/utils/apperror"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+type OrderClient struct {
+	cl  proto.OrdersClient
+	log *logrus.Logger
+}
+
+func InitOrderClient(conn *grpc.ClientConn, log *logrus.Logger) *OrderClient {
+	cl := proto.NewOrdersClient(conn)
+	return &OrderClient{
+		cl:  cl,
+		log: log,
+	}
+}
+
+func (oc *OrderClient) Create(ctx context.Context, userID uint64, idempotencyKey string, createOrderDTO dto.CreateOrderDTO) (uint64, error) {
+	req := dto.FromCreateOrderDtoToRequest(userID, idempotencyKey, createOrderDTO)
+
+	resp, err := oc.cl.Create(ctx, req)
+	if err != nil {
+		oc.log.Errorf("error from order service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return 0, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return 0, err
+		}
+
+		return 0, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return resp.Id, nil
+}
+
+func (oc *OrderClient) GetByID(ctx context.Context, orderID uint64) (entity.Order, error) {
+	req := &proto.GetOrderByIDRequest{
+		Id: orderID,
+	}
+
+	resp, err := oc.cl.GetByID(ctx, req)
+	if err != nil {
+		oc.log.Errorf("error from order service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.Order{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.Order{}, err
+		}
+
+		return entity.Order{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.FromOrderResponse(resp), nil
+}
+
+func (oc *OrderClient) GetHistory(ctx context.Context, orderID uint64) (entity.OrderHistory, error) {
+	req := &proto.GetOrderHistoryRequest{
+		Id: orderID,
+	}
+
+	resp, err := oc.cl.GetOrderHistory(ctx, req)
+	if err != nil {
+		oc.log.Errorf("error from order service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.OrderHistory{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.OrderHistory{}, err
+		}
+
+		return entity.OrderHistory{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.FromOrderHistoryResponse(resp), nil
+}
+
+// GetByUserID returns the orders of the user, only those in the status when
+// it is set.
+func (oc *OrderClient) GetByUserID(ctx context.Context, userID uint64, orderStatus proto.OrderStatus) ([]entity.Order, error) {
+	var (
+		resp *proto.OrderArray
+		err  error
+	)
+
+	if orderStatus == proto.OrderStatus_ORDER_STATUS_UNSPECIFIED {
+		resp, err = oc.cl.GetByUserID(ctx, &proto.GetOrderByUserIDRequest{UserId: userID})
+	} else {
+		resp, err = oc.cl.GetByUserIDAndStatus(ctx, &proto.GetOrderByUserIDAndStatusRequest{UserId: userID, Status: orderStatus})
+	}
+
+	if err != nil {
+		oc.log.Errorf("error from order service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return nil, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return nil, err
+		}
+
+		return nil, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	orders := make([]entity.Order, 0, len(resp.Oo))
+	for _, order := range resp.Oo {
+		orders = append(orders, entity.FromOrderResponse(order))
+	}
+
+	return orders, nil
+}
+
+func (oc *OrderClient) ChangeStatus(ctx context.Context, orderID, actorID uint64, orderStatus proto.OrderStatus, changeDTO dto.ChangeOrderStatusDTO) (entity.Order, error) {
+	req := &proto.ChangeStatusRequest{
+		Id:              orderID,
+		Status:          orderStatus,
+		ExpectedVersion: changeDTO.ExpectedVersion,
+		ActorId:         actorID,
+		Reason:          changeDTO.Reason,
+	}
+
+	resp, err := oc.cl.ChangeStatus(ctx, req)
+	if err != nil {
+		oc.log.Errorf("error from order service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.Order{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.Order{}, err
+		}
+
+		return entity.Order{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.FromOrderResponse(resp), nil
+}
+
+func (oc *OrderClient) Cancel(ctx context.Context, orderID, userID uint64, admin bool, reason proto.CancelReason, cancelDTO dto.CancelOrderDTO) (entity.OrderCancellation, error) {
+	req := &proto.CancelOrderRequest{
+		Id:              orderID,
+		UserId:          userID,
+		Admin:           admin,
+		Reason:          reason,
+		Comment:         cancelDTO.Comment,
+		ExpectedVersion: cancelDTO.ExpectedVersion,
+	}
+
+	resp, err := oc.cl.CancelOrder(ctx, req)
+	if err != nil {
+		oc.log.Errorf("error from order service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.OrderCancellation{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.OrderCancellation{}, err
+		}
+
+		return entity.OrderCancellation{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.FromCancelOrderResponse(resp), nil
+}
+
+func (oc *OrderClient) UpdateItems(ctx context.Context, orderID, userID uint64, updateDTO dto.UpdateOrderItemsDTO) (entity.Order, error) {
+	req := dto.FromUpdateOrderItemsDtoToRequest(orderID, userID, updateDTO)
+
+	resp, err := oc.cl.UpdateOrderItems(ctx, req)
+	if err != nil {
+		oc.log.Errorf("error from order service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.Order{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.Order{}, err
+		}
+
+		return entity.Order{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.FromOrderResponse(resp), nil
+}
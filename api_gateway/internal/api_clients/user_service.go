@@ -82,7 +82,9 @@ func (uc *UserClient) SignIn(ctx context.Context, dto *dto.SignInDTO) (*entity.T
 	}, nil
 }
 
-func (uc *UserClient) Validate(ctx context.Context, accessToken string) (uint64, error) {
+// Validate returns the user owning the access token with their roles and
+// permissions as of when the token was issued.
+func (uc *UserClient) Validate(ctx context.Context, accessToken string) (entity.Identity, error) {
 	request := &proto.ValidateRequest{
 		Access: accessToken,
 	}
@@ -93,18 +95,23 @@ func (uc *UserClient) Validate(ctx context.Context, accessToken string) (uint64,
 
 		status, ok := status.FromError(err)
 		if !ok {
-			return 0, err
+			return entity.Identity{}, err
 		}
 
 		statusCode := gRPCToHTTP(status.Code())
 		if statusCode == -1 {
-			return 0, err
+			return entity.Identity{}, err
 		}
 
-		return 0, apperror.NewError(status.Err(), status.Message(), statusCode)
+		return entity.Identity{}, apperror.NewError(status.Err(), status.Message(), statusCode)
 	}
 
-	return response.UserID, nil
+	return entity.Identity{
+		UserID:        response.UserID,
+		Roles:         response.Roles,
+		Permissions:   response.Permissions,
+		EmailVerified: response.EmailVerified,
+	}, nil
 }
 
 func (uc *UserClient) GetMe(ctx context.Context, accessToken string) (*entity.User, error) {
@@ -221,3 +228,237 @@ func (uc *UserClient) Rerfresh(ctx context.Context, dtoReq *dto.RefreshDTO) (*dt
 		RefreshToken: response.Refresh,
 	}, nil
 }
+
+// Logout signs out the sign-in the refresh token was issued to.
+func (uc *UserClient) Logout(ctx context.Context, refreshToken string) error {
+	request := &proto.LogoutRequest{
+		Refresh: refreshToken,
+	}
+
+	if _, err := uc.cl.Logout(ctx, request); err != nil {
+		uc.log.Errorf("error from user service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return err
+		}
+
+		return apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return nil
+}
+
+func (uc *UserClient) ListSessions(ctx context.Context, accessToken string) ([]entity.Session, error) {
+	request := &proto.ValidateRequest{
+		Access: accessToken,
+	}
+
+	response, err := uc.cl.ListSessions(ctx, request)
+	if err != nil {
+		uc.log.Errorf("error from user service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return nil, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return nil, err
+		}
+
+		return nil, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.FromSessionsResponse(response), nil
+}
+
+func (uc *UserClient) RevokeSession(ctx context.Context, accessToken, sessionID string) error {
+	request := &proto.RevokeSessionRequest{
+		Access:    accessToken,
+		SessionId: sessionID,
+	}
+
+	if _, err := uc.cl.RevokeSession(ctx, request); err != nil {
+		uc.log.Errorf("error from user service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return err
+		}
+
+		return apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return nil
+}
+
+func (uc *UserClient) AssignRole(ctx context.Context, accessToken string, userID uint64, role string) (entity.UserRoles, error) {
+	request := &proto.RoleRequest{
+		Access: accessToken,
+		UserID: userID,
+		Role:   role,
+	}
+
+	response, err := uc.cl.AssignRole(ctx, request)
+	if err != nil {
+		uc.log.Errorf("error from user service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.UserRoles{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.UserRoles{}, err
+		}
+
+		return entity.UserRoles{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.UserRoles{
+		UserID: response.UserID,
+		Roles:  response.Roles,
+	}, nil
+}
+
+func (uc *UserClient) RevokeRole(ctx context.Context, accessToken string, userID uint64, role string) (entity.UserRoles, error) {
+	request := &proto.RoleRequest{
+		Access: accessToken,
+		UserID: userID,
+		Role:   role,
+	}
+
+	response, err := uc.cl.RevokeRole(ctx, request)
+	if err != nil {
+		uc.log.Errorf("error from user service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return entity.UserRoles{}, err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return entity.UserRoles{}, err
+		}
+
+		return entity.UserRoles{}, apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+
+	return entity.UserRoles{
+		UserID: response.UserID,
+		Roles:  response.Roles,
+	}, nil
+}
+
+// RequestPasswordReset asks the user service to send a reset token to the
+// email. It succeeds for emails nobody signed up with too.
+func (uc *UserClient) RequestPasswordReset(ctx context.Context, email string) error {
+	request := &proto.PasswordResetRequest{
+		Email: email,
+	}
+
+	if _, err := uc.cl.RequestPasswordReset(ctx, request); err != nil {
+		uc.log.Errorf("error from user service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return err
+		}
+
+		return apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+	return nil
+}
+
+// ConfirmPasswordReset sets the new password with the reset token.
+func (uc *UserClient) ConfirmPasswordReset(ctx context.Context, resetToken, newPassword string) error {
+	request := &proto.ConfirmPasswordResetRequest{
+		Token:       resetToken,
+		NewPassword: newPassword,
+	}
+
+	if _, err := uc.cl.ConfirmPasswordReset(ctx, request); err != nil {
+		uc.log.Errorf("error from user service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return err
+		}
+
+		return apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+	return nil
+}
+
+// VerifyEmail verifies the email the token was sent to.
+func (uc *UserClient) VerifyEmail(ctx context.Context, verificationToken string) error {
+	request := &proto.VerifyEmailRequest{
+		Token: verificationToken,
+	}
+
+	if _, err := uc.cl.VerifyEmail(ctx, request); err != nil {
+		uc.log.Errorf("error from user service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return err
+		}
+
+		return apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+	return nil
+}
+
+// ResendVerification sends a new verification link to the owner of the
+// access token.
+func (uc *UserClient) ResendVerification(ctx context.Context, accessToken string) error {
+	request := &proto.ValidateRequest{
+		Access: accessToken,
+	}
+
+	if _, err := uc.cl.ResendVerification(ctx, request); err != nil {
+		uc.log.Errorf("error from user service: %v", err)
+
+		status, ok := status.FromError(err)
+		if !ok {
+			return err
+		}
+
+		statusCode := gRPCToHTTP(status.Code())
+		if statusCode == -1 {
+			return err
+		}
+
+		return apperror.NewError(status.Err(), status.Message(), statusCode)
+	}
+	return nil
+}
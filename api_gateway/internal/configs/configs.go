@@ -1,6 +1,10 @@
 package configs
 
-import "github.com/ilyakaznacheev/cleanenv"
+import (
+	"time"
+
+	"github.com/ilyakaznacheev/cleanenv"
+)
 
 type Configs struct {
 	Server struct {
@@ -14,6 +18,19 @@ type Configs struct {
 	BookService struct {
 		Addr string `yaml:"addr"`
 	} `yaml:"book_service"`
+
+	OrderService struct {
+		Addr string `yaml:"addr"`
+	} `yaml:"order_service"`
+
+	GuestCart struct {
+		Sign string        `yaml:"sign"`
+		TTL  time.Duration `yaml:"ttl"`
+	} `yaml:"guest_cart"`
+
+	Orders struct {
+		RequireVerifiedEmail bool `yaml:"require_verified_email"`
+	} `yaml:"orders"`
 }
 
 func GetConfigs() (*Configs, error) {
@@ -1,6 +1,9 @@
 package dto
 
-import "github.com/Levap123/api_gateway/proto"
+import (
+	"github.com/Levap123/api_gateway/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
 
 type CreateBookDTO struct {
 	Title       string `json:"title,omitempty"`
@@ -13,6 +16,10 @@ type CreateBookDTO struct {
 	Binding     bool   `json:"binding,omitempty"`
 	Series      string `json:"series,omitempty"`
 	Language    string `json:"language,omitempty"`
+	ISBN        string `json:"isbn,omitempty"`
+	Quantity    int64  `json:"quantity,omitempty"`
+	PriceAmount int64  `json:"price_amount,omitempty"`
+	Currency    string `json:"currency,omitempty"`
 }
 
 func FromDtoToRequest(dto CreateBookDTO) *proto.BookInfo {
@@ -27,5 +34,111 @@ func FromDtoToRequest(dto CreateBookDTO) *proto.BookInfo {
 		Binding:     dto.Binding,
 		Series:      dto.Series,
 		Language:    dto.Language,
+		Isbn:        dto.ISBN,
+		Quantity:    dto.Quantity,
+		PriceAmount: dto.PriceAmount,
+		Currency:    dto.Currency,
+	}
+}
+
+type StockDTO struct {
+	Quantity int64 `json:"quantity"`
+}
+
+type PageDTO struct {
+	PageSize   uint32
+	PageToken  string
+	SortBy     string
+	Descending bool
+}
+
+func FromPageDtoToRequest(dto PageDTO) *proto.PageRequest {
+	return &proto.PageRequest{
+		PageSize:   dto.PageSize,
+		PageToken:  dto.PageToken,
+		SortBy:     dto.SortBy,
+		Descending: dto.Descending,
+	}
+}
+
+// UpdateBookDTO holds a partial book update: only non-nil fields are changed.
+type UpdateBookDTO struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Image       *string `json:"image,omitempty"`
+	Pages       *uint64 `json:"pages,omitempty"`
+	Author      *string `json:"author,omitempty"`
+	Genre       *string `json:"genre,omitempty"`
+	Publisher   *string `json:"publisher,omitempty"`
+	Binding     *bool   `json:"binding,omitempty"`
+	Series      *string `json:"series,omitempty"`
+	Language    *string `json:"language,omitempty"`
+	ISBN        *string `json:"isbn,omitempty"`
+	PriceAmount *int64  `json:"price_amount,omitempty"`
+	Currency    *string `json:"currency,omitempty"`
+}
+
+func FromUpdateDtoToRequest(bookID string, dto UpdateBookDTO) *proto.UpdateBookRequest {
+	book := &proto.BookInfo{
+		ID: bookID,
+	}
+	mask := &fieldmaskpb.FieldMask{}
+
+	if dto.Title != nil {
+		book.Title = *dto.Title
+		mask.Paths = append(mask.Paths, "title")
+	}
+	if dto.Description != nil {
+		book.Description = *dto.Description
+		mask.Paths = append(mask.Paths, "description")
+	}
+	if dto.Image != nil {
+		book.Image = *dto.Image
+		mask.Paths = append(mask.Paths, "image")
+	}
+	if dto.Pages != nil {
+		book.Pages = *dto.Pages
+		mask.Paths = append(mask.Paths, "pages")
+	}
+	if dto.Author != nil {
+		book.Author = *dto.Author
+		mask.Paths = append(mask.Paths, "author")
+	}
+	if dto.Genre != nil {
+		book.Genre = *dto.Genre
+		mask.Paths = append(mask.Paths, "genre")
+	}
+	if dto.Publisher != nil {
+		book.Publisher = *dto.Publisher
+		mask.Paths = append(mask.Paths, "publisher")
+	}
+	if dto.Binding != nil {
+		book.Binding = *dto.Binding
+		mask.Paths = append(mask.Paths, "binding")
+	}
+	if dto.Series != nil {
+		book.Series = *dto.Series
+		mask.Paths = append(mask.Paths, "series")
+	}
+	if dto.Language != nil {
+		book.Language = *dto.Language
+		mask.Paths = append(mask.Paths, "language")
+	}
+	if dto.ISBN != nil {
+		book.Isbn = *dto.ISBN
+		mask.Paths = append(mask.Paths, "isbn")
+	}
+	if dto.PriceAmount != nil {
+		book.PriceAmount = *dto.PriceAmount
+		mask.Paths = append(mask.Paths, "price_amount")
+	}
+	if dto.Currency != nil {
+		book.Currency = *dto.Currency
+		mask.Paths = append(mask.Paths, "currency")
+	}
+
+	return &proto.UpdateBookRequest{
+		Book:       book,
+		UpdateMask: mask,
 	}
 }
@@ -0,0 +1,312 @@
+package dto
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Levap123/api_gateway/internal/entity"
+)
+
+const FormatONIX = "onix"
+
+// ExportContentTypes are the media types of the export formats.
+var ExportContentTypes = map[string]string{
+	FormatCSV:   "text/csv",
+	FormatJSONL: "application/jsonl",
+	FormatONIX:  "application/xml",
+}
+
+// BookWriter writes exported books one at a time. Close writes whatever the
+// format needs after the last book and must be called once all are written.
+type BookWriter interface {
+	Write(book entity.Book) error
+	Close() error
+}
+
+func NewBookWriter(format string, w io.Writer) (BookWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVBookWriter(w)
+	case FormatJSONL:
+		return &jsonlBookWriter{encoder: json.NewEncoder(w)}, nil
+	case FormatONIX:
+		return newONIXBookWriter(w)
+	default:
+		return nil, fmt.Errorf("%q - %w", format, ErrUnknownFormat)
+	}
+}
+
+// exportColumns are the CSV columns of an export. All but the read only ones
+// are import columns, so an export can be imported back.
+var exportColumns = []string{
+	"id", "title", "description", "image", "pages", "author", "genre", "publisher", "binding",
+	"series", "language", "isbn", "quantity", "available", "price_amount", "currency", "added_at",
+}
+
+type csvBookWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVBookWriter(w io.Writer) (*csvBookWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportColumns); err != nil {
+		return nil, err
+	}
+
+	return &csvBookWriter{writer: writer}, nil
+}
+
+func (cw *csvBookWriter) Write(book entity.Book) error {
+	return cw.writer.Write([]string{
+		book.ID,
+		book.Title,
+		book.Description,
+		book.Image,
+		strconv.FormatUint(book.Pages, 10),
+		book.Author,
+		book.Genre,
+		book.Publisher,
+		strconv.FormatBool(book.Binding),
+		book.Series,
+		book.Language,
+		book.ISBN,
+		strconv.FormatInt(book.Quantity, 10),
+		strconv.FormatInt(book.Available, 10),
+		strconv.FormatInt(book.PriceAmount, 10),
+		book.Currency,
+		book.AddedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (cw *csvBookWriter) Close() error {
+	cw.writer.Flush()
+	return cw.writer.Error()
+}
+
+type jsonlBookWriter struct {
+	encoder *json.Encoder
+}
+
+func (jw *jsonlBookWriter) Write(book entity.Book) error {
+	return jw.encoder.Encode(book)
+}
+
+func (jw *jsonlBookWriter) Close() error {
+	return nil
+}
+
+// onixBookWriter writes a simplified ONIX for Books 3.0 message: one Product
+// per book with its identifiers, title, contributor, extent, subject,
+// description, publisher and price. Code values are from the ONIX code lists.
+type onixBookWriter struct {
+	w       io.Writer
+	encoder *xml.Encoder
+}
+
+type onixHeader struct {
+	XMLName      xml.Name `xml:"Header"`
+	SentDateTime string   `xml:"SentDateTime"`
+}
+
+type onixProduct struct {
+	XMLName            xml.Name                `xml:"Product"`
+	RecordReference    string                  `xml:"RecordReference"`
+	NotificationType   string                  `xml:"NotificationType"`
+	ProductIdentifiers []onixProductIdentifier `xml:"ProductIdentifier"`
+	DescriptiveDetail  onixDescriptiveDetail   `xml:"DescriptiveDetail"`
+	CollateralDetail   *onixCollateralDetail   `xml:"CollateralDetail,omitempty"`
+	PublishingDetail   *onixPublishingDetail   `xml:"PublishingDetail,omitempty"`
+	ProductSupply      onixProductSupply       `xml:"ProductSupply"`
+}
+
+type onixProductIdentifier struct {
+	ProductIDType string `xml:"ProductIDType"`
+	IDValue       string `xml:"IDValue"`
+}
+
+type onixDescriptiveDetail struct {
+	ProductComposition string           `xml:"ProductComposition"`
+	ProductForm        string           `xml:"ProductForm"`
+	TitleDetail        onixTitleDetail  `xml:"TitleDetail"`
+	Contributor        *onixContributor `xml:"Contributor,omitempty"`
+	Language           *onixLanguage    `xml:"Language,omitempty"`
+	Extent             *onixExtent      `xml:"Extent,omitempty"`
+	Subject            *onixSubject     `xml:"Subject,omitempty"`
+}
+
+type onixTitleDetail struct {
+	TitleType         string `xml:"TitleType"`
+	TitleElementLevel string `xml:"TitleElement>TitleElementLevel"`
+	TitleText         string `xml:"TitleElement>TitleText"`
+}
+
+type onixContributor struct {
+	SequenceNumber  int    `xml:"SequenceNumber"`
+	ContributorRole string `xml:"ContributorRole"`
+	PersonName      string `xml:"PersonName"`
+}
+
+type onixLanguage struct {
+	LanguageRole string `xml:"LanguageRole"`
+	LanguageCode string `xml:"LanguageCode"`
+}
+
+type onixExtent struct {
+	ExtentType  string `xml:"ExtentType"`
+	ExtentValue uint64 `xml:"ExtentValue"`
+	ExtentUnit  string `xml:"ExtentUnit"`
+}
+
+type onixSubject struct {
+	SubjectSchemeIdentifier string `xml:"SubjectSchemeIdentifier"`
+	SubjectHeadingText      string `xml:"SubjectHeadingText"`
+}
+
+type onixCollateralDetail struct {
+	TextType        string `xml:"TextContent>TextType"`
+	ContentAudience string `xml:"TextContent>ContentAudience"`
+	Text            string `xml:"TextContent>Text"`
+}
+
+type onixPublishingDetail struct {
+	PublishingRole string `xml:"Publisher>PublishingRole"`
+	PublisherName  string `xml:"Publisher>PublisherName"`
+}
+
+type onixProductSupply struct {
+	ProductAvailability string     `xml:"SupplyDetail>ProductAvailability"`
+	Price               *onixPrice `xml:"SupplyDetail>Price,omitempty"`
+}
+
+type onixPrice struct {
+	PriceType    string `xml:"PriceType"`
+	PriceAmount  string `xml:"PriceAmount"`
+	CurrencyCode string `xml:"CurrencyCode"`
+}
+
+func newONIXBookWriter(w io.Writer) (*onixBookWriter, error) {
+	if _, err := io.WriteString(w, xml.Header+`<ONIXMessage release="3.0">`); err != nil {
+		return nil, err
+	}
+
+	encoder := xml.NewEncoder(w)
+	header := onixHeader{SentDateTime: time.Now().UTC().Format("20060102T1504Z")}
+	if err := encoder.Encode(header); err != nil {
+		return nil, err
+	}
+
+	return &onixBookWriter{w: w, encoder: encoder}, nil
+}
+
+func (ow *onixBookWriter) Write(book entity.Book) error {
+	return ow.encoder.Encode(newONIXProduct(book))
+}
+
+func (ow *onixBookWriter) Close() error {
+	if err := ow.encoder.Flush(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(ow.w, "</ONIXMessage>\n")
+	return err
+}
+
+func newONIXProduct(book entity.Book) onixProduct {
+	product := onixProduct{
+		RecordReference:  book.ID,
+		NotificationType: "03", // notification confirmed on publication
+		ProductIdentifiers: []onixProductIdentifier{
+			{ProductIDType: "01", IDValue: book.ID}, // proprietary
+		},
+		DescriptiveDetail: onixDescriptiveDetail{
+			ProductComposition: "00", // single component
+			ProductForm:        "BA", // book
+			TitleDetail: onixTitleDetail{
+				TitleType:         "01", // distinctive title
+				TitleElementLevel: "01", // product
+				TitleText:         book.Title,
+			},
+		},
+		ProductSupply: onixProductSupply{
+			ProductAvailability: "31", // out of stock
+		},
+	}
+
+	switch len(book.ISBN) {
+	case 10:
+		product.ProductIdentifiers = append(product.ProductIdentifiers, onixProductIdentifier{ProductIDType: "02", IDValue: book.ISBN})
+	case 13:
+		product.ProductIdentifiers = append(product.ProductIdentifiers, onixProductIdentifier{ProductIDType: "15", IDValue: book.ISBN})
+	}
+
+	if book.Binding {
+		product.DescriptiveDetail.ProductForm = "BB" // hardback
+	}
+	if book.Author != "" {
+		product.DescriptiveDetail.Contributor = &onixContributor{SequenceNumber: 1, ContributorRole: "A01", PersonName: book.Author}
+	}
+	if book.Language != "" {
+		product.DescriptiveDetail.Language = &onixLanguage{LanguageRole: "01", LanguageCode: book.Language}
+	}
+	if book.Pages != 0 {
+		product.DescriptiveDetail.Extent = &onixExtent{ExtentType: "00", ExtentValue: book.Pages, ExtentUnit: "03"}
+	}
+	if book.Genre != "" {
+		product.DescriptiveDetail.Subject = &onixSubject{SubjectSchemeIdentifier: "20", SubjectHeadingText: book.Genre} // keywords
+	}
+	if book.Description != "" {
+		product.CollateralDetail = &onixCollateralDetail{TextType: "03", ContentAudience: "00", Text: book.Description}
+	}
+	if book.Publisher != "" {
+		product.PublishingDetail = &onixPublishingDetail{PublishingRole: "01", PublisherName: book.Publisher}
+	}
+	if book.Available > 0 {
+		product.ProductSupply.ProductAvailability = "21" // in stock
+	}
+	if book.Currency != "" {
+		product.ProductSupply.Price = &onixPrice{
+			PriceType:    "02", // RRP including tax
+			PriceAmount:  majorUnits(book.PriceAmount, book.Currency),
+			CurrencyCode: book.Currency,
+		}
+	}
+
+	return product
+}
+
+// minorUnitDigits lists the ISO 4217 currencies whose minor unit is not a
+// hundredth of the major one.
+var minorUnitDigits = map[string]int{
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+// majorUnits formats an amount in minor units as a decimal in major units,
+// which is how ONIX expects prices.
+func majorUnits(amount int64, currency string) string {
+	digits, ok := minorUnitDigits[currency]
+	if !ok {
+		digits = 2
+	}
+
+	text := strconv.FormatInt(amount, 10)
+	if digits == 0 {
+		return text
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign, text = "-", text[1:]
+	}
+	for len(text) <= digits {
+		text = "0" + text
+	}
+
+	return sign + text[:len(text)-digits] + "." + text[len(text)-digits:]
+}
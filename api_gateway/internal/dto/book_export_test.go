@@ -0,0 +1,108 @@
+package dto
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Levap123/api_gateway/internal/entity"
+)
+
+var exportedBooks = []entity.Book{
+	{
+		ID:          "64b7f0c2a1b2c3d4e5f60718",
+		Title:       "Dune",
+		Author:      "Frank Herbert",
+		Pages:       412,
+		ISBN:        "9780441013593",
+		ISBN10:      "0441013597",
+		Quantity:    3,
+		Available:   2,
+		PriceAmount: 1999,
+		Currency:    "USD",
+		AddedAt:     time.Date(2023, 7, 19, 0, 0, 0, 0, time.UTC),
+	},
+	{Title: "Emma, a novel", Author: "Jane Austen"},
+}
+
+func exportAll(t *testing.T, format string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer, err := NewBookWriter(format, &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, book := range exportedBooks {
+		if err := writer.Write(book); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return buf.String()
+}
+
+func TestExportCanBeImported(t *testing.T) {
+	for _, format := range []string{FormatCSV, FormatJSONL} {
+		t.Run(format, func(t *testing.T) {
+			reader, err := NewBookRowReader(format, strings.NewReader(exportAll(t, format)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			rows := readAll(t, reader)
+			if len(rows) != len(exportedBooks) {
+				t.Fatalf("got %d rows, want %d", len(rows), len(exportedBooks))
+			}
+			for i, row := range rows {
+				if row.Err != nil {
+					t.Fatalf("row %d rejected: %v", row.Row, row.Err)
+				}
+				want := exportedBooks[i]
+				if row.Book.Title != want.Title || row.Book.ISBN != want.ISBN || row.Book.PriceAmount != want.PriceAmount {
+					t.Errorf("got %+v, want %+v", row.Book, want)
+				}
+			}
+		})
+	}
+}
+
+func TestONIXExport(t *testing.T) {
+	onix := exportAll(t, FormatONIX)
+
+	for _, want := range []string{
+		`<ONIXMessage release="3.0">`,
+		`<ProductIDType>15</ProductIDType><IDValue>9780441013593</IDValue>`,
+		`<PriceAmount>19.99</PriceAmount><CurrencyCode>USD</CurrencyCode>`,
+		`<TitleText>Emma, a novel</TitleText>`,
+		`</ONIXMessage>`,
+	} {
+		if !strings.Contains(onix, want) {
+			t.Errorf("export does not contain %s", want)
+		}
+	}
+}
+
+func TestMajorUnits(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{1999, "USD", "19.99"},
+		{5, "EUR", "0.05"},
+		{1500, "JPY", "1500"},
+		{1234, "KWD", "1.234"},
+		{-250, "USD", "-2.50"},
+	}
+
+	for _, tt := range tests {
+		if got := majorUnits(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("majorUnits(%d, %s) = %s, want %s", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
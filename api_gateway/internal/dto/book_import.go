@@ -0,0 +1,203 @@
+package dto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+var ErrUnknownFormat = errors.New("unknown catalog format")
+
+// BookRow is one book of an imported file. Row counts books from 1, without
+// the CSV header. Err is set when the row itself is malformed, which rejects
+// only this row and not the whole import.
+type BookRow struct {
+	Row  uint32
+	Book CreateBookDTO
+	Err  error
+}
+
+// BookRowReader reads books from an imported file one at a time and returns
+// io.EOF after the last one.
+type BookRowReader interface {
+	Next() (BookRow, error)
+}
+
+func NewBookRowReader(format string, r io.Reader) (BookRowReader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVBookReader(r)
+	case FormatJSONL:
+		return &jsonlBookReader{scanner: newLineScanner(r)}, nil
+	default:
+		return nil, fmt.Errorf("%q - %w", format, ErrUnknownFormat)
+	}
+}
+
+// bookColumns maps CSV header names, which are the json names of
+// CreateBookDTO, to setters of the matching field. The read only columns of an
+// export are accepted and ignored, so that an export can be imported back.
+var bookColumns = map[string]func(book *CreateBookDTO, value string) error{
+	"id":        ignoreColumn,
+	"available": ignoreColumn,
+	"added_at":  ignoreColumn,
+
+	"title":       func(book *CreateBookDTO, value string) error { book.Title = value; return nil },
+	"description": func(book *CreateBookDTO, value string) error { book.Description = value; return nil },
+	"image":       func(book *CreateBookDTO, value string) error { book.Image = value; return nil },
+	"author":      func(book *CreateBookDTO, value string) error { book.Author = value; return nil },
+	"genre":       func(book *CreateBookDTO, value string) error { book.Genre = value; return nil },
+	"publisher":   func(book *CreateBookDTO, value string) error { book.Publisher = value; return nil },
+	"series":      func(book *CreateBookDTO, value string) error { book.Series = value; return nil },
+	"language":    func(book *CreateBookDTO, value string) error { book.Language = value; return nil },
+	"isbn":        func(book *CreateBookDTO, value string) error { book.ISBN = value; return nil },
+	"currency":    func(book *CreateBookDTO, value string) error { book.Currency = value; return nil },
+	"pages": func(book *CreateBookDTO, value string) (err error) {
+		book.Pages, err = strconv.ParseUint(value, 10, 64)
+		return err
+	},
+	"binding": func(book *CreateBookDTO, value string) (err error) {
+		book.Binding, err = strconv.ParseBool(value)
+		return err
+	},
+	"quantity": func(book *CreateBookDTO, value string) (err error) {
+		book.Quantity, err = strconv.ParseInt(value, 10, 64)
+		return err
+	},
+	"price_amount": func(book *CreateBookDTO, value string) (err error) {
+		book.PriceAmount, err = strconv.ParseInt(value, 10, 64)
+		return err
+	},
+}
+
+func ignoreColumn(*CreateBookDTO, string) error {
+	return nil
+}
+
+type csvBookReader struct {
+	reader  *csv.Reader
+	columns []string
+	row     uint32
+}
+
+func newCSVBookReader(r io.Reader) (*csvBookReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header - %w", err)
+	}
+
+	columns := make([]string, 0, len(header))
+	for _, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if _, ok := bookColumns[column]; !ok {
+			return nil, fmt.Errorf("unknown csv column %q", column)
+		}
+		columns = append(columns, column)
+	}
+
+	// The header fixes the number of fields of every record.
+	reader.FieldsPerRecord = len(columns)
+
+	return &csvBookReader{
+		reader:  reader,
+		columns: columns,
+	}, nil
+}
+
+func (cr *csvBookReader) Next() (BookRow, error) {
+	record, err := cr.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return BookRow{}, io.EOF
+	}
+
+	cr.row++
+	row := BookRow{Row: cr.row}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		row.Err = parseErr.Err
+		return row, nil
+	}
+	if err != nil {
+		return BookRow{}, err
+	}
+
+	for i, value := range record {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if err := bookColumns[cr.columns[i]](&row.Book, value); err != nil {
+			row.Err = fmt.Errorf("column %s: invalid value %q", cr.columns[i], value)
+			break
+		}
+	}
+
+	return row, nil
+}
+
+// maxLineSize bounds a single JSON Lines record.
+const maxLineSize = 1 << 20
+
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return scanner
+}
+
+// exportedBook accepts the read only fields of an exported book on top of the
+// ones a book is created with.
+type exportedBook struct {
+	CreateBookDTO
+	ID        string          `json:"id"`
+	ISBN10    string          `json:"isbn10"`
+	Available int64           `json:"available"`
+	AddedAt   json.RawMessage `json:"added_at"`
+}
+
+type jsonlBookReader struct {
+	scanner *bufio.Scanner
+	row     uint32
+}
+
+func (jr *jsonlBookReader) Next() (BookRow, error) {
+	for jr.scanner.Scan() {
+		line := bytes.TrimSpace(jr.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		jr.row++
+		row := BookRow{Row: jr.row}
+
+		var exported exportedBook
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&exported); err != nil {
+			row.Err = err
+		}
+		row.Book = exported.CreateBookDTO
+
+		return row, nil
+	}
+
+	if err := jr.scanner.Err(); err != nil {
+		return BookRow{}, err
+	}
+
+	return BookRow{}, io.EOF
+}
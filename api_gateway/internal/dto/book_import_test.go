@@ -0,0 +1,89 @@
+package dto
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func readAll(t *testing.T, reader BookRowReader) []BookRow {
+	t.Helper()
+
+	var rows []BookRow
+	for {
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestCSVBookReader(t *testing.T) {
+	body := "Title,Author,Pages,price_amount,currency\n" +
+		"Dune,Frank Herbert,412,1999,USD\n" +
+		"Emma,Jane Austen,many,,\n" +
+		"Ulysses,James Joyce\n"
+
+	reader, err := NewBookRowReader(FormatCSV, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows := readAll(t, reader)
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(rows))
+	}
+
+	dune := rows[0]
+	if dune.Err != nil || dune.Row != 1 || dune.Book.Title != "Dune" || dune.Book.Pages != 412 || dune.Book.PriceAmount != 1999 {
+		t.Errorf("unexpected first row: %+v", dune)
+	}
+	if rows[1].Err == nil {
+		t.Errorf("row with invalid pages should be rejected")
+	}
+	if rows[2].Err == nil {
+		t.Errorf("row with missing fields should be rejected")
+	}
+}
+
+func TestCSVBookReaderUnknownColumn(t *testing.T) {
+	if _, err := NewBookRowReader(FormatCSV, strings.NewReader("title,rating\n")); err == nil {
+		t.Errorf("header with unknown column should fail")
+	}
+}
+
+func TestJSONLBookReader(t *testing.T) {
+	body := `{"title": "Dune", "author": "Frank Herbert", "isbn": "9780441013593"}` + "\n\n" +
+		`{"title": "Emma", "rating": 5}` + "\n" +
+		`not json`
+
+	reader, err := NewBookRowReader(FormatJSONL, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows := readAll(t, reader)
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(rows))
+	}
+	if rows[0].Err != nil || rows[0].Book.ISBN != "9780441013593" {
+		t.Errorf("unexpected first row: %+v", rows[0])
+	}
+	if rows[1].Row != 2 || rows[1].Err == nil {
+		t.Errorf("row with unknown field should be rejected: %+v", rows[1])
+	}
+	if rows[2].Err == nil {
+		t.Errorf("malformed row should be rejected")
+	}
+}
+
+func TestNewBookRowReaderUnknownFormat(t *testing.T) {
+	if _, err := NewBookRowReader("xml", strings.NewReader("")); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("got %v, want %v", err, ErrUnknownFormat)
+	}
+}
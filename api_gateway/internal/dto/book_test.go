@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFromUpdateDtoToRequest(t *testing.T) {
+	title, pages, binding := "Dune", uint64(412), false
+	amount, currency := int64(0), "USD"
+
+	req := FromUpdateDtoToRequest("book-1", UpdateBookDTO{
+		Title:       &title,
+		Pages:       &pages,
+		Binding:     &binding,
+		PriceAmount: &amount,
+		Currency:    &currency,
+	})
+
+	wantPaths := []string{"title", "pages", "binding", "price_amount", "currency"}
+	if !reflect.DeepEqual(req.UpdateMask.Paths, wantPaths) {
+		t.Errorf("got paths %v, want %v", req.UpdateMask.Paths, wantPaths)
+	}
+
+	book := req.Book
+	if book.ID != "book-1" || book.Title != title || book.Pages != pages || book.Currency != currency {
+		t.Errorf("got book %+v", book)
+	}
+}
+
+func TestFromUpdateDtoToRequestEmpty(t *testing.T) {
+	req := FromUpdateDtoToRequest("book-1", UpdateBookDTO{})
+
+	if len(req.UpdateMask.Paths) != 0 {
+		t.Errorf("got paths %v for an empty update", req.UpdateMask.Paths)
+	}
+}
@@ -0,0 +1,23 @@
+package dto
+
+// CartOwner is whose cart a request is about: a signed in user or a guest
+// known by the cookie. Exactly one of the fields is set.
+type CartOwner struct {
+	UserID  uint64
+	GuestID string
+}
+
+type CartItemDTO struct {
+	BookID   string `json:"book_id"`
+	Quantity uint32 `json:"quantity"`
+}
+
+type CartQuantityDTO struct {
+	Quantity uint32 `json:"quantity"`
+}
+
+// CheckoutDTO orders the cart. Currency may be left empty to take the one the
+// books are priced in.
+type CheckoutDTO struct {
+	Currency string `json:"currency"`
+}
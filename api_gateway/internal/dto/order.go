@@ -0,0 +1,101 @@
+package dto
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Levap123/api_gateway/proto"
+)
+
+var (
+	ErrUnknownOrderStatus  = errors.New("unknown order status")
+	ErrUnknownCancelReason = errors.New("unknown cancel reason")
+)
+
+// OrderItemDTO is a book to order. UnitPrice is optional: when set, the order
+// is only made if the book still costs that much.
+type OrderItemDTO struct {
+	BookID    string `json:"book_id"`
+	Quantity  uint32 `json:"quantity"`
+	UnitPrice int64  `json:"unit_price,omitempty"`
+}
+
+type CreateOrderDTO struct {
+	Currency string         `json:"currency"`
+	Items    []OrderItemDTO `json:"items"`
+}
+
+// FromCreateOrderDtoToRequest makes the request of the user. The idempotency
+// key comes from the Idempotency-Key header and may be empty.
+func FromCreateOrderDtoToRequest(userID uint64, idempotencyKey string, dto CreateOrderDTO) *proto.CreateOrderRequest {
+	return &proto.CreateOrderRequest{
+		UserId:         userID,
+		Currency:       dto.Currency,
+		Items:          fromOrderItemDTOs(dto.Items),
+		IdempotencyKey: idempotencyKey,
+	}
+}
+
+// UpdateOrderItemsDTO replaces the items of an order that is not paid yet.
+// ExpectedVersion is the version of the order the client last read.
+type UpdateOrderItemsDTO struct {
+	Items           []OrderItemDTO `json:"items"`
+	ExpectedVersion uint64         `json:"expected_version"`
+}
+
+func FromUpdateOrderItemsDtoToRequest(orderID, userID uint64, dto UpdateOrderItemsDTO) *proto.UpdateOrderItemsRequest {
+	return &proto.UpdateOrderItemsRequest{
+		Id:              orderID,
+		UserId:          userID,
+		Items:           fromOrderItemDTOs(dto.Items),
+		ExpectedVersion: dto.ExpectedVersion,
+	}
+}
+
+func fromOrderItemDTOs(dtos []OrderItemDTO) []*proto.OrderItem {
+	items := make([]*proto.OrderItem, 0, len(dtos))
+	for _, item := range dtos {
+		items = append(items, &proto.OrderItem{
+			BookId:    item.BookID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		})
+	}
+	return items
+}
+
+// ChangeOrderStatusDTO moves an order to Status, one of paid, shipped,
+// delivered or refunded. Orders are cancelled with CancelOrderDTO instead. A
+// non-zero ExpectedVersion makes the change fail if the order changed since
+// the client read it.
+type ChangeOrderStatusDTO struct {
+	Status          string `json:"status"`
+	ExpectedVersion uint64 `json:"expected_version"`
+	Reason          string `json:"reason"`
+}
+
+// OrderStatusFromName turns paid into ORDER_STATUS_PAID.
+func OrderStatusFromName(name string) (proto.OrderStatus, error) {
+	value, ok := proto.OrderStatus_value["ORDER_STATUS_"+strings.ToUpper(strings.TrimSpace(name))]
+	if !ok || value == int32(proto.OrderStatus_ORDER_STATUS_UNSPECIFIED) {
+		return proto.OrderStatus_ORDER_STATUS_UNSPECIFIED, ErrUnknownOrderStatus
+	}
+	return proto.OrderStatus(value), nil
+}
+
+// CancelOrderDTO cancels an order for Reason, one of customer_request,
+// duplicate_order, payment_failed, out_of_stock, fraud_suspected or other.
+type CancelOrderDTO struct {
+	Reason          string `json:"reason"`
+	Comment         string `json:"comment"`
+	ExpectedVersion uint64 `json:"expected_version"`
+}
+
+// CancelReasonFromName turns duplicate_order into CANCEL_REASON_DUPLICATE_ORDER.
+func CancelReasonFromName(name string) (proto.CancelReason, error) {
+	value, ok := proto.CancelReason_value["CANCEL_REASON_"+strings.ToUpper(strings.TrimSpace(name))]
+	if !ok || value == int32(proto.CancelReason_CANCEL_REASON_UNSPECIFIED) {
+		return proto.CancelReason_CANCEL_REASON_UNSPECIFIED, ErrUnknownCancelReason
+	}
+	return proto.CancelReason(value), nil
+}
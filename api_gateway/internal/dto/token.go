@@ -4,3 +4,16 @@ type RefreshDTO struct {
 	AccessToken  string `json:"access_token,omitempty"`
 	RefreshToken string `json:"refresh_token,omitempty"`
 }
+
+type LogoutDTO struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type PasswordResetDTO struct {
+	Email string `json:"email"`
+}
+
+type ConfirmPasswordResetDTO struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
@@ -18,7 +18,18 @@ type Book struct {
 	Binding     bool      `json:"binding,omitempty"`
 	Series      string    `json:"series,omitempty"`
 	Language    string    `json:"language,omitempty"`
+	ISBN        string    `json:"isbn,omitempty"`
+	ISBN10      string    `json:"isbn10,omitempty"`
 	AddedAt     time.Time `json:"added_at,omitempty"`
+	Quantity    int64     `json:"quantity"`
+	Available   int64     `json:"available"`
+	PriceAmount int64     `json:"price_amount"`
+	Currency    string    `json:"currency,omitempty"`
+}
+
+type BookPage struct {
+	Books         []Book `json:"books"`
+	NextPageToken string `json:"next_page_token"`
 }
 
 func FromBookRequestToBook(req *proto.BookInfo) Book {
@@ -34,6 +45,82 @@ func FromBookRequestToBook(req *proto.BookInfo) Book {
 		Binding:     req.Binding,
 		Series:      req.Series,
 		Language:    req.Language,
+		ISBN:        req.Isbn,
+		ISBN10:      req.Isbn10,
 		AddedAt:     req.AddedAt.AsTime(),
+		Quantity:    req.Quantity,
+		Available:   req.Available,
+		PriceAmount: req.PriceAmount,
+		Currency:    req.Currency,
+	}
+}
+
+type Price struct {
+	BookID        string     `json:"book_id"`
+	Amount        int64      `json:"amount"`
+	Currency      string     `json:"currency"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty"`
+}
+
+func FromPriceResponseToPrice(resp *proto.Price) Price {
+	price := Price{
+		BookID:        resp.BookID,
+		Amount:        resp.Amount,
+		Currency:      resp.Currency,
+		EffectiveFrom: resp.EffectiveFrom.AsTime(),
+	}
+
+	if resp.EffectiveTo != nil {
+		effectiveTo := resp.EffectiveTo.AsTime()
+		price.EffectiveTo = &effectiveTo
+	}
+
+	return price
+}
+
+type ImportRow struct {
+	Row    uint32 `json:"row"`
+	Status string `json:"status"`
+	BookID string `json:"book_id,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type ImportReport struct {
+	Rows     []ImportRow `json:"rows"`
+	Created  uint32      `json:"created"`
+	Updated  uint32      `json:"updated"`
+	Rejected uint32      `json:"rejected"`
+	DryRun   bool        `json:"dry_run"`
+}
+
+func FromImportReportResponse(resp *proto.ImportReport) ImportReport {
+	report := ImportReport{
+		Rows:     make([]ImportRow, 0, len(resp.Rows)),
+		Created:  resp.Created,
+		Updated:  resp.Updated,
+		Rejected: resp.Rejected,
+		DryRun:   resp.DryRun,
 	}
+
+	for _, row := range resp.Rows {
+		report.Rows = append(report.Rows, ImportRow{
+			Row:    row.Row,
+			Status: row.Status,
+			BookID: row.BookID,
+			Reason: row.Reason,
+		})
+	}
+
+	return report
+}
+
+// Reject adds a row the gateway could not even parse to the report.
+func (r *ImportReport) Reject(row uint32, reason string) {
+	r.Rows = append(r.Rows, ImportRow{
+		Row:    row,
+		Status: "rejected",
+		Reason: reason,
+	})
+	r.Rejected++
 }
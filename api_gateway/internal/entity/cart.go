@@ -0,0 +1,84 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/Levap123/api_gateway/proto"
+)
+
+type CartItem struct {
+	BookID   string    `json:"book_id"`
+	Quantity uint32    `json:"quantity"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+type Cart struct {
+	UserID  uint64     `json:"user_id,omitempty"`
+	GuestID string     `json:"guest_id,omitempty"`
+	Items   []CartItem `json:"items"`
+}
+
+func FromCartResponse(resp *proto.CartInfo) Cart {
+	cart := Cart{
+		UserID:  resp.UserId,
+		GuestID: resp.GuestId,
+		Items:   make([]CartItem, 0, len(resp.Items)),
+	}
+
+	for _, item := range resp.Items {
+		cart.Items = append(cart.Items, CartItem{
+			BookID:   item.BookId,
+			Quantity: item.Quantity,
+			AddedAt:  item.AddedAt.AsTime(),
+		})
+	}
+
+	return cart
+}
+
+type MergedCartItem struct {
+	BookID   string `json:"book_id"`
+	Quantity uint32 `json:"quantity"`
+	Total    uint32 `json:"total"`
+}
+
+// DroppedCartItem is a book of the guest cart that did not make it into the
+// cart of the user. Reason is not_found, not_for_sale, out_of_stock or
+// limit_reached.
+type DroppedCartItem struct {
+	BookID   string `json:"book_id"`
+	Quantity uint32 `json:"quantity"`
+	Reason   string `json:"reason"`
+}
+
+// CartMergeReport tells what became of the guest cart on sign-in.
+type CartMergeReport struct {
+	Cart    Cart              `json:"cart"`
+	Merged  []MergedCartItem  `json:"merged"`
+	Dropped []DroppedCartItem `json:"dropped"`
+}
+
+func FromMergeReport(resp *proto.MergeReport) CartMergeReport {
+	report := CartMergeReport{
+		Cart:    FromCartResponse(resp.Cart),
+		Merged:  make([]MergedCartItem, 0, len(resp.Merged)),
+		Dropped: make([]DroppedCartItem, 0, len(resp.Dropped)),
+	}
+
+	for _, item := range resp.Merged {
+		report.Merged = append(report.Merged, MergedCartItem{
+			BookID:   item.BookId,
+			Quantity: item.Quantity,
+			Total:    item.Total,
+		})
+	}
+	for _, item := range resp.Dropped {
+		report.Dropped = append(report.Dropped, DroppedCartItem{
+			BookID:   item.BookId,
+			Quantity: item.Quantity,
+			Reason:   item.Reason,
+		})
+	}
+
+	return report
+}
@@ -0,0 +1,125 @@
+package entity
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Levap123/api_gateway/proto"
+)
+
+type OrderItem struct {
+	BookID    string `json:"book_id"`
+	Title     string `json:"title,omitempty"`
+	Quantity  uint32 `json:"quantity"`
+	UnitPrice int64  `json:"unit_price"`
+	Subtotal  int64  `json:"subtotal"`
+}
+
+type Order struct {
+	ID       uint64      `json:"id"`
+	UserID   uint64      `json:"user_id"`
+	AddedAt  time.Time   `json:"added_at"`
+	Status   string      `json:"status"`
+	Version  uint64      `json:"version"`
+	Items    []OrderItem `json:"items"`
+	Currency string      `json:"currency,omitempty"`
+	Total    int64       `json:"total"`
+}
+
+type Refund struct {
+	ID       uint64 `json:"id"`
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency,omitempty"`
+	Status   string `json:"status"`
+}
+
+// OrderCancellation is a cancelled order with what was done to make up for
+// it.
+type OrderCancellation struct {
+	Order         Order   `json:"order"`
+	StockReleased bool    `json:"stock_released"`
+	Refund        *Refund `json:"refund,omitempty"`
+}
+
+type OrderStatusEvent struct {
+	From    string    `json:"from,omitempty"`
+	To      string    `json:"to"`
+	ActorID uint64    `json:"actor_id"`
+	Reason  string    `json:"reason,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+type OrderHistory struct {
+	OrderID uint64             `json:"order_id"`
+	Events  []OrderStatusEvent `json:"events"`
+}
+
+// OrderStatusName turns ORDER_STATUS_PAID into paid, the name clients use.
+func OrderStatusName(status proto.OrderStatus) string {
+	if status == proto.OrderStatus_ORDER_STATUS_UNSPECIFIED {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(status.String(), "ORDER_STATUS_"))
+}
+
+func FromOrderResponse(resp *proto.Order) Order {
+	order := Order{
+		ID:       resp.Id,
+		UserID:   resp.UserId,
+		AddedAt:  resp.AddedAt.AsTime(),
+		Status:   OrderStatusName(resp.Status),
+		Version:  resp.Version,
+		Items:    make([]OrderItem, 0, len(resp.Items)),
+		Currency: resp.Currency,
+		Total:    resp.Total,
+	}
+
+	for _, item := range resp.Items {
+		order.Items = append(order.Items, OrderItem{
+			BookID:    item.BookId,
+			Title:     item.Title,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Subtotal:  item.Subtotal,
+		})
+	}
+
+	return order
+}
+
+func FromOrderHistoryResponse(resp *proto.OrderHistory) OrderHistory {
+	history := OrderHistory{
+		OrderID: resp.Id,
+		Events:  make([]OrderStatusEvent, 0, len(resp.Events)),
+	}
+
+	for _, event := range resp.Events {
+		history.Events = append(history.Events, OrderStatusEvent{
+			From:    OrderStatusName(event.From),
+			To:      OrderStatusName(event.To),
+			ActorID: event.ActorId,
+			Reason:  event.Reason,
+			At:      event.At.AsTime(),
+		})
+	}
+
+	return history
+}
+
+func FromCancelOrderResponse(resp *proto.CancelOrderResponse) OrderCancellation {
+	cancellation := OrderCancellation{
+		Order:         FromOrderResponse(resp.Order),
+		StockReleased: resp.StockReleased,
+	}
+
+	if resp.Refund != nil {
+		cancellation.Refund = &Refund{
+			ID:       resp.Refund.Id,
+			Amount:   resp.Refund.Amount,
+			Currency: resp.Refund.Currency,
+			Status:   resp.Refund.Status,
+		}
+	}
+
+	return cancellation
+}
@@ -1,8 +1,76 @@
 package entity
 
+import (
+	"time"
+
+	"github.com/Levap123/api_gateway/proto"
+)
+
 type User struct {
 	ID       uint64 `json:"id,omitempty"`
 	Email    string `json:"email,omitempty"`
 	Username string `json:"username,omitempty"`
 	Password string `json:"password,omitempty"`
 }
+
+// Identity is who an access token belongs to and what they may do.
+type Identity struct {
+	UserID        uint64
+	Roles         []string
+	Permissions   []string
+	EmailVerified bool
+}
+
+func (i Identity) HasRole(role string) bool {
+	for _, r := range i.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (i Identity) Can(permission string) bool {
+	for _, p := range i.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type UserRoles struct {
+	UserID uint64   `json:"user_id"`
+	Roles  []string `json:"roles"`
+}
+
+// Session is a sign-in of the user on some device.
+type Session struct {
+	ID              string     `json:"id"`
+	UserAgent       string     `json:"user_agent"`
+	IP              string     `json:"ip"`
+	CreatedAt       time.Time  `json:"created_at"`
+	LastRefreshedAt *time.Time `json:"last_refreshed_at,omitempty"`
+	Current         bool       `json:"current"`
+}
+
+func FromSessionsResponse(resp *proto.SessionsResponse) []Session {
+	sessions := make([]Session, 0, len(resp.Sessions))
+
+	for _, s := range resp.Sessions {
+		session := Session{
+			ID:        s.Id,
+			UserAgent: s.UserAgent,
+			IP:        s.Ip,
+			CreatedAt: s.CreatedAt.AsTime(),
+			Current:   s.Current,
+		}
+		if s.LastRefreshedAt != nil {
+			lastRefreshedAt := s.LastRefreshedAt.AsTime()
+			session.LastRefreshedAt = &lastRefreshedAt
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions
+}
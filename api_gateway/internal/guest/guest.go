@@ -0,0 +1,106 @@
+package guest
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CookieName is the cookie naming the cart of a guest who has not signed in.
+const CookieName = "guest_cart"
+
+// idBytes is the length of a guest ID before hex encoding. The order service
+// takes guest IDs of up to 64 characters.
+const idBytes = 16
+
+// Signer issues guest IDs in cookies signed with HMAC-SHA256, so clients
+// can't make up IDs to read the carts of other guests. The cookie value is
+// "<guest id>.<unix expiry>.<mac>".
+type Signer struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+func NewSigner(key string, ttl time.Duration) *Signer {
+	return &Signer{
+		key: []byte(key),
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// NewCookie makes up a guest ID and returns it with the cookie carrying it.
+func (s *Signer) NewCookie() (*http.Cookie, string, error) {
+	id := make([]byte, idBytes)
+	if _, err := rand.Read(id); err != nil {
+		return nil, "", fmt.Errorf("guest - new cookie - %w", err)
+	}
+	guestID := hex.EncodeToString(id)
+
+	expires := s.now().Add(s.ttl)
+	payload := guestID + "." + strconv.FormatInt(expires.Unix(), 10)
+
+	return &http.Cookie{
+		Name:     CookieName,
+		Value:    payload + "." + s.mac(payload),
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}, guestID, nil
+}
+
+// FromRequest returns the guest ID of the request cookie if its signature is
+// good and it has not expired.
+func (s *Signer) FromRequest(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(CookieName)
+	if err != nil {
+		return "", false
+	}
+	return s.Verify(cookie.Value)
+}
+
+// Verify returns the guest ID of a cookie value issued by NewCookie.
+func (s *Signer) Verify(value string) (string, bool) {
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 {
+		return "", false
+	}
+
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(s.mac(payload))) {
+		return "", false
+	}
+
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || s.now().Unix() >= expires {
+		return "", false
+	}
+
+	return parts[0], parts[0] != ""
+}
+
+// ClearCookie returns a cookie removing the guest cookie from the client.
+func (s *Signer) ClearCookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     CookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func (s *Signer) mac(payload string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,48 @@
+package guest
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigner(t *testing.T) {
+	now := time.Now()
+	signer := NewSigner("secret", time.Hour)
+	signer.now = func() time.Time { return now }
+
+	cookie, guestID, err := signer.NewCookie()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(guestID) != idBytes*2 {
+		t.Errorf("unexpected guest ID %q", guestID)
+	}
+
+	req := httptest.NewRequest("GET", "/api/cart", nil)
+	req.AddCookie(cookie)
+	if got, ok := signer.FromRequest(req); !ok || got != guestID {
+		t.Errorf("got %q, %v, want %q", got, ok, guestID)
+	}
+
+	other := "a"
+	if guestID[0] == 'a' {
+		other = "b"
+	}
+	forged := other + strings.TrimPrefix(cookie.Value, guestID[:1])
+	if _, ok := signer.Verify(forged); ok {
+		t.Error("changed guest ID should not verify")
+	}
+	if _, ok := NewSigner("other", time.Hour).Verify(cookie.Value); ok {
+		t.Error("cookie signed with another key should not verify")
+	}
+	if _, ok := signer.Verify(guestID); ok {
+		t.Error("unsigned guest ID should not verify")
+	}
+
+	signer.now = func() time.Time { return now.Add(time.Hour) }
+	if _, ok := signer.Verify(cookie.Value); ok {
+		t.Error("expired cookie should not verify")
+	}
+}
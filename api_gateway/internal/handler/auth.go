@@ -5,15 +5,30 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"net"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/Levap123/api_gateway/internal/dto"
+	"github.com/Levap123/api_gateway/internal/entity"
 	jsend "github.com/Levap123/api_gateway/pkg/json"
 	"github.com/Levap123/utils/apperror"
+	"google.golang.org/grpc/metadata"
 )
 
+// signUpResponse and signInResponse carry the report of the guest cart merged
+// into the cart of the user, if the client had one.
+type signUpResponse struct {
+	UserID    uint64                  `json:"user_id"`
+	CartMerge *entity.CartMergeReport `json:"cart_merge,omitempty"`
+}
+
+type signInResponse struct {
+	*entity.Tokens
+	CartMerge *entity.CartMergeReport `json:"cart_merge,omitempty"`
+}
+
 func (h *Handler) signUp(w http.ResponseWriter, r *http.Request) error {
 	h.log.Debug("user signup handler")
 	var dto dto.SignUpDTO
@@ -39,7 +54,12 @@ func (h *Handler) signUp(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	responseBytes := jsend.Marshal(map[string]uint64{"user_id": userID})
+	resp := signUpResponse{
+		UserID:    userID,
+		CartMerge: h.mergeGuestCart(w, r, userID),
+	}
+
+	responseBytes := jsend.Marshal(resp)
 	jsend.SendJSON(w, responseBytes, http.StatusOK)
 	return nil
 }
@@ -56,20 +76,30 @@ func (h *Handler) signIn(w http.ResponseWriter, r *http.Request) error {
 	defer r.Body.Close()
 
 	if err := json.Unmarshal(bytes, &dto); err != nil {
-		h.log.Error("error in unmarshalling: %v", err)
+		h.log.Errorf("error in unmarshalling: %v", err)
 		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
 	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), time.Second*2)
 	defer cancel()
 
-	tokens, err := h.apiClients.UserClient.SignIn(ctx, &dto)
+	tokens, err := h.apiClients.UserClient.SignIn(withClientInfo(ctx, r), &dto)
 	if err != nil {
 		h.log.Errorf("error in sending request to user service: %v", err)
 		return err
 	}
 
-	responseBytes := jsend.Marshal(tokens)
+	resp := signInResponse{Tokens: tokens}
+	if _, ok := h.guests.FromRequest(r); ok {
+		identity, err := h.apiClients.UserClient.Validate(ctx, tokens.Access)
+		if err != nil {
+			h.log.Errorf("error in validating new access token: %v", err)
+		} else {
+			resp.CartMerge = h.mergeGuestCart(w, r, identity.UserID)
+		}
+	}
+
+	responseBytes := jsend.Marshal(resp)
 	jsend.SendJSON(w, responseBytes, http.StatusOK)
 	return nil
 }
@@ -96,7 +126,7 @@ func (h *Handler) refresh(w http.ResponseWriter, r *http.Request) error {
 	defer r.Body.Close()
 
 	if err := json.Unmarshal(bytes, dto); err != nil {
-		h.log.Error("error in unmarshalling: %v", err)
+		h.log.Errorf("error in unmarshalling: %v", err)
 		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
 	}
 
@@ -112,3 +142,163 @@ func (h *Handler) refresh(w http.ResponseWriter, r *http.Request) error {
 	jsend.SendJSON(w, responseBytes, http.StatusOK)
 	return nil
 }
+
+// logout revokes the tokens of the sign-in the refresh token belongs to.
+func (h *Handler) logout(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("logout")
+	var dto dto.LogoutDTO
+
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.log.Errorf("error in reading request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+	defer r.Body.Close()
+
+	if err := json.Unmarshal(bytes, &dto); err != nil || dto.RefreshToken == "" {
+		h.log.Errorf("error in unmarshalling: %v", err)
+		return apperror.NewError(errors.New("refresh token is required"), "incorrect request body", http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*2)
+	defer cancel()
+
+	if err := h.apiClients.UserClient.Logout(ctx, dto.RefreshToken); err != nil {
+		h.log.Errorf("error in sending request to user service: %v", err)
+		return err
+	}
+
+	responseBytes := jsend.Marshal(map[string]bool{"logged_out": true})
+	jsend.SendJSON(w, responseBytes, http.StatusOK)
+	return nil
+}
+
+// requestPasswordReset has a reset token sent to the email. The response is
+// the same whether or not the email is signed up.
+func (h *Handler) requestPasswordReset(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("request password reset")
+	var dto dto.PasswordResetDTO
+
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.log.Errorf("error in reading request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+	defer r.Body.Close()
+
+	if err := json.Unmarshal(bytes, &dto); err != nil {
+		h.log.Errorf("error in unmarshalling: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*2)
+	defer cancel()
+
+	if err := h.apiClients.UserClient.RequestPasswordReset(ctx, dto.Email); err != nil {
+		h.log.Errorf("error in sending request to user service: %v", err)
+		return err
+	}
+
+	responseBytes := jsend.Marshal(map[string]bool{"requested": true})
+	jsend.SendJSON(w, responseBytes, http.StatusAccepted)
+	return nil
+}
+
+// confirmPasswordReset sets the new password with the reset token; every
+// session of the user is signed out.
+func (h *Handler) confirmPasswordReset(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("confirm password reset")
+	var dto dto.ConfirmPasswordResetDTO
+
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.log.Errorf("error in reading request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+	defer r.Body.Close()
+
+	if err := json.Unmarshal(bytes, &dto); err != nil || dto.Token == "" {
+		h.log.Errorf("error in unmarshalling: %v", err)
+		return apperror.NewError(errors.New("reset token is required"), "incorrect request body", http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*2)
+	defer cancel()
+
+	if err := h.apiClients.UserClient.ConfirmPasswordReset(ctx, dto.Token, dto.NewPassword); err != nil {
+		h.log.Errorf("error in sending request to user service: %v", err)
+		return err
+	}
+
+	responseBytes := jsend.Marshal(map[string]bool{"password_reset": true})
+	jsend.SendJSON(w, responseBytes, http.StatusOK)
+	return nil
+}
+
+// verifyEmail takes the token of the verification link, given by the token
+// query parameter.
+func (h *Handler) verifyEmail(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("verify email")
+
+	verificationToken := r.URL.Query().Get("token")
+	if verificationToken == "" {
+		return apperror.NewError(errors.New("verification token is required"), "token query parameter is required", http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*2)
+	defer cancel()
+
+	if err := h.apiClients.UserClient.VerifyEmail(ctx, verificationToken); err != nil {
+		h.log.Errorf("error in sending request to user service: %v", err)
+		return err
+	}
+
+	responseBytes := jsend.Marshal(map[string]bool{"email_verified": true})
+	jsend.SendJSON(w, responseBytes, http.StatusOK)
+	return nil
+}
+
+// resendVerification sends the signed in user a new verification link.
+func (h *Handler) resendVerification(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("resend verification")
+
+	authToken, ok := bearerToken(r)
+	if !ok {
+		return apperror.NewError(errors.New("auth header must be: Bearer <token>"), "invalid auth header", http.StatusUnauthorized)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*2)
+	defer cancel()
+
+	if err := h.apiClients.UserClient.ResendVerification(ctx, authToken); err != nil {
+		h.log.Errorf("error in sending request to user service: %v", err)
+		return err
+	}
+
+	responseBytes := jsend.Marshal(map[string]bool{"sent": true})
+	jsend.SendJSON(w, responseBytes, http.StatusAccepted)
+	return nil
+}
+
+// withClientInfo passes the user agent and the IP of the client to the user
+// service, which records them with the session.
+func withClientInfo(ctx context.Context, r *http.Request) context.Context {
+	return metadata.AppendToOutgoingContext(ctx,
+		"x-client-user-agent", r.UserAgent(),
+		"x-client-ip", clientIP(r),
+	)
+}
+
+// clientIP takes the first address of X-Forwarded-For, set by a proxy in
+// front of the gateway, and the address of the peer otherwise.
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
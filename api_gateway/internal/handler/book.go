@@ -3,8 +3,15 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Levap123/api_gateway/internal/dto"
@@ -45,29 +52,339 @@ func (h *Handler) createBook(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+func (h *Handler) updateBook(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("update book")
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.log.Errorf("error in reading request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+
+	var updateBookDTO dto.UpdateBookDTO
+	if err := json.Unmarshal(reqBytes, &updateBookDTO); err != nil {
+		h.log.Errorf("error in unmarshalling request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+	defer cancel()
+
+	params := httprouter.ParamsFromContext(r.Context())
+
+	book, err := h.apiClients.BookClient.Update(ctx, params.ByName("book_id"), updateBookDTO)
+	if err != nil {
+		h.log.Errorf("error in updating book: %v", err)
+		return err
+	}
+
+	respBytes := jsend.Marshal(book)
+
+	jsend.SendJSON(w, respBytes, http.StatusOK)
+
+	return nil
+}
+
+func (h *Handler) setBookStock(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("set book stock")
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.log.Errorf("error in reading request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+
+	var stockDTO dto.StockDTO
+	if err := json.Unmarshal(reqBytes, &stockDTO); err != nil {
+		h.log.Errorf("error in unmarshalling request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+	defer cancel()
+
+	params := httprouter.ParamsFromContext(r.Context())
+
+	book, err := h.apiClients.BookClient.SetStock(ctx, params.ByName("book_id"), stockDTO.Quantity)
+	if err != nil {
+		h.log.Errorf("error in setting book stock: %v", err)
+		return err
+	}
+
+	respBytes := jsend.Marshal(book)
+
+	jsend.SendJSON(w, respBytes, http.StatusOK)
+
+	return nil
+}
+
+// getBookPrice returns the price in effect at the time given in the at query
+// parameter, in RFC 3339, or the current price without it.
+func (h *Handler) getBookPrice(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("get book price")
+
+	at := time.Now()
+	if value := r.URL.Query().Get("at"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return apperror.NewError(err, "at must be a RFC 3339 time", http.StatusBadRequest)
+		}
+		at = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+	defer cancel()
+
+	params := httprouter.ParamsFromContext(r.Context())
+
+	price, err := h.apiClients.BookClient.GetPriceAt(ctx, params.ByName("book_id"), at)
+	if err != nil {
+		h.log.Errorf("error in getting book price: %v", err)
+		return err
+	}
+
+	respBytes := jsend.Marshal(price)
+
+	jsend.SendJSON(w, respBytes, http.StatusOK)
+
+	return nil
+}
+
+// maxImportSize bounds the body of an import request.
+const maxImportSize = 32 << 20
+
+// importBooks streams the books of a CSV or JSON Lines body to the book
+// service. The format comes from the format query parameter or the content
+// type, and dry_run=true validates the books without writing them.
+func (h *Handler) importBooks(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("import books")
+
+	params := r.URL.Query()
+
+	dryRun := false
+	if value := params.Get("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return apperror.NewError(err, "dry_run must be a boolean", http.StatusBadRequest)
+		}
+		dryRun = parsed
+	}
+
+	format := params.Get("format")
+	if format == "" {
+		format = importFormat(r.Header.Get("Content-Type"))
+	}
+
+	reader, err := dto.NewBookRowReader(format, http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		h.log.Errorf("error in reading import: %v", err)
+		if errors.Is(err, dto.ErrUnknownFormat) {
+			return apperror.NewError(err, "import must be csv or jsonl", http.StatusUnsupportedMediaType)
+		}
+		return apperror.NewError(err, err.Error(), http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Minute)
+	defer cancel()
+
+	bookImport, err := h.apiClients.BookClient.ImportBooks(ctx, dryRun)
+	if err != nil {
+		h.log.Errorf("error in importing books: %v", err)
+		return err
+	}
+
+	var rejected []entity.ImportRow
+
+	for {
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			h.log.Errorf("error in reading import: %v", err)
+			return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+		}
+
+		if row.Err != nil {
+			rejected = append(rejected, entity.ImportRow{Row: row.Row, Reason: row.Err.Error()})
+			continue
+		}
+
+		// The service has ended the import; Close tells why.
+		if err := bookImport.Send(row.Row, row.Book); err != nil {
+			break
+		}
+	}
+
+	report, err := bookImport.Close()
+	if err != nil {
+		h.log.Errorf("error in importing books: %v", err)
+		return err
+	}
+
+	for _, row := range rejected {
+		report.Reject(row.Row, row.Reason)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		return report.Rows[i].Row < report.Rows[j].Row
+	})
+
+	respBytes := jsend.Marshal(report)
+
+	jsend.SendJSON(w, respBytes, http.StatusOK)
+
+	return nil
+}
+
+func importFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch mediaType {
+	case "text/csv":
+		return dto.FormatCSV
+	case "application/jsonl", "application/x-ndjson", "application/x-jsonlines":
+		return dto.FormatJSONL
+	default:
+		return mediaType
+	}
+}
+
+// exportFlushEvery is how many exported books are buffered before they are
+// flushed to the client.
+const exportFlushEvery = 100
+
+// exportBooks streams the books matching the same filters as getAllBoks as
+// CSV, JSON Lines or ONIX XML. The format comes from the format query
+// parameter or the Accept header and defaults to JSON Lines.
+func (h *Handler) exportBooks(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("export books")
+
+	params := r.URL.Query()
+
+	format := params.Get("format")
+	if format == "" {
+		format = exportFormat(r.Header.Get("Accept"))
+	}
+
+	contentType, ok := dto.ExportContentTypes[format]
+	if !ok {
+		return apperror.NewError(dto.ErrUnknownFormat, "export must be csv, jsonl or onix", http.StatusNotAcceptable)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+	defer cancel()
+
+	export, err := h.apiClients.BookClient.ExportBooks(ctx, params)
+	if err != nil {
+		h.log.Errorf("error in exporting books: %v", err)
+		return err
+	}
+
+	// Errors are still reported properly until the first book is received;
+	// after that the response has started and they can only be logged.
+	book, err := export.Next()
+	if err != nil && !errors.Is(err, io.EOF) {
+		h.log.Errorf("error in exporting books: %v", err)
+		return err
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=books.%s", exportExtensions[format]))
+	w.WriteHeader(http.StatusOK)
+
+	writer, err := dto.NewBookWriter(format, w)
+	if err != nil {
+		h.log.Errorf("error in writing export: %v", err)
+		return nil
+	}
+
+	flusher, _ := w.(http.Flusher)
+
+	for count := 1; err == nil; count++ {
+		if err := writer.Write(book); err != nil {
+			h.log.Errorf("error in writing export: %v", err)
+			return nil
+		}
+
+		if flusher != nil && count%exportFlushEvery == 0 {
+			flusher.Flush()
+		}
+
+		book, err = export.Next()
+	}
+
+	if !errors.Is(err, io.EOF) {
+		h.log.Errorf("error in exporting books: %v", err)
+		return nil
+	}
+
+	if err := writer.Close(); err != nil {
+		h.log.Errorf("error in writing export: %v", err)
+	}
+
+	return nil
+}
+
+var exportExtensions = map[string]string{
+	dto.FormatCSV:   "csv",
+	dto.FormatJSONL: "jsonl",
+	dto.FormatONIX:  "xml",
+}
+
+// exportFormat picks the first format the Accept header lists.
+func exportFormat(accept string) string {
+	if strings.TrimSpace(accept) == "" {
+		return dto.FormatJSONL
+	}
+
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(mediaRange)
+		if err != nil {
+			continue
+		}
+
+		switch mediaType {
+		case "text/csv":
+			return dto.FormatCSV
+		case "application/jsonl", "application/x-ndjson", "application/x-jsonlines", "*/*", "application/*":
+			return dto.FormatJSONL
+		case "application/xml", "text/xml", "application/onix+xml":
+			return dto.FormatONIX
+		}
+	}
+
+	return accept
+}
+
+// filterParams are the query parameters that make getAllBoks filter books.
+var filterParams = []string{"author", "genre", "language", "publisher"}
+
 func (h *Handler) getAllBoks(w http.ResponseWriter, r *http.Request) error {
 	h.log.Debug("get all books")
 
 	params := r.URL.Query()
 
+	pageDTO, err := pageFromQuery(params)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), time.Second*2)
 	defer cancel()
 
-	var books []entity.Book
+	var books entity.BookPage
 
-	if len(params) != 0 {
+	if hasAnyParam(params, filterParams) {
 		h.log.Debug("go to filtering")
 
-		var err error
-		books, err = h.apiClients.BookClient.GetByFiltering(ctx, params)
+		books, err = h.apiClients.BookClient.GetByFiltering(ctx, params, pageDTO)
 		if err != nil {
 			h.log.Errorf("error in getting books by filter: %v", err)
 			return err
 		}
 
 	} else {
-		var err error
-		books, err = h.apiClients.BookClient.GetAll(ctx)
+		books, err = h.apiClients.BookClient.GetAll(ctx, pageDTO)
 		if err != nil {
 			h.log.Errorf("error in getting all books: %v", err)
 			return err
@@ -81,6 +398,71 @@ func (h *Handler) getAllBoks(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// pageFromQuery reads page_size, page_token, sort_by and order (asc or desc).
+func pageFromQuery(params url.Values) (dto.PageDTO, error) {
+	pageDTO := dto.PageDTO{
+		PageToken: params.Get("page_token"),
+		SortBy:    params.Get("sort_by"),
+	}
+
+	if pageSize := params.Get("page_size"); pageSize != "" {
+		size, err := strconv.ParseUint(pageSize, 10, 32)
+		if err != nil {
+			return dto.PageDTO{}, apperror.NewError(err, "page_size must be a positive number", http.StatusBadRequest)
+		}
+		pageDTO.PageSize = uint32(size)
+	}
+
+	switch params.Get("order") {
+	case "", "asc":
+	case "desc":
+		pageDTO.Descending = true
+	default:
+		return dto.PageDTO{}, apperror.NewError(errors.New("invalid order"), "order must be asc or desc", http.StatusBadRequest)
+	}
+
+	return pageDTO, nil
+}
+
+func hasAnyParam(params url.Values, keys []string) bool {
+	for _, key := range keys {
+		if params.Has(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) searchBooks(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("search books")
+
+	params := r.URL.Query()
+
+	var limit uint32
+	if limitParam := params.Get("limit"); limitParam != "" {
+		parsed, err := strconv.ParseUint(limitParam, 10, 32)
+		if err != nil {
+			return apperror.NewError(err, "limit must be a positive number", http.StatusBadRequest)
+		}
+		limit = uint32(parsed)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*2)
+	defer cancel()
+
+	books, err := h.apiClients.BookClient.Search(ctx, params.Get("q"), limit)
+	if err != nil {
+		h.log.Errorf("error in searching books: %v", err)
+		return err
+	}
+
+	reqBytes := jsend.Marshal(books)
+
+	jsend.SendJSON(w, reqBytes, http.StatusOK)
+
+	return nil
+}
+
 func (h *Handler) getBookByID(w http.ResponseWriter, r *http.Request) error {
 	h.log.Debug(("get book by ID"))
 
@@ -103,3 +485,47 @@ func (h *Handler) getBookByID(w http.ResponseWriter, r *http.Request) error {
 
 	return nil
 }
+
+func (h *Handler) getBookByISBN(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("get book by ISBN")
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+	defer cancel()
+
+	params := httprouter.ParamsFromContext(r.Context())
+
+	book, err := h.apiClients.BookClient.GetByISBN(ctx, params.ByName("isbn"))
+	if err != nil {
+		h.log.Errorf("error in getting book by ISBN: %v", err)
+		return err
+	}
+
+	respBytes := jsend.Marshal(book)
+
+	jsend.SendJSON(w, respBytes, http.StatusOK)
+
+	return nil
+}
+
+func (h *Handler) getBooksBy(getBooks func(ctx context.Context, name string) ([]entity.Book, error)) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		h.log.Debug("get books by field")
+
+		ctx, cancel := context.WithTimeout(r.Context(), time.Second*2)
+		defer cancel()
+
+		params := httprouter.ParamsFromContext(r.Context())
+
+		books, err := getBooks(ctx, params.ByName("name"))
+		if err != nil {
+			h.log.Errorf("error in getting books by field: %v", err)
+			return err
+		}
+
+		reqBytes := jsend.Marshal(books)
+
+		jsend.SendJSON(w, reqBytes, http.StatusOK)
+
+		return nil
+	}
+}
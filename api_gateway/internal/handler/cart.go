@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Levap123/api_gateway/internal/dto"
+	"github.com/Levap123/api_gateway/internal/entity"
+	jsend "github.com/Levap123/api_gateway/pkg/json"
+	"github.com/Levap123/utils/apperror"
+	"github.com/julienschmidt/httprouter"
+)
+
+func (h *Handler) getCart(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("get cart")
+
+	owner, ok := cartOwnerFromContext(r.Context())
+	if !ok {
+		return apperror.NewError(errNoUser, "unauthorized", http.StatusUnauthorized)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+	defer cancel()
+
+	cart, err := h.apiClients.CartClient.Get(ctx, owner)
+	if err != nil {
+		h.log.Errorf("error in getting cart: %v", err)
+		return err
+	}
+
+	respBytes := jsend.Marshal(cart)
+
+	jsend.SendJSON(w, respBytes, http.StatusOK)
+
+	return nil
+}
+
+func (h *Handler) addCartItem(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("add cart item")
+
+	owner, ok := cartOwnerFromContext(r.Context())
+	if !ok {
+		return apperror.NewError(errNoUser, "unauthorized", http.StatusUnauthorized)
+	}
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.log.Errorf("error in reading request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+
+	var itemDTO dto.CartItemDTO
+	if err := json.Unmarshal(reqBytes, &itemDTO); err != nil {
+		h.log.Errorf("error in unmarshalling request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+	defer cancel()
+
+	cart, err := h.apiClients.CartClient.AddItem(ctx, owner, itemDTO)
+	if err != nil {
+		h.log.Errorf("error in adding cart item: %v", err)
+		return err
+	}
+
+	respBytes := jsend.Marshal(cart)
+
+	jsend.SendJSON(w, respBytes, http.StatusOK)
+
+	return nil
+}
+
+func (h *Handler) setCartItemQuantity(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("set cart item quantity")
+
+	owner, ok := cartOwnerFromContext(r.Context())
+	if !ok {
+		return apperror.NewError(errNoUser, "unauthorized", http.StatusUnauthorized)
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	bookID := params.ByName("book_id")
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.log.Errorf("error in reading request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+
+	var quantityDTO dto.CartQuantityDTO
+	if err := json.Unmarshal(reqBytes, &quantityDTO); err != nil {
+		h.log.Errorf("error in unmarshalling request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+	defer cancel()
+
+	cart, err := h.apiClients.CartClient.SetQuantity(ctx, owner, bookID, quantityDTO.Quantity)
+	if err != nil {
+		h.log.Errorf("error in setting cart item quantity: %v", err)
+		return err
+	}
+
+	respBytes := jsend.Marshal(cart)
+
+	jsend.SendJSON(w, respBytes, http.StatusOK)
+
+	return nil
+}
+
+func (h *Handler) removeCartItem(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("remove cart item")
+
+	owner, ok := cartOwnerFromContext(r.Context())
+	if !ok {
+		return apperror.NewError(errNoUser, "unauthorized", http.StatusUnauthorized)
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	bookID := params.ByName("book_id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+	defer cancel()
+
+	cart, err := h.apiClients.CartClient.RemoveItem(ctx, owner, bookID)
+	if err != nil {
+		h.log.Errorf("error in removing cart item: %v", err)
+		return err
+	}
+
+	respBytes := jsend.Marshal(cart)
+
+	jsend.SendJSON(w, respBytes, http.StatusOK)
+
+	return nil
+}
+
+// checkoutCart orders the cart of the signed in user. Guests have to sign in
+// first, which merges their cart into the cart of the user. Like createOrder, it
+// honours the Idempotency-Key header.
+func (h *Handler) checkoutCart(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("checkout cart")
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		return apperror.NewError(errNoUser, "unauthorized", http.StatusUnauthorized)
+	}
+
+	var checkoutDTO dto.CheckoutDTO
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.log.Errorf("error in reading request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+	if len(reqBytes) != 0 {
+		if err := json.Unmarshal(reqBytes, &checkoutDTO); err != nil {
+			h.log.Errorf("error in unmarshalling request: %v", err)
+			return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*2)
+	defer cancel()
+
+	idempotencyKey := strings.TrimSpace(r.Header.Get(idempotencyKeyHeader))
+
+	orderID, err := h.apiClients.CartClient.Checkout(ctx, userID, idempotencyKey, checkoutDTO)
+	if err != nil {
+		h.log.Errorf("error in checking out cart: %v", err)
+		return err
+	}
+
+	respBytes := jsend.Marshal(map[string]uint64{"order_id": orderID})
+
+	jsend.SendJSON(w, respBytes, http.StatusOK)
+
+	return nil
+}
+
+// mergeGuestCart moves the cart of the guest cookie, if the request has one,
+// into the cart of the user who has just signed in and drops the cookie.
+// Failures are only logged: a cart must not keep anyone from signing in, and
+// the guest cart stays to be merged on the next sign-in.
+func (h *Handler) mergeGuestCart(w http.ResponseWriter, r *http.Request, userID uint64) *entity.CartMergeReport {
+	guestID, ok := h.guests.FromRequest(r)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*2)
+	defer cancel()
+
+	report, err := h.apiClients.CartClient.MergeGuestCart(ctx, guestID, userID)
+	if err != nil {
+		h.log.Errorf("error in merging guest cart into cart of user %d: %v", userID, err)
+		return nil
+	}
+
+	http.SetCookie(w, h.guests.ClearCookie())
+	return &report
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	apiclients "github.com/Levap123/api_gateway/internal/api_clients"
+	"github.com/Levap123/api_gateway/internal/guest"
 
 	"github.com/sirupsen/logrus"
 )
@@ -9,11 +10,22 @@ import (
 type Handler struct {
 	log        *logrus.Logger
 	apiClients *apiclients.ApiClients
+	guests     *guest.Signer
+	cfg        Config
 }
 
-func NewHandler(log *logrus.Logger, apiClients *apiclients.ApiClients) *Handler {
+// Config is what Handler takes from the config file.
+type Config struct {
+	// RequireVerifiedEmail keeps users who haven't verified their email from
+	// placing orders.
+	RequireVerifiedEmail bool
+}
+
+func NewHandler(log *logrus.Logger, apiClients *apiclients.ApiClients, guests *guest.Signer, cfg Config) *Handler {
 	return &Handler{
 		log:        log,
 		apiClients: apiClients,
+		guests:     guests,
+		cfg:        cfg,
 	}
 }
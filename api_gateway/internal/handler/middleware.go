@@ -7,29 +7,36 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Levap123/api_gateway/internal/dto"
+	"github.com/Levap123/api_gateway/internal/entity"
 	"github.com/Levap123/api_gateway/pkg/json"
 	"github.com/Levap123/utils/apperror"
 )
 
+// Roles and permissions granted by the user service.
+const (
+	roleAdmin = "admin"
+
+	permissionManageCatalog = "catalog:manage"
+	permissionManageOrders  = "orders:manage"
+)
+
 func (h *Handler) UserIdentity(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		h.log.Debug("refresh")
 
-		authHeader := r.Header.Get("Authorization")
-		authHeaderSplit := strings.Split(authHeader, "Bearer ")
-		if len(authHeaderSplit) != 2 {
+		authToken, ok := bearerToken(r)
+		if !ok {
 			err := apperror.NewError(errors.New("auth header must be: Bearer <token>"), "invalid auth header", http.StatusUnauthorized)
 			bytes := json.Marshal(err)
 			json.SendJSON(w, bytes, http.StatusUnauthorized)
 			return
 		}
 
-		authToken := authHeaderSplit[1]
-
 		ctx, cancel := context.WithTimeout(r.Context(), time.Second*1)
 		defer cancel()
 
-		userID, err := h.apiClients.UserClient.Validate(ctx, authToken)
+		identity, err := h.apiClients.UserClient.Validate(ctx, authToken)
 		if err != nil {
 			err := apperror.NewError(err, "error in validating token", http.StatusUnauthorized)
 			bytes := json.Marshal(err)
@@ -37,47 +44,153 @@ func (h *Handler) UserIdentity(next http.Handler) http.Handler {
 			return
 		}
 
-		ctxWithValue := context.WithValue(r.Context(), "user_id", userID)
+		ctxWithValue := context.WithValue(r.Context(), "user_id", identity.UserID)
+		ctxWithValue = context.WithValue(ctxWithValue, "identity", identity)
 
 		next.ServeHTTP(w, r.WithContext(ctxWithValue))
 	})
 }
 
-func (h *Handler) AdminMiddleware(next http.Handler) http.Handler {
+// CartIdentity lets guests use a cart before signing in. Requests with an
+// auth header are checked like in UserIdentity. Others get the guest ID of
+// their signed cookie, and a new cookie is issued when they have none.
+func (h *Handler) CartIdentity(next http.Handler) http.Handler {
+	userIdentity := h.UserIdentity(next)
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		h.log.Debug("admin middleware")
+		h.log.Debug("cart identity")
 
-		authHeader := r.Header.Get("Authorization")
-		authHeaderSplit := strings.Split(authHeader, "Bearer ")
-		if len(authHeaderSplit) != 2 {
-			err := apperror.NewError(errors.New("auth header must be: Bearer <token>"), "invalid auth header", http.StatusUnauthorized)
-			bytes := json.Marshal(err)
-			json.SendJSON(w, bytes, http.StatusUnauthorized)
+		if r.Header.Get("Authorization") != "" {
+			userIdentity.ServeHTTP(w, r)
 			return
 		}
 
-		authToken := authHeaderSplit[1]
+		guestID, ok := h.guests.FromRequest(r)
+		if !ok {
+			cookie, newGuestID, err := h.guests.NewCookie()
+			if err != nil {
+				h.log.Errorf("error in issuing guest cookie: %v", err)
+				err := apperror.NewError(err, "internal server error", http.StatusInternalServerError)
+				bytes := json.Marshal(err)
+				json.SendJSON(w, bytes, http.StatusInternalServerError)
+				return
+			}
+
+			http.SetCookie(w, cookie)
+			guestID = newGuestID
+		}
 
-		ctx, cancel := context.WithTimeout(r.Context(), time.Second*1)
-		defer cancel()
+		ctxWithValue := context.WithValue(r.Context(), "guest_id", guestID)
 
-		userID, err := h.apiClients.UserClient.Validate(ctx, authToken)
-		if err != nil {
-			err := apperror.NewError(err, "error in validating token", http.StatusUnauthorized)
+		next.ServeHTTP(w, r.WithContext(ctxWithValue))
+	})
+}
+
+// RequireRole lets through users having any of the roles. It goes after
+// UserIdentity.
+func (h *Handler) RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return h.require(func(identity entity.Identity) bool {
+		for _, role := range roles {
+			if identity.HasRole(role) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// RequirePermission lets through users whose roles grant the permission. It
+// goes after UserIdentity.
+func (h *Handler) RequirePermission(permission string) func(http.Handler) http.Handler {
+	return h.require(func(identity entity.Identity) bool {
+		return identity.Can(permission)
+	})
+}
+
+// RequireVerifiedEmail lets through users who verified their email, when
+// the config asks for it. It goes after UserIdentity.
+func (h *Handler) RequireVerifiedEmail(next http.Handler) http.Handler {
+	if !h.cfg.RequireVerifiedEmail {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := identityFromContext(r.Context())
+		if !ok {
+			err := apperror.NewError(errNoUser, "unauthorized", http.StatusUnauthorized)
 			bytes := json.Marshal(err)
 			json.SendJSON(w, bytes, http.StatusUnauthorized)
 			return
 		}
 
-		if userID != 1 {
-			err := apperror.NewError(errors.New("invalid user"), "you are not admin", http.StatusUnauthorized)
+		if !identity.EmailVerified {
+			err := apperror.NewError(errors.New("email is not verified"), "verify your email to place orders", http.StatusForbidden)
 			bytes := json.Marshal(err)
-			json.SendJSON(w, bytes, http.StatusUnauthorized)
+			json.SendJSON(w, bytes, http.StatusForbidden)
 			return
 		}
 
-		ctxWithValue := context.WithValue(r.Context(), "user_id", userID)
-
-		next.ServeHTTP(w, r.WithContext(ctxWithValue))
+		next.ServeHTTP(w, r)
 	})
 }
+
+func (h *Handler) require(allowed func(entity.Identity) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, ok := identityFromContext(r.Context())
+			if !ok {
+				err := apperror.NewError(errNoUser, "unauthorized", http.StatusUnauthorized)
+				bytes := json.Marshal(err)
+				json.SendJSON(w, bytes, http.StatusUnauthorized)
+				return
+			}
+
+			if !allowed(identity) {
+				err := apperror.NewError(errors.New("permission denied"), "you are not allowed to do this", http.StatusForbidden)
+				bytes := json.Marshal(err)
+				json.SendJSON(w, bytes, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	authHeaderSplit := strings.Split(r.Header.Get("Authorization"), "Bearer ")
+	if len(authHeaderSplit) != 2 {
+		return "", false
+	}
+	return authHeaderSplit[1], true
+}
+
+var errNoUser = errors.New("no user in context")
+
+// userIDFromContext returns the user that UserIdentity authenticated.
+func userIDFromContext(ctx context.Context) (uint64, bool) {
+	userID, ok := ctx.Value("user_id").(uint64)
+	return userID, ok
+}
+
+func identityFromContext(ctx context.Context) (entity.Identity, bool) {
+	identity, ok := ctx.Value("identity").(entity.Identity)
+	return identity, ok
+}
+
+// canManageOrders tells whether the user may see and change orders of others.
+func canManageOrders(ctx context.Context) bool {
+	identity, _ := identityFromContext(ctx)
+	return identity.Can(permissionManageOrders)
+}
+
+// cartOwnerFromContext returns the user or the guest that CartIdentity let
+// through.
+func cartOwnerFromContext(ctx context.Context) (dto.CartOwner, bool) {
+	if userID, ok := userIDFromContext(ctx); ok {
+		return dto.CartOwner{UserID: userID}, true
+	}
+
+	guestID, ok := ctx.Value("guest_id").(string)
+	return dto.CartOwner{GuestID: guestID}, ok
+}
@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Levap123/api_gateway/internal/dto"
+	"github.com/Levap123/api_gateway/internal/entity"
+	jsend "github.com/Levap123/api_gateway/pkg/json"
+	"github.com/Levap123/api_gateway/proto"
+	"github.com/Levap123/utils/apperror"
+	"github.com/julienschmidt/httprouter"
+)
+
+var errOrderNotFound = errors.New("order not found")
+
+// idempotencyKeyHeader carries a key that makes retries of a create request
+// return the order of the first one instead of making another.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// createOrder places an order on behalf of the signed in user.
+func (h *Handler) createOrder(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("create order")
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		return apperror.NewError(errNoUser, "unauthorized", http.StatusUnauthorized)
+	}
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.log.Errorf("error in reading request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+
+	var createOrderDTO dto.CreateOrderDTO
+	if err := json.Unmarshal(reqBytes, &createOrderDTO); err != nil {
+		h.log.Errorf("error in unmarshalling request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+	defer cancel()
+
+	idempotencyKey := strings.TrimSpace(r.Header.Get(idempotencyKeyHeader))
+
+	orderID, err := h.apiClients.OrderClient.Create(ctx, userID, idempotencyKey, createOrderDTO)
+	if err != nil {
+		h.log.Errorf("error in creating order: %v", err)
+		return err
+	}
+
+	respBytes := jsend.Marshal(map[string]uint64{"order_id": orderID})
+
+	jsend.SendJSON(w, respBytes, http.StatusOK)
+
+	return nil
+}
+
+// getOrders returns the orders of the signed in user, optionally only those
+// in the status given by the status parameter.
+func (h *Handler) getOrders(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("get orders")
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		return apperror.NewError(errNoUser, "unauthorized", http.StatusUnauthorized)
+	}
+
+	orderStatus := proto.OrderStatus_ORDER_STATUS_UNSPECIFIED
+	if name := r.URL.Query().Get("status"); name != "" {
+		var err error
+		orderStatus, err = dto.OrderStatusFromName(name)
+		if err != nil {
+			return apperror.NewError(err, "unknown order status", http.StatusBadRequest)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+	defer cancel()
+
+	orders, err := h.apiClients.OrderClient.GetByUserID(ctx, userID, orderStatus)
+	if err != nil {
+		h.log.Errorf("error in getting orders: %v", err)
+		return err
+	}
+
+	respBytes := jsend.Marshal(orders)
+
+	jsend.SendJSON(w, respBytes, http.StatusOK)
+
+	return nil
+}
+
+// getOrderByID returns an order to its owner or to an order manager.
+func (h *Handler) getOrderByID(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("get order by id")
+
+	params := httprouter.ParamsFromContext(r.Context())
+	orderID, err := strconv.ParseUint(params.ByName("order_id"), 10, 64)
+	if err != nil {
+		return apperror.NewError(errOrderNotFound, "order not found", http.StatusNotFound)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+	defer cancel()
+
+	order, err := h.checkOrderAccess(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	respBytes := jsend.Marshal(order)
+
+	jsend.SendJSON(w, respBytes, http.StatusOK)
+
+	return nil
+}
+
+// changeOrderStatus moves an order to another status. The route is for
+// order managers only, and the manager is recorded as the actor of the change.
+func (h *Handler) changeOrderStatus(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("change order status")
+
+	actorID, ok := userIDFromContext(r.Context())
+	if !ok {
+		return apperror.NewError(errNoUser, "unauthorized", http.StatusUnauthorized)
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	orderID, err := strconv.ParseUint(params.ByName("order_id"), 10, 64)
+	if err != nil {
+		return apperror.NewError(errOrderNotFound, "order not found", http.StatusNotFound)
+	}
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.log.Errorf("error in reading request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+
+	var changeDTO dto.ChangeOrderStatusDTO
+	if err := json.Unmarshal(reqBytes, &changeDTO); err != nil {
+		h.log.Errorf("error in unmarshalling request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+
+	orderStatus, err := dto.OrderStatusFromName(changeDTO.Status)
+	if err != nil {
+		return apperror.NewError(err, "unknown order status", http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+	defer cancel()
+
+	order, err := h.apiClients.OrderClient.ChangeStatus(ctx, orderID, actorID, orderStatus, changeDTO)
+	if err != nil {
+		h.log.Errorf("error in changing order status: %v", err)
+		return err
+	}
+
+	respBytes := jsend.Marshal(order)
+
+	jsend.SendJSON(w, respBytes, http.StatusOK)
+
+	return nil
+}
+
+// updateOrderItems replaces the items of an order of the signed in user
+// while it is not paid.
+func (h *Handler) updateOrderItems(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("update order items")
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		return apperror.NewError(errNoUser, "unauthorized", http.StatusUnauthorized)
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	orderID, err := strconv.ParseUint(params.ByName("order_id"), 10, 64)
+	if err != nil {
+		return apperror.NewError(errOrderNotFound, "order not found", http.StatusNotFound)
+	}
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.log.Errorf("error in reading request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+
+	var updateDTO dto.UpdateOrderItemsDTO
+	if err := json.Unmarshal(reqBytes, &updateDTO); err != nil {
+		h.log.Errorf("error in unmarshalling request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+	defer cancel()
+
+	order, err := h.apiClients.OrderClient.UpdateItems(ctx, orderID, userID, updateDTO)
+	if err != nil {
+		h.log.Errorf("error in updating order items: %v", err)
+		return err
+	}
+
+	respBytes := jsend.Marshal(order)
+
+	jsend.SendJSON(w, respBytes, http.StatusOK)
+
+	return nil
+}
+
+// cancelOrder cancels an order of the signed in user. Order managers may cancel the
+// orders of any user.
+func (h *Handler) cancelOrder(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("cancel order")
+
+	userID, ok := userIDFromContext(r.Context())
+	if !ok {
+		return apperror.NewError(errNoUser, "unauthorized", http.StatusUnauthorized)
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	orderID, err := strconv.ParseUint(params.ByName("order_id"), 10, 64)
+	if err != nil {
+		return apperror.NewError(errOrderNotFound, "order not found", http.StatusNotFound)
+	}
+
+	reqBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.log.Errorf("error in reading request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+
+	var cancelDTO dto.CancelOrderDTO
+	if err := json.Unmarshal(reqBytes, &cancelDTO); err != nil {
+		h.log.Errorf("error in unmarshalling request: %v", err)
+		return apperror.NewError(err, "incorrect request body", http.StatusBadRequest)
+	}
+
+	reason, err := dto.CancelReasonFromName(cancelDTO.Reason)
+	if err != nil {
+		return apperror.NewError(err, "unknown cancel reason", http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+	defer cancel()
+
+	cancellation, err := h.apiClients.OrderClient.Cancel(ctx, orderID, userID, canManageOrders(ctx), reason, cancelDTO)
+	if err != nil {
+		h.log.Errorf("error in cancelling order: %v", err)
+		return err
+	}
+
+	respBytes := jsend.Marshal(cancellation)
+
+	jsend.SendJSON(w, respBytes, http.StatusOK)
+
+	return nil
+}
+
+// getOrderHistory returns the status history of an order to its owner or to
+// an order manager.
+func (h *Handler) getOrderHistory(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("get order history")
+
+	params := httprouter.ParamsFromContext(r.Context())
+	orderID, err := strconv.ParseUint(params.ByName("order_id"), 10, 64)
+	if err != nil {
+		return apperror.NewError(errOrderNotFound, "order not found", http.StatusNotFound)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+	defer cancel()
+
+	if _, err := h.checkOrderAccess(ctx, orderID); err != nil {
+		return err
+	}
+
+	history, err := h.apiClients.OrderClient.GetHistory(ctx, orderID)
+	if err != nil {
+		h.log.Errorf("error in getting order history: %v", err)
+		return err
+	}
+
+	respBytes := jsend.Marshal(history)
+
+	jsend.SendJSON(w, respBytes, http.StatusOK)
+
+	return nil
+}
+
+// checkOrderAccess returns the order to its owner and to order managers. Other users
+// get not found, so they can't probe order IDs.
+func (h *Handler) checkOrderAccess(ctx context.Context, orderID uint64) (entity.Order, error) {
+	userID, ok := userIDFromContext(ctx)
+	if !ok {
+		return entity.Order{}, apperror.NewError(errNoUser, "unauthorized", http.StatusUnauthorized)
+	}
+
+	order, err := h.apiClients.OrderClient.GetByID(ctx, orderID)
+	if err != nil {
+		h.log.Errorf("error in getting order: %v", err)
+		return entity.Order{}, err
+	}
+
+	if order.UserID != userID && !canManageOrders(ctx) {
+		return entity.Order{}, apperror.NewError(errOrderNotFound, "order not found", http.StatusNotFound)
+	}
+
+	return order, nil
+}
@@ -13,15 +13,59 @@ func (h *Handler) InitRoutes() http.Handler {
 	r.Handler(http.MethodPost, "/auth/sign-up", middlwares.CheckErrorMiddlware(h.signUp))
 	r.Handler(http.MethodPost, "/auth/sign-in", middlwares.CheckErrorMiddlware(h.signIn))
 	r.Handler(http.MethodPost, "/auth/refresh", middlwares.CheckErrorMiddlware(h.refresh))
+	r.Handler(http.MethodPost, "/auth/logout", middlwares.CheckErrorMiddlware(h.logout))
+	r.Handler(http.MethodPost, "/auth/password-reset", middlwares.CheckErrorMiddlware(h.requestPasswordReset))
+	r.Handler(http.MethodPost, "/auth/password-reset/confirm", middlwares.CheckErrorMiddlware(h.confirmPasswordReset))
+	r.Handler(http.MethodGet, "/auth/verify-email", middlwares.CheckErrorMiddlware(h.verifyEmail))
+	r.Handler(http.MethodPost, "/auth/verify-email/resend", middlwares.CheckErrorMiddlware(h.resendVerification))
 
 	r.Handler(http.MethodGet, "/api/user", middlwares.CheckErrorMiddlware(h.getMe))
 	r.Handler(http.MethodPut, "/api/user", middlwares.CheckErrorMiddlware(h.updateUser))
 
 	r.Handler(http.MethodGet, "/api/user/:user_id", middlwares.CheckErrorMiddlware(h.getUserByID))
+	r.Handler(http.MethodPut, "/api/user/:user_id/roles/:role", h.UserIdentity(h.RequireRole(roleAdmin)(middlwares.CheckErrorMiddlware(h.assignRole))))
+	r.Handler(http.MethodDelete, "/api/user/:user_id/roles/:role", h.UserIdentity(h.RequireRole(roleAdmin)(middlwares.CheckErrorMiddlware(h.revokeRole))))
 
-	r.Handler(http.MethodPost, "/api/books", h.AdminMiddleware(middlwares.CheckErrorMiddlware(h.createBook)))
+	r.Handler(http.MethodPost, "/api/books", h.UserIdentity(h.RequirePermission(permissionManageCatalog)(middlwares.CheckErrorMiddlware(h.createBook))))
+	r.Handler(http.MethodPost, "/api/books/import", h.UserIdentity(h.RequirePermission(permissionManageCatalog)(middlwares.CheckErrorMiddlware(h.importBooks))))
 	r.Handler(http.MethodGet, "/api/books", middlwares.CheckErrorMiddlware(h.getAllBoks))
 	r.Handler(http.MethodGet, "/api/books/:book_id", middlwares.CheckErrorMiddlware(h.getBookByID))
+	r.Handler(http.MethodPatch, "/api/books/:book_id", h.UserIdentity(h.RequirePermission(permissionManageCatalog)(middlwares.CheckErrorMiddlware(h.updateBook))))
+	r.Handler(http.MethodPut, "/api/books/:book_id/stock", h.UserIdentity(h.RequirePermission(permissionManageCatalog)(middlwares.CheckErrorMiddlware(h.setBookStock))))
+	r.Handler(http.MethodGet, "/api/books/:book_id/price", middlwares.CheckErrorMiddlware(h.getBookPrice))
 
-	return r
+	r.Handler(http.MethodPost, "/api/orders", h.UserIdentity(h.RequireVerifiedEmail(middlwares.CheckErrorMiddlware(h.createOrder))))
+	r.Handler(http.MethodGet, "/api/orders", h.UserIdentity(middlwares.CheckErrorMiddlware(h.getOrders)))
+	r.Handler(http.MethodGet, "/api/orders/:order_id", h.UserIdentity(middlwares.CheckErrorMiddlware(h.getOrderByID)))
+	r.Handler(http.MethodPatch, "/api/orders/:order_id/status", h.UserIdentity(h.RequirePermission(permissionManageOrders)(middlwares.CheckErrorMiddlware(h.changeOrderStatus))))
+	r.Handler(http.MethodPut, "/api/orders/:order_id/items", h.UserIdentity(middlwares.CheckErrorMiddlware(h.updateOrderItems)))
+	r.Handler(http.MethodPost, "/api/orders/:order_id/cancel", h.UserIdentity(middlwares.CheckErrorMiddlware(h.cancelOrder)))
+	r.Handler(http.MethodGet, "/api/orders/:order_id/history", h.UserIdentity(middlwares.CheckErrorMiddlware(h.getOrderHistory)))
+
+	r.Handler(http.MethodGet, "/api/cart", h.CartIdentity(middlwares.CheckErrorMiddlware(h.getCart)))
+	r.Handler(http.MethodPost, "/api/cart/items", h.CartIdentity(middlwares.CheckErrorMiddlware(h.addCartItem)))
+	r.Handler(http.MethodPut, "/api/cart/items/:book_id", h.CartIdentity(middlwares.CheckErrorMiddlware(h.setCartItemQuantity)))
+	r.Handler(http.MethodDelete, "/api/cart/items/:book_id", h.CartIdentity(middlwares.CheckErrorMiddlware(h.removeCartItem)))
+	r.Handler(http.MethodPost, "/api/cart/checkout", h.UserIdentity(h.RequireVerifiedEmail(middlwares.CheckErrorMiddlware(h.checkoutCart))))
+
+	r.Handler(http.MethodGet, "/api/authors/:name/books", middlwares.CheckErrorMiddlware(h.getBooksBy(h.apiClients.BookClient.GetByAuthor)))
+	r.Handler(http.MethodGet, "/api/publishers/:name/books", middlwares.CheckErrorMiddlware(h.getBooksBy(h.apiClients.BookClient.GetByPublisher)))
+	r.Handler(http.MethodGet, "/api/genres/:name/books", middlwares.CheckErrorMiddlware(h.getBooksBy(h.apiClients.BookClient.GetByGenre)))
+	r.Handler(http.MethodGet, "/api/languages/:name/books", middlwares.CheckErrorMiddlware(h.getBooksBy(h.apiClients.BookClient.GetByLanguage)))
+
+	// httprouter refuses static paths next to a wildcard at the same place, like
+	// /api/books/search next to /api/books/:book_id, so these are served by a
+	// router of their own that hands everything else over to r.
+	static := httprouter.New()
+	static.NotFound = r
+	static.HandleMethodNotAllowed = false
+
+	static.Handler(http.MethodGet, "/api/user/sessions", middlwares.CheckErrorMiddlware(h.listSessions))
+	static.Handler(http.MethodDelete, "/api/user/sessions", middlwares.CheckErrorMiddlware(h.revokeSession))
+
+	static.Handler(http.MethodGet, "/api/books/search", middlwares.CheckErrorMiddlware(h.searchBooks))
+	static.Handler(http.MethodGet, "/api/books/export", h.UserIdentity(h.RequirePermission(permissionManageCatalog)(middlwares.CheckErrorMiddlware(h.exportBooks))))
+	static.Handler(http.MethodGet, "/api/books/isbn/:isbn", middlwares.CheckErrorMiddlware(h.getBookByISBN))
+
+	return static
 }
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/Levap123/api_gateway/internal/dto"
+	"github.com/Levap123/api_gateway/internal/entity"
 	jsend "github.com/Levap123/api_gateway/pkg/json"
 	"github.com/julienschmidt/httprouter"
 
@@ -89,3 +90,93 @@ func (h *Handler) updateUser(w http.ResponseWriter, r *http.Request) error {
 	jsend.SendJSON(w, bytes, http.StatusOK)
 	return nil
 }
+
+// assignRole grants a role to a user. The route is for admins; the user
+// service checks the token of the admin once more.
+func (h *Handler) assignRole(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("assign role")
+
+	return h.changeRole(w, r, h.apiClients.UserClient.AssignRole)
+}
+
+func (h *Handler) revokeRole(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("revoke role")
+
+	return h.changeRole(w, r, h.apiClients.UserClient.RevokeRole)
+}
+
+func (h *Handler) changeRole(w http.ResponseWriter, r *http.Request,
+	change func(ctx context.Context, accessToken string, userID uint64, role string) (entity.UserRoles, error)) error {
+	authToken, ok := bearerToken(r)
+	if !ok {
+		return apperror.NewError(errors.New("auth header must be: Bearer <token>"), "invalid auth header", http.StatusUnauthorized)
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	userID, err := strconv.ParseUint(params.ByName("user_id"), 10, 64)
+	if err != nil {
+		return apperror.NewError(errors.New("not found"), "not found", http.StatusNotFound)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*2)
+	defer cancel()
+
+	roles, err := change(ctx, authToken, userID, params.ByName("role"))
+	if err != nil {
+		h.log.Errorf("error in changing role: %v", err)
+		return err
+	}
+
+	bytes := jsend.Marshal(roles)
+	jsend.SendJSON(w, bytes, http.StatusOK)
+	return nil
+}
+
+// listSessions returns where the user is signed in.
+func (h *Handler) listSessions(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("list sessions")
+
+	authToken, ok := bearerToken(r)
+	if !ok {
+		return apperror.NewError(errors.New("auth header must be: Bearer <token>"), "invalid auth header", http.StatusUnauthorized)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*2)
+	defer cancel()
+
+	sessions, err := h.apiClients.UserClient.ListSessions(ctx, authToken)
+	if err != nil {
+		return err
+	}
+
+	bytes := jsend.Marshal(sessions)
+	jsend.SendJSON(w, bytes, http.StatusOK)
+	return nil
+}
+
+// revokeSession signs the user out of the session given by the session_id
+// query parameter, e.g. on a lost device.
+func (h *Handler) revokeSession(w http.ResponseWriter, r *http.Request) error {
+	h.log.Debug("revoke session")
+
+	authToken, ok := bearerToken(r)
+	if !ok {
+		return apperror.NewError(errors.New("auth header must be: Bearer <token>"), "invalid auth header", http.StatusUnauthorized)
+	}
+
+	sessionID := r.URL.Query().Get("session_id")
+	if sessionID == "" {
+		return apperror.NewError(errors.New("session_id is required"), "session_id is required", http.StatusBadRequest)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*2)
+	defer cancel()
+
+	if err := h.apiClients.UserClient.RevokeSession(ctx, authToken, sessionID); err != nil {
+		return err
+	}
+
+	bytes := jsend.Marshal(map[string]string{"revoked": sessionID})
+	jsend.SendJSON(w, bytes, http.StatusOK)
+	return nil
+}
@@ -54,6 +54,14 @@ func main() {
 	}
 
 	repo := mongo.NewBookRepo(DB, log)
+
+	ctxIndexes, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	if err := repo.EnsureIndexes(ctxIndexes); err != nil {
+		log.Fatalf("fatal in creating indexes: %v", err)
+	}
+
 	repoWrapper := repository.NewRepo(repo, redisClient, log)
 
 	service := book.NewBookService(repoWrapper)
@@ -69,7 +77,7 @@ func main() {
 	proto.RegisterBookServer(srv, handler)
 	reflection.Register(srv)
 
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
 
 	log.Info("server started")
@@ -3,11 +3,13 @@ package book
 import (
 	"context"
 	"errors"
+	"io"
+	"time"
 
 	"github.com/Levap123/book_service/internal/domain"
 	"github.com/Levap123/book_service/proto"
-	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -21,9 +23,23 @@ type BookHandler struct {
 type IBookService interface {
 	Delete(ctx context.Context, bookID string) (string, error)
 	Create(ctx context.Context, book Book) (string, error)
+	Update(ctx context.Context, bookID string, update map[string]any) (Book, error)
 	GetByID(ctx context.Context, bookID string) (Book, error)
-	GetAll(ctx context.Context) ([]Book, error)
-	BooksFilter(ctx context.Context, genre, author, language, publisher []string) ([]Book, error)
+	GetByISBN(ctx context.Context, isbn string) (Book, error)
+	GetAll(ctx context.Context, page Page) ([]Book, string, error)
+	BooksFilter(ctx context.Context, genre, author, language, publisher []string, page Page) ([]Book, string, error)
+	GetByAuthor(ctx context.Context, author string) ([]Book, error)
+	GetByPublisher(ctx context.Context, publisher string) ([]Book, error)
+	GetByGenre(ctx context.Context, genre string) ([]Book, error)
+	GetByLanguage(ctx context.Context, language string) ([]Book, error)
+	Search(ctx context.Context, query SearchQuery) ([]Book, error)
+	SetStock(ctx context.Context, bookID string, quantity int64) (Book, error)
+	Reserve(ctx context.Context, reservationID string, items []StockItem, extend bool) (Reservation, error)
+	Release(ctx context.Context, reservationID string, items []StockItem) (Reservation, error)
+	Commit(ctx context.Context, reservationID string) (Reservation, error)
+	GetPriceAt(ctx context.Context, bookID string, at time.Time) (Price, error)
+	Import(ctx context.Context, run *ImportRun, book Book) (ImportResult, error)
+	Export(ctx context.Context, genre, author, language, publisher []string, send func(Book) error) error
 }
 
 func NewBookHandler(service IBookService, log *logrus.Logger) *BookHandler {
@@ -34,10 +50,18 @@ func NewBookHandler(service IBookService, log *logrus.Logger) *BookHandler {
 }
 
 func (h *BookHandler) Create(ctx context.Context, req *proto.BookInfo) (*proto.CreateBookResponse, error) {
-	book := NewBookFromCreateBookRequest(req)
+	book, err := NewBookFromCreateBookRequest(req)
+	if err != nil {
+		h.log.Errorf("error in creating book: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	bookID, err := h.service.Create(ctx, book)
 	if err != nil {
 		h.log.Errorf("error in creating book: %v", err)
+		if errors.Is(err, domain.ErrDuplicateISBN) {
+			return nil, status.Error(codes.AlreadyExists, domain.ErrDuplicateISBN.Error())
+		}
 		return nil, err
 	}
 
@@ -46,6 +70,31 @@ func (h *BookHandler) Create(ctx context.Context, req *proto.BookInfo) (*proto.C
 	}, nil
 }
 
+func (h *BookHandler) Update(ctx context.Context, req *proto.UpdateBookRequest) (*proto.BookInfo, error) {
+	update, err := NewBookUpdateFromUpdateBookRequest(req)
+	if err != nil {
+		h.log.Errorf("error in updating book: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	book, err := h.service.Update(ctx, req.GetBook().GetID(), update)
+	if err != nil {
+		h.log.Errorf("error in updating book: %v", err)
+		if errors.Is(err, domain.ErrBookNotFound) {
+			return nil, status.Errorf(codes.NotFound, "book with this ID not found")
+		}
+		if errors.Is(err, domain.ErrDuplicateISBN) {
+			return nil, status.Error(codes.AlreadyExists, domain.ErrDuplicateISBN.Error())
+		}
+		if errors.Is(err, domain.ErrInvalidPrice) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, err
+	}
+
+	return NewBookResponseFromBook(book), nil
+}
+
 func (h *BookHandler) GetByID(ctx context.Context, req *proto.GetBookRequset) (*proto.BookInfo, error) {
 	bookResp, err := h.service.GetByID(ctx, req.BookID)
 	if err != nil {
@@ -61,45 +110,141 @@ func (h *BookHandler) GetByID(ctx context.Context, req *proto.GetBookRequset) (*
 	return NewBookResponseFromBook(bookResp), nil
 }
 
-func (h *BookHandler) GetAll(ctx context.Context, req *empty.Empty) (*proto.BookInfoArray, error) {
-	books, err := h.service.GetAll(ctx)
+func (h *BookHandler) GetByISBN(ctx context.Context, req *proto.GetByISBNRequest) (*proto.BookInfo, error) {
+	book, err := h.service.GetByISBN(ctx, req.Isbn)
 	if err != nil {
-		h.log.Errorf("error in getting all books: %v", err)
-
+		h.log.Errorf("error in getting book by ISBN: %v", err)
+		if errors.Is(err, domain.ErrInvalidISBN) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 		if errors.Is(err, domain.ErrBookNotFound) {
-			return nil, status.Errorf(codes.NotFound, domain.ErrBookNotFound.Error())
+			return nil, status.Errorf(codes.NotFound, "book with this ISBN not found")
 		}
 		return nil, err
 	}
 
-	requestArray := make([]*proto.BookInfo, 0, len(books))
+	return NewBookResponseFromBook(book), nil
+}
 
-	for _, book := range books {
-		requestArray = append(requestArray, NewBookResponseFromBook(book))
+func (h *BookHandler) GetAll(ctx context.Context, req *proto.PageRequest) (*proto.BookInfoArray, error) {
+	page, err := NewPageFromPageRequest(req)
+	if err != nil {
+		h.log.Errorf("error in getting all books: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	return &proto.BookInfoArray{
-		Arr: requestArray,
-	}, nil
+	books, nextPageToken, err := h.service.GetAll(ctx, page)
+	if err != nil {
+		h.log.Errorf("error in getting all books: %v", err)
+
+		switch {
+		case errors.Is(err, domain.ErrBookNotFound):
+			return nil, status.Errorf(codes.NotFound, domain.ErrBookNotFound.Error())
+		case errors.Is(err, domain.ErrInvalidPage):
+			return nil, status.Errorf(codes.InvalidArgument, domain.ErrInvalidPage.Error())
+		}
+		return nil, err
+	}
+
+	resp := newBookInfoArray(books)
+	resp.NextPageToken = nextPageToken
+
+	return resp, nil
 }
 
 func (h *BookHandler) GetWithFilter(ctx context.Context, req *proto.Filter) (*proto.BookInfoArray, error) {
-	books, err := h.service.BooksFilter(ctx, req.Genre, req.Author, req.Language, req.Publsiher)
+	page, err := NewPageFromPageRequest(req.Page)
+	if err != nil {
+		h.log.Errorf("error in gettin books by filtering: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	books, nextPageToken, err := h.service.BooksFilter(ctx, req.Genre, req.Author, req.Language, req.Publsiher, page)
 	if err != nil {
 		h.log.Errorf("error in gettin books by filtering: %v", err)
 
+		if errors.Is(err, domain.ErrInvalidPage) {
+			return nil, status.Errorf(codes.InvalidArgument, domain.ErrInvalidPage.Error())
+		}
 		return nil, err
 	}
 
-	requestArray := make([]*proto.BookInfo, 0, len(books))
+	resp := newBookInfoArray(books)
+	resp.NextPageToken = nextPageToken
+
+	return resp, nil
+}
+
+func (h *BookHandler) Search(ctx context.Context, req *proto.SearchRequest) (*proto.BookInfoArray, error) {
+	query, err := NewSearchQuery(req)
+	if err != nil {
+		h.log.Errorf("error in searching books: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 
-	for _, book := range books {
-		requestArray = append(requestArray, NewBookResponseFromBook(book))
+	books, err := h.service.Search(ctx, query)
+	if err != nil {
+		h.log.Errorf("error in searching books: %v", err)
+		return nil, err
 	}
 
-	return &proto.BookInfoArray{
-		Arr: requestArray,
-	}, nil
+	return newBookInfoArray(books), nil
+}
+
+func (h *BookHandler) GetByAuthor(ctx context.Context, req *proto.GetByAuthorRequest) (*proto.BookInfoArray, error) {
+	books, err := h.service.GetByAuthor(ctx, req.Author)
+	if err != nil {
+		h.log.Errorf("error in getting books by author: %v", err)
+
+		if errors.Is(err, domain.ErrBookNotFound) {
+			return nil, status.Errorf(codes.NotFound, "books of this author not found")
+		}
+		return nil, err
+	}
+
+	return newBookInfoArray(books), nil
+}
+
+func (h *BookHandler) GetByPublisher(ctx context.Context, req *proto.GetByPublisherRequest) (*proto.BookInfoArray, error) {
+	books, err := h.service.GetByPublisher(ctx, req.Publisher)
+	if err != nil {
+		h.log.Errorf("error in getting books by publisher: %v", err)
+
+		if errors.Is(err, domain.ErrBookNotFound) {
+			return nil, status.Errorf(codes.NotFound, "books of this publisher not found")
+		}
+		return nil, err
+	}
+
+	return newBookInfoArray(books), nil
+}
+
+func (h *BookHandler) GetByGenre(ctx context.Context, req *proto.GetByGenreRequest) (*proto.BookInfoArray, error) {
+	books, err := h.service.GetByGenre(ctx, req.Genre)
+	if err != nil {
+		h.log.Errorf("error in getting books by genre: %v", err)
+
+		if errors.Is(err, domain.ErrBookNotFound) {
+			return nil, status.Errorf(codes.NotFound, "books of this genre not found")
+		}
+		return nil, err
+	}
+
+	return newBookInfoArray(books), nil
+}
+
+func (h *BookHandler) GetByLanguage(ctx context.Context, req *proto.GetByLanguageRequest) (*proto.BookInfoArray, error) {
+	books, err := h.service.GetByLanguage(ctx, req.Language)
+	if err != nil {
+		h.log.Errorf("error in getting books by language: %v", err)
+
+		if errors.Is(err, domain.ErrBookNotFound) {
+			return nil, status.Errorf(codes.NotFound, "books in this language not found")
+		}
+		return nil, err
+	}
+
+	return newBookInfoArray(books), nil
 }
 
 func (h *BookHandler) Delete(ctx context.Context, req *proto.DeleteBookRequestResponse) (*proto.DeleteBookRequestResponse, error) {
@@ -115,3 +260,178 @@ func (h *BookHandler) Delete(ctx context.Context, req *proto.DeleteBookRequestRe
 		BookID: bookID,
 	}, nil
 }
+
+func (h *BookHandler) SetStock(ctx context.Context, req *proto.SetStockRequest) (*proto.BookInfo, error) {
+	book, err := h.service.SetStock(ctx, req.BookID, req.Quantity)
+	if err != nil {
+		h.log.Errorf("error in setting stock: %v", err)
+		return nil, stockError(err)
+	}
+
+	return NewBookResponseFromBook(book), nil
+}
+
+func (h *BookHandler) Reserve(ctx context.Context, req *proto.ReserveRequest) (*proto.Reservation, error) {
+	items, err := NewStockItems(req.Items)
+	if err != nil {
+		h.log.Errorf("error in reserving books: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	reservation, err := h.service.Reserve(ctx, req.ReservationId, items, req.Extend)
+	if err != nil {
+		h.log.Errorf("error in reserving books: %v", err)
+		return nil, stockError(err)
+	}
+
+	return NewReservationResponse(reservation), nil
+}
+
+func (h *BookHandler) Release(ctx context.Context, req *proto.ReleaseRequest) (*proto.Reservation, error) {
+	items, err := NewStockItems(req.Items)
+	if err != nil {
+		h.log.Errorf("error in releasing books: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	reservation, err := h.service.Release(ctx, req.ReservationId, items)
+	if err != nil {
+		h.log.Errorf("error in releasing books: %v", err)
+		return nil, stockError(err)
+	}
+
+	return NewReservationResponse(reservation), nil
+}
+
+func (h *BookHandler) Commit(ctx context.Context, req *proto.CommitRequest) (*proto.Reservation, error) {
+	reservation, err := h.service.Commit(ctx, req.ReservationId)
+	if err != nil {
+		h.log.Errorf("error in committing reservation: %v", err)
+		return nil, stockError(err)
+	}
+
+	return NewReservationResponse(reservation), nil
+}
+
+// Reasons in the ErrorInfo of stock errors. Both come with
+// FailedPrecondition, so clients tell them apart by the reason.
+const (
+	ReasonOutOfStock        = "OUT_OF_STOCK"
+	ReasonReservationClosed = "RESERVATION_CLOSED"
+
+	errorDomain = "book_service"
+)
+
+func stockError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidStock):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrBookNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrReservationNotFound):
+		return status.Error(codes.NotFound, domain.ErrReservationNotFound.Error())
+	case errors.Is(err, domain.ErrOutOfStock):
+		return errorWithReason(codes.FailedPrecondition, err.Error(), ReasonOutOfStock)
+	case errors.Is(err, domain.ErrReservationClosed):
+		return errorWithReason(codes.FailedPrecondition, domain.ErrReservationClosed.Error(), ReasonReservationClosed)
+	default:
+		return err
+	}
+}
+
+// errorWithReason is a status error carrying the reason in an ErrorInfo.
+func errorWithReason(code codes.Code, msg, reason string) error {
+	st, err := status.New(code, msg).WithDetails(&errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: errorDomain,
+	})
+	if err != nil {
+		return status.Error(code, msg)
+	}
+	return st.Err()
+}
+
+func (h *BookHandler) GetPriceAt(ctx context.Context, req *proto.PriceAtRequest) (*proto.Price, error) {
+	at := time.Now()
+	if req.At != nil {
+		at = req.At.AsTime()
+	}
+
+	price, err := h.service.GetPriceAt(ctx, req.BookID, at)
+	if err != nil {
+		h.log.Errorf("error in getting price: %v", err)
+		if errors.Is(err, domain.ErrPriceNotFound) {
+			return nil, status.Errorf(codes.NotFound, "book has no price at this time")
+		}
+		return nil, err
+	}
+
+	return NewPriceResponse(price), nil
+}
+
+func (h *BookHandler) ImportBooks(stream proto.Book_ImportBooksServer) error {
+	report := &proto.ImportReport{}
+	var run *ImportRun
+
+	for row := uint32(1); ; row++ {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(report)
+		}
+		if err != nil {
+			h.log.Errorf("error in receiving imported book: %v", err)
+			return err
+		}
+
+		if run == nil {
+			report.DryRun = req.DryRun
+			run = NewImportRun(req.DryRun)
+		}
+		if req.Row != 0 {
+			row = req.Row
+		}
+
+		result, err := h.importBook(stream.Context(), run, req.GetBook())
+		if err != nil {
+			h.log.Errorf("error in importing book: %v", err)
+			return err
+		}
+		result.Row = row
+
+		switch result.Status {
+		case ImportCreated:
+			report.Created++
+		case ImportUpdated:
+			report.Updated++
+		case ImportRejected:
+			report.Rejected++
+		}
+		report.Rows = append(report.Rows, NewImportRowResult(result))
+	}
+}
+
+func (h *BookHandler) importBook(ctx context.Context, run *ImportRun, req *proto.BookInfo) (ImportResult, error) {
+	if req == nil {
+		return ImportResult{Status: ImportRejected, Reason: "book is empty"}, nil
+	}
+
+	book, err := NewBookFromCreateBookRequest(req)
+	if err != nil {
+		return ImportResult{Status: ImportRejected, Reason: err.Error()}, nil
+	}
+
+	return h.service.Import(ctx, run, book)
+}
+
+func (h *BookHandler) ExportBooks(req *proto.ExportRequest, stream proto.Book_ExportBooksServer) error {
+	send := func(book Book) error {
+		return stream.Send(NewBookResponseFromBook(book))
+	}
+
+	if err := h.service.Export(stream.Context(), req.Genre, req.Author, req.Language, req.Publisher, send); err != nil {
+		h.log.Errorf("error in exporting books: %v", err)
+		return err
+	}
+
+	return nil
+}
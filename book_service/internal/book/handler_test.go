@@ -0,0 +1,108 @@
+package book
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/Levap123/book_service/internal/domain"
+	"github.com/Levap123/book_service/proto"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fieldRepo finds books by the value of one of their fields, keyed as
+// "author=Stephen King".
+type fieldRepo struct {
+	IBookRepo
+	books map[string][]Book
+}
+
+func (r fieldRepo) get(field, value string) ([]Book, error) {
+	books, ok := r.books[field+"="+value]
+	if !ok {
+		return nil, domain.ErrBookNotFound
+	}
+	return books, nil
+}
+
+func (r fieldRepo) GetByAuthor(ctx context.Context, author string) ([]Book, error) {
+	return r.get("author", author)
+}
+
+func (r fieldRepo) GetByPublisher(ctx context.Context, publisher string) ([]Book, error) {
+	return r.get("publisher", publisher)
+}
+
+func (r fieldRepo) GetByGenre(ctx context.Context, genre string) ([]Book, error) {
+	return r.get("genre", genre)
+}
+
+func (r fieldRepo) GetByLanguage(ctx context.Context, language string) ([]Book, error) {
+	return r.get("language", language)
+}
+
+func TestBookHandler_GetByField(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	h := NewBookHandler(NewBookService(fieldRepo{books: map[string][]Book{
+		"author=Stephen King": {{ID: "1", Title: "It"}, {ID: "2", Title: "Misery"}},
+		"publisher=Viking":    {{ID: "1", Title: "It"}},
+		"genre=horror":        {{ID: "2", Title: "Misery"}},
+		"language=english":    {{ID: "1", Title: "It"}},
+	}}), log)
+	ctx := context.Background()
+
+	type getter func(value string) (*proto.BookInfoArray, error)
+	getters := map[string]getter{
+		"author": func(value string) (*proto.BookInfoArray, error) {
+			return h.GetByAuthor(ctx, &proto.GetByAuthorRequest{Author: value})
+		},
+		"publisher": func(value string) (*proto.BookInfoArray, error) {
+			return h.GetByPublisher(ctx, &proto.GetByPublisherRequest{Publisher: value})
+		},
+		"genre": func(value string) (*proto.BookInfoArray, error) {
+			return h.GetByGenre(ctx, &proto.GetByGenreRequest{Genre: value})
+		},
+		"language": func(value string) (*proto.BookInfoArray, error) {
+			return h.GetByLanguage(ctx, &proto.GetByLanguageRequest{Language: value})
+		},
+	}
+
+	tests := []struct {
+		field string
+		value string
+		want  []string
+	}{
+		{field: "author", value: "Stephen King", want: []string{"1", "2"}},
+		{field: "publisher", value: "Viking", want: []string{"1"}},
+		{field: "genre", value: "horror", want: []string{"2"}},
+		{field: "language", value: "english", want: []string{"1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			get := getters[tt.field]
+
+			resp, err := get(tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(resp.Arr) != len(tt.want) {
+				t.Fatalf("got %d books, want %d", len(resp.Arr), len(tt.want))
+			}
+			for i, info := range resp.Arr {
+				if info.ID != tt.want[i] {
+					t.Errorf("book %d: got ID %q, want %q", i, info.ID, tt.want[i])
+				}
+			}
+
+			_, err = get("unknown")
+			if status.Code(err) != codes.NotFound {
+				t.Errorf("got %v, want NotFound", err)
+			}
+		})
+	}
+}
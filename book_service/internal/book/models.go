@@ -1,28 +1,59 @@
 package book
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/Levap123/book_service/internal/domain"
+	"github.com/Levap123/book_service/internal/isbn"
+	"github.com/Levap123/book_service/internal/search"
 	"github.com/Levap123/book_service/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type Book struct {
-	ID          string    `bson:"_id,omitempty" json:"id,omitempty"`
-	Title       string    `bson:"title,omitempty" json:"title,omitempty"`
+	ID string `bson:"_id,omitempty" json:"id,omitempty"`
+	// Title and Pages are stored even when empty, as pages of books are sorted
+	// by them and a page cursor never matches a missing field.
+	Title       string    `bson:"title" json:"title,omitempty"`
 	Description string    `bson:"description,omitempty" json:"description,omitempty"`
 	Image       string    `bson:"image,omitempty" json:"image,omitempty"`
-	Pages       uint64    `bson:"pages,omitempty" json:"pages,omitempty"`
+	Pages       uint64    `bson:"pages" json:"pages,omitempty"`
 	Author      string    `bson:"author,omitempty" json:"author,omitempty"`
 	Genre       string    `bson:"genre,omitempty" json:"genre,omitempty"`
 	Publisher   string    `bson:"publisher,omitempty" json:"publisher,omitempty"`
 	Binding     bool      `bson:"binding,omitempty" json:"binding,omitempty"`
 	Series      string    `bson:"series,omitempty" json:"series,omitempty"`
 	Language    string    `bson:"language,omitempty" json:"language,omitempty"`
+	ISBN        string    `bson:"isbn,omitempty" json:"isbn,omitempty"`
+	ISBN10      string    `bson:"isbn10,omitempty" json:"isbn10,omitempty"`
 	AddedAt     time.Time `bson:"created_at,omitempty" json:"added_at,omitempty"`
+
+	Quantity int64 `bson:"quantity" json:"quantity"`
+	Reserved int64 `bson:"reserved" json:"reserved"`
+
+	// PriceAmount is in minor units of Currency, e.g. cents for USD.
+	PriceAmount int64  `bson:"price_amount" json:"price_amount"`
+	Currency    string `bson:"currency,omitempty" json:"currency,omitempty"`
+
+	// TextLanguage tells the text index which stemming rules to use for the book.
+	TextLanguage string `bson:"text_language,omitempty" json:"text_language,omitempty"`
 }
 
-func NewBookFromCreateBookRequest(req *proto.BookInfo) Book {
+func NewBookFromCreateBookRequest(req *proto.BookInfo) (Book, error) {
+	currency, err := validatePrice(req.PriceAmount, req.Currency)
+	if err != nil {
+		return Book{}, err
+	}
+
+	isbn13, isbn10, err := normalizeISBN(req.Isbn)
+	if err != nil {
+		return Book{}, err
+	}
+
 	return Book{
 		Title:       req.Title,
 		Description: req.Description,
@@ -34,8 +65,273 @@ func NewBookFromCreateBookRequest(req *proto.BookInfo) Book {
 		Binding:     req.Binding,
 		Series:      req.Series,
 		Language:    req.Language,
+		ISBN:        isbn13,
+		ISBN10:      isbn10,
 		AddedAt:     time.Now(),
+		Quantity:    req.Quantity,
+		PriceAmount: req.PriceAmount,
+		Currency:    currency,
+
+		TextLanguage: search.Language(req.Title + " " + req.Description),
+	}, nil
+}
+
+// normalizeISBN returns the ISBN-13 and, if it exists, the ISBN-10 form of an
+// ISBN given in either form. An empty ISBN stays empty.
+func normalizeISBN(value string) (string, string, error) {
+	if strings.TrimSpace(value) == "" {
+		return "", "", nil
+	}
+
+	isbn13, err := isbn.Normalize(value)
+	if err != nil {
+		return "", "", fmt.Errorf("%q: %v - %w", value, err, domain.ErrInvalidISBN)
+	}
+
+	isbn10, _ := isbn.To10(isbn13)
+	return isbn13, isbn10, nil
+}
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// validatePrice checks the amount and the ISO 4217 currency code and returns
+// the code in upper case. A zero price may come without a currency.
+func validatePrice(amount int64, currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+
+	if amount < 0 {
+		return "", fmt.Errorf("price amount must not be negative - %w", domain.ErrInvalidPrice)
+	}
+	if currency == "" && amount != 0 {
+		return "", fmt.Errorf("currency is required for a price - %w", domain.ErrInvalidPrice)
+	}
+	if currency != "" && !currencyCode.MatchString(currency) {
+		return "", fmt.Errorf("currency %q is not an ISO 4217 code - %w", currency, domain.ErrInvalidPrice)
 	}
+
+	return currency, nil
+}
+
+// Price is a history record of a book price, in effect from EffectiveFrom
+// until EffectiveTo. The price in effect now has no EffectiveTo.
+type Price struct {
+	BookID        string     `bson:"book_id"`
+	Amount        int64      `bson:"amount"`
+	Currency      string     `bson:"currency"`
+	EffectiveFrom time.Time  `bson:"effective_from"`
+	EffectiveTo   *time.Time `bson:"effective_to,omitempty"`
+}
+
+func NewPriceResponse(price Price) *proto.Price {
+	resp := &proto.Price{
+		BookID:        price.BookID,
+		Amount:        price.Amount,
+		Currency:      price.Currency,
+		EffectiveFrom: timestamppb.New(price.EffectiveFrom),
+	}
+
+	if price.EffectiveTo != nil {
+		resp.EffectiveTo = timestamppb.New(*price.EffectiveTo)
+	}
+
+	return resp
+}
+
+const (
+	SortByAddedAt = "added_at"
+	SortByTitle   = "title"
+	SortByPages   = "pages"
+
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	maxQueryLength = 256
+)
+
+// Page describes one page of a listing. Token is the opaque cursor returned
+// with the previous page and is empty for the first one.
+type Page struct {
+	Size   int64
+	Token  string
+	SortBy string
+	Desc   bool
+}
+
+func NewPageFromPageRequest(req *proto.PageRequest) (Page, error) {
+	page := Page{
+		Size:   int64(req.GetPageSize()),
+		Token:  req.GetPageToken(),
+		SortBy: req.GetSortBy(),
+		Desc:   req.GetDescending(),
+	}
+
+	switch {
+	case page.Size == 0:
+		page.Size = defaultPageSize
+	case page.Size > maxPageSize:
+		page.Size = maxPageSize
+	}
+
+	switch page.SortBy {
+	case "":
+		page.SortBy = SortByAddedAt
+	case SortByAddedAt, SortByTitle, SortByPages:
+	default:
+		return Page{}, fmt.Errorf("books can not be sorted by %q - %w", page.SortBy, domain.ErrInvalidPage)
+	}
+
+	return page, nil
+}
+
+type SearchQuery struct {
+	Text     string
+	Language string
+	Limit    int64
+}
+
+func NewSearchQuery(req *proto.SearchRequest) (SearchQuery, error) {
+	text := strings.TrimSpace(req.GetQuery())
+	if text == "" {
+		return SearchQuery{}, fmt.Errorf("query is empty - %w", domain.ErrInvalidQuery)
+	}
+
+	if len([]rune(text)) > maxQueryLength {
+		return SearchQuery{}, fmt.Errorf("query is longer than %d characters - %w", maxQueryLength, domain.ErrInvalidQuery)
+	}
+
+	limit := int64(req.GetLimit())
+	switch {
+	case limit == 0:
+		limit = defaultPageSize
+	case limit > maxPageSize:
+		limit = maxPageSize
+	}
+
+	return SearchQuery{
+		Text:     text,
+		Language: search.Language(text),
+		Limit:    limit,
+	}, nil
+}
+
+// updatableFields maps field mask paths to the values they take from BookInfo.
+// Paths match the BookInfo proto field names, which are also the bson keys.
+var updatableFields = map[string]func(req *proto.BookInfo) any{
+	"title":       func(req *proto.BookInfo) any { return req.Title },
+	"description": func(req *proto.BookInfo) any { return req.Description },
+	"image":       func(req *proto.BookInfo) any { return req.Image },
+	"pages":       func(req *proto.BookInfo) any { return req.Pages },
+	"author":      func(req *proto.BookInfo) any { return req.Author },
+	"genre":       func(req *proto.BookInfo) any { return req.Genre },
+	"publisher":   func(req *proto.BookInfo) any { return req.Publisher },
+	"binding":     func(req *proto.BookInfo) any { return req.Binding },
+	"series":      func(req *proto.BookInfo) any { return req.Series },
+	"language":    func(req *proto.BookInfo) any { return req.Language },
+	"isbn":        func(req *proto.BookInfo) any { return req.Isbn },
+
+	"price_amount": func(req *proto.BookInfo) any { return req.PriceAmount },
+	"currency":     func(req *proto.BookInfo) any { return req.Currency },
+}
+
+// NewBookUpdateFromUpdateBookRequest returns the fields listed in the update mask
+// together with their new values.
+func NewBookUpdateFromUpdateBookRequest(req *proto.UpdateBookRequest) (map[string]any, error) {
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("update mask is empty - %w", domain.ErrInvalidUpdateMask)
+	}
+
+	book := req.GetBook()
+	if book == nil {
+		book = &proto.BookInfo{}
+	}
+
+	update := make(map[string]any, len(paths))
+	for _, path := range paths {
+		value, ok := updatableFields[path]
+		if !ok {
+			return nil, fmt.Errorf("field %q can not be updated - %w", path, domain.ErrInvalidUpdateMask)
+		}
+		update[path] = value(book)
+	}
+
+	if err := validatePriceUpdate(update); err != nil {
+		return nil, err
+	}
+
+	if value, ok := update["isbn"].(string); ok {
+		isbn13, isbn10, err := normalizeISBN(value)
+		if err != nil {
+			return nil, err
+		}
+		update["isbn"], update["isbn10"] = isbn13, isbn10
+	}
+
+	return update, nil
+}
+
+// validatePriceUpdate checks the price fields present in the update. Whether
+// a non zero amount has a currency depends on the stored book, so that is left
+// to completeUpdate.
+func validatePriceUpdate(update map[string]any) error {
+	if amount, ok := update["price_amount"].(int64); ok && amount < 0 {
+		return fmt.Errorf("price amount must not be negative - %w", domain.ErrInvalidPrice)
+	}
+
+	if currency, ok := update["currency"].(string); ok {
+		currency, err := validatePrice(0, currency)
+		if err != nil {
+			return err
+		}
+		update["currency"] = currency
+	}
+
+	return nil
+}
+
+// completeUpdate checks the update against the stored book. The price the
+// update leaves in effect is checked as a whole, taking the amount or the
+// currency the update doesn't change from the book, and both go into the
+// update so the repo records the full price. Likewise the text language is
+// worked out from the title and the description the book ends up with.
+func completeUpdate(stored Book, update map[string]any) error {
+	_, title := update["title"]
+	_, description := update["description"]
+	if title || description {
+		merged := stored
+		if value, ok := update["title"].(string); ok {
+			merged.Title = value
+		}
+		if value, ok := update["description"].(string); ok {
+			merged.Description = value
+		}
+		update["text_language"] = search.Language(merged.Title + " " + merged.Description)
+	}
+
+	if PriceChanged(update) {
+		amount, currency := stored.PriceAmount, stored.Currency
+		if value, ok := update["price_amount"].(int64); ok {
+			amount = value
+		}
+		if value, ok := update["currency"].(string); ok {
+			currency = value
+		}
+
+		currency, err := validatePrice(amount, currency)
+		if err != nil {
+			return err
+		}
+		update["price_amount"], update["currency"] = amount, currency
+	}
+
+	return nil
+}
+
+// PriceChanged tells whether the update touches the book price.
+func PriceChanged(update map[string]any) bool {
+	_, amount := update["price_amount"]
+	_, currency := update["currency"]
+	return amount || currency
 }
 
 func NewBookResponseFromBook(book Book) *proto.BookInfo {
@@ -51,6 +347,161 @@ func NewBookResponseFromBook(book Book) *proto.BookInfo {
 		Binding:     book.Binding,
 		Series:      book.Series,
 		Language:    book.Language,
+		Isbn:        book.ISBN,
+		Isbn10:      book.ISBN10,
 		AddedAt:     timestamppb.New(book.AddedAt),
+		Quantity:    book.Quantity,
+		Available:   book.Available(),
+		PriceAmount: book.PriceAmount,
+		Currency:    book.Currency,
+	}
+}
+
+// Available is the number of copies that can still be reserved.
+func (b Book) Available() int64 {
+	return b.Quantity - b.Reserved
+}
+
+func newBookInfoArray(books []Book) *proto.BookInfoArray {
+	arr := make([]*proto.BookInfo, 0, len(books))
+
+	for _, book := range books {
+		arr = append(arr, NewBookResponseFromBook(book))
+	}
+
+	return &proto.BookInfoArray{
+		Arr: arr,
+	}
+}
+
+const (
+	ReservationReserved  = "reserved"
+	ReservationReleased  = "released"
+	ReservationCommitted = "committed"
+)
+
+type StockItem struct {
+	BookID   string
+	Quantity int64
+}
+
+// Reservation holds copies of books for a caller, usually an order, until it
+// either commits them or releases them back to stock.
+type Reservation struct {
+	ID        string           `bson:"_id"`
+	Items     map[string]int64 `bson:"items"`
+	Status    string           `bson:"status"`
+	UpdatedAt time.Time        `bson:"updated_at"`
+}
+
+// NewStockItems validates the items and merges the ones of the same book.
+func NewStockItems(req []*proto.StockItem) ([]StockItem, error) {
+	quantities := make(map[string]int64, len(req))
+	items := make([]StockItem, 0, len(req))
+
+	for _, item := range req {
+		if item.BookID == "" {
+			return nil, fmt.Errorf("book ID is empty - %w", domain.ErrInvalidStock)
+		}
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity of book %s must be positive - %w", item.BookID, domain.ErrInvalidStock)
+		}
+
+		if _, ok := quantities[item.BookID]; !ok {
+			items = append(items, StockItem{BookID: item.BookID})
+		}
+		quantities[item.BookID] += item.Quantity
+	}
+
+	for i := range items {
+		items[i].Quantity = quantities[items[i].BookID]
+	}
+
+	return items, nil
+}
+
+func NewReservationResponse(reservation Reservation) *proto.Reservation {
+	items := make([]*proto.StockItem, 0, len(reservation.Items))
+	for bookID, quantity := range reservation.Items {
+		if quantity == 0 {
+			continue
+		}
+		items = append(items, &proto.StockItem{
+			BookID:   bookID,
+			Quantity: quantity,
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].BookID < items[j].BookID
+	})
+
+	return &proto.Reservation{
+		ReservationId: reservation.ID,
+		Items:         items,
+		Status:        reservation.Status,
+	}
+}
+
+const (
+	ImportCreated  = "created"
+	ImportUpdated  = "updated"
+	ImportRejected = "rejected"
+)
+
+type ImportResult struct {
+	Row    uint32
+	Status string
+	BookID string
+	Reason string
+}
+
+// ImportRun is one pass over the rows of an import. A dry run writes nothing,
+// so it remembers the books the rows before would have written: a later row
+// matching one of them is reported as an update, as the import would.
+type ImportRun struct {
+	DryRun  bool
+	written map[string]string
+}
+
+func NewImportRun(dryRun bool) *ImportRun {
+	return &ImportRun{
+		DryRun:  dryRun,
+		written: map[string]string{},
+	}
+}
+
+// writtenBook returns the ID of the book an earlier row of the dry run would have
+// written over, matched the way Upsert matches books.
+func (r *ImportRun) writtenBook(book Book) (string, bool) {
+	bookID, ok := r.written[importMatchKey(book)]
+	return bookID, ok
+}
+
+// write remembers the book under every key a later row may match it by.
+func (r *ImportRun) write(book Book, bookID string) {
+	if book.ISBN != "" {
+		r.written["isbn:"+book.ISBN] = bookID
+	}
+	r.written[titleAuthorKey(book)] = bookID
+}
+
+func importMatchKey(book Book) string {
+	if book.ISBN != "" {
+		return "isbn:" + book.ISBN
+	}
+	return titleAuthorKey(book)
+}
+
+func titleAuthorKey(book Book) string {
+	return fmt.Sprintf("title:%q author:%q", book.Title, book.Author)
+}
+
+func NewImportRowResult(result ImportResult) *proto.ImportRowResult {
+	return &proto.ImportRowResult{
+		Row:    result.Row,
+		Status: result.Status,
+		BookID: result.BookID,
+		Reason: result.Reason,
 	}
 }
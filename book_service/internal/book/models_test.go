@@ -0,0 +1,247 @@
+package book
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Levap123/book_service/internal/domain"
+	"github.com/Levap123/book_service/internal/search"
+	"github.com/Levap123/book_service/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestNewStockItems(t *testing.T) {
+	items, err := NewStockItems([]*proto.StockItem{
+		{BookID: "b", Quantity: 1},
+		{BookID: "a", Quantity: 2},
+		{BookID: "b", Quantity: 3},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []StockItem{
+		{BookID: "b", Quantity: 4},
+		{BookID: "a", Quantity: 2},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("got %v, want %v", items, want)
+	}
+}
+
+func TestNewStockItemsInvalid(t *testing.T) {
+	tests := map[string][]*proto.StockItem{
+		"empty book ID":     {{BookID: "", Quantity: 1}},
+		"zero quantity":     {{BookID: "a", Quantity: 0}},
+		"negative quantity": {{BookID: "a", Quantity: -1}},
+	}
+
+	for name, req := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewStockItems(req); !errors.Is(err, domain.ErrInvalidStock) {
+				t.Errorf("got %v, want %v", err, domain.ErrInvalidStock)
+			}
+		})
+	}
+}
+
+func TestValidatePrice(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   int64
+		currency string
+		want     string
+		wantErr  bool
+	}{
+		{name: "should upper case currency", amount: 1999, currency: " usd ", want: "USD"},
+		{name: "should allow free book without currency", amount: 0, currency: ""},
+		{name: "should reject negative amount", amount: -1, currency: "USD", wantErr: true},
+		{name: "should require currency", amount: 100, currency: "", wantErr: true},
+		{name: "should reject malformed currency", amount: 100, currency: "dollar", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			currency, err := validatePrice(tt.amount, tt.currency)
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrInvalidPrice) {
+					t.Errorf("got %v, want %v", err, domain.ErrInvalidPrice)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if currency != tt.want {
+				t.Errorf("got %q, want %q", currency, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewBookFromCreateBookRequestISBN(t *testing.T) {
+	book, err := NewBookFromCreateBookRequest(&proto.BookInfo{Title: "Dune", Isbn: "0-441-01359-7"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if book.ISBN != "9780441013593" || book.ISBN10 != "0441013597" {
+		t.Errorf("got ISBN %q and ISBN-10 %q", book.ISBN, book.ISBN10)
+	}
+
+	if _, err := NewBookFromCreateBookRequest(&proto.BookInfo{Title: "Dune", Isbn: "0441013590"}); !errors.Is(err, domain.ErrInvalidISBN) {
+		t.Errorf("got %v, want %v", err, domain.ErrInvalidISBN)
+	}
+}
+
+func TestCompleteUpdatePrice(t *testing.T) {
+	tests := []struct {
+		name    string
+		stored  Book
+		update  map[string]any
+		want    map[string]any
+		wantErr error
+	}{
+		{
+			name:    "amount for a book without a currency",
+			stored:  Book{},
+			update:  map[string]any{"price_amount": int64(500)},
+			wantErr: domain.ErrInvalidPrice,
+		},
+		{
+			name:   "amount for a book with a currency",
+			stored: Book{PriceAmount: 300, Currency: "USD"},
+			update: map[string]any{"price_amount": int64(500)},
+			want:   map[string]any{"price_amount": int64(500), "currency": "USD"},
+		},
+		{
+			name:   "currency of a priced book",
+			stored: Book{PriceAmount: 300, Currency: "USD"},
+			update: map[string]any{"currency": "eur"},
+			want:   map[string]any{"price_amount": int64(300), "currency": "EUR"},
+		},
+		{
+			name:    "currency dropped from a priced book",
+			stored:  Book{PriceAmount: 300, Currency: "USD"},
+			update:  map[string]any{"currency": ""},
+			wantErr: domain.ErrInvalidPrice,
+		},
+		{
+			name:   "no price",
+			stored: Book{PriceAmount: 300, Currency: "USD"},
+			update: map[string]any{"pages": uint64(10)},
+			want:   map[string]any{"pages": uint64(10)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := completeUpdate(tt.stored, tt.update)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.update, tt.want) {
+				t.Errorf("got %v, want %v", tt.update, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewBookUpdateFromUpdateBookRequest(t *testing.T) {
+	req := &proto.UpdateBookRequest{
+		Book: &proto.BookInfo{
+			Title:    "Dune",
+			Pages:    412,
+			Author:   "ignored, not in the mask",
+			Isbn:     "0-306-40615-2",
+			Currency: " usd ",
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title", "pages", "isbn", "currency"}},
+	}
+
+	update, err := NewBookUpdateFromUpdateBookRequest(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]any{
+		"title":    "Dune",
+		"pages":    uint64(412),
+		"isbn":     "9780306406157",
+		"isbn10":   "0306406152",
+		"currency": "USD",
+	}
+	if !reflect.DeepEqual(update, want) {
+		t.Errorf("got %v, want %v", update, want)
+	}
+}
+
+func TestNewBookUpdateFromUpdateBookRequestInvalid(t *testing.T) {
+	tests := map[string]struct {
+		req  *proto.UpdateBookRequest
+		want error
+	}{
+		"empty mask": {
+			req:  &proto.UpdateBookRequest{Book: &proto.BookInfo{Title: "Dune"}},
+			want: domain.ErrInvalidUpdateMask,
+		},
+		"unknown path": {
+			req:  &proto.UpdateBookRequest{UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"quantity"}}},
+			want: domain.ErrInvalidUpdateMask,
+		},
+		"negative price": {
+			req: &proto.UpdateBookRequest{
+				Book:       &proto.BookInfo{PriceAmount: -1},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"price_amount"}},
+			},
+			want: domain.ErrInvalidPrice,
+		},
+		"invalid isbn": {
+			req: &proto.UpdateBookRequest{
+				Book:       &proto.BookInfo{Isbn: "123"},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"isbn"}},
+			},
+			want: domain.ErrInvalidISBN,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewBookUpdateFromUpdateBookRequest(tt.req); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompleteUpdateTextLanguage(t *testing.T) {
+	stored := Book{Title: "Война и мир", Description: "Роман Льва Толстого о войне 1812 года"}
+
+	tests := []struct {
+		name   string
+		update map[string]any
+		want   any
+	}{
+		{"title only keeps the stored description", map[string]any{"title": "War"}, search.Russian},
+		{"description only keeps the stored title", map[string]any{"description": "A novel"}, search.Russian},
+		{"both replaced", map[string]any{"title": "War and Peace", "description": "A novel by Tolstoy"}, search.English},
+		{"neither", map[string]any{"pages": uint64(1225)}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := completeUpdate(stored, tt.update); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := tt.update["text_language"]; got != tt.want {
+				t.Errorf("got text language %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package repository
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Levap123/book_service/internal/book"
+	"github.com/Levap123/book_service/internal/domain"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeRedis serves the few redis commands the repo uses from a map.
+type fakeRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (f *fakeRedis) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, server := net.Pipe()
+	go f.serve(server)
+	return client, nil
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, f.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) exec(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "GET":
+		value, ok := f.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		f.values[args[1]] = args[2]
+		return "+OK\r\n"
+	case "INCR":
+		n, _ := strconv.ParseInt(f.values[args[1]], 10, 64)
+		n++
+		f.values[args[1]] = strconv.FormatInt(n, 10)
+		return fmt.Sprintf(":%d\r\n", n)
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := f.values[key]; ok {
+				delete(f.values, key)
+				deleted++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", deleted)
+	default:
+		return fmt.Sprintf("-ERR unknown command %s\r\n", args[0])
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line)[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, strings.TrimSuffix(arg, "\r\n"))
+	}
+	return args, nil
+}
+
+// memoryRepo keeps books in a map. onGet, when set, runs after a book was read
+// and before it is returned. getErr is the error of the context of the last
+// read once it is done.
+type memoryRepo struct {
+	IBookRepo
+	books  map[string]book.Book
+	onGet  func()
+	getErr error
+}
+
+func (m *memoryRepo) GetByID(ctx context.Context, bookID string) (book.Book, error) {
+	b, ok := m.books[bookID]
+	if !ok {
+		return book.Book{}, domain.ErrBookNotFound
+	}
+	if m.onGet != nil {
+		onGet := m.onGet
+		m.onGet = nil
+		onGet()
+	}
+	m.getErr = ctx.Err()
+	return b, nil
+}
+
+func (m *memoryRepo) Update(ctx context.Context, bookID string, update map[string]any) (book.Book, error) {
+	b := m.books[bookID]
+	if title, ok := update["title"].(string); ok {
+		b.Title = title
+	}
+	m.books[bookID] = b
+	return b, nil
+}
+
+func (m *memoryRepo) Reserve(ctx context.Context, reservationID string, items []book.StockItem, extend bool) (book.Reservation, error) {
+	for _, item := range items {
+		b := m.books[item.BookID]
+		b.Reserved += item.Quantity
+		m.books[item.BookID] = b
+	}
+	return book.Reservation{ID: reservationID}, nil
+}
+
+func (m *memoryRepo) Create(ctx context.Context, b book.Book) (string, error) {
+	m.books[b.ID] = b
+	return b.ID, nil
+}
+
+func (m *memoryRepo) GetByAuthor(ctx context.Context, author string) ([]book.Book, error) {
+	return m.filter(func(b book.Book) bool { return b.Author == author })
+}
+
+func (m *memoryRepo) GetByGenre(ctx context.Context, genre string) ([]book.Book, error) {
+	return m.filter(func(b book.Book) bool { return b.Genre == genre })
+}
+
+func (m *memoryRepo) filter(match func(book.Book) bool) ([]book.Book, error) {
+	var books []book.Book
+	for _, b := range m.books {
+		if match(b) {
+			books = append(books, b)
+		}
+	}
+	if len(books) == 0 {
+		return nil, domain.ErrBookNotFound
+	}
+	sort.Slice(books, func(i, j int) bool { return books[i].ID < books[j].ID })
+	return books, nil
+}
+
+func newCachedRepo(books map[string]book.Book) (*Repo, *memoryRepo) {
+	cache := redis.NewClient(&redis.Options{
+		Dialer: (&fakeRedis{values: map[string]string{}}).dial,
+	})
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	inner := &memoryRepo{books: books}
+	return NewRepo(inner, cache, log), inner
+}
+
+func TestRepo_GetByIDAfterUpdate(t *testing.T) {
+	ctx := context.Background()
+	repo, _ := newCachedRepo(map[string]book.Book{"1": {ID: "1", Title: "Old"}})
+
+	if _, err := repo.GetByID(ctx, "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := repo.Update(ctx, "1", map[string]any{"title": "New"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Title != "New" {
+		t.Errorf("got title %q, want New", got.Title)
+	}
+}
+
+func TestRepo_GetByIDRacingUpdate(t *testing.T) {
+	ctx := context.Background()
+	repo, inner := newCachedRepo(map[string]book.Book{"1": {ID: "1", Title: "Old"}})
+
+	// The update lands after the read loaded the book and before it is cached.
+	inner.onGet = func() {
+		if _, err := repo.Update(ctx, "1", map[string]any{"title": "New"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	stale, err := repo.GetByID(ctx, "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stale.Title != "Old" {
+		t.Fatalf("got title %q, want Old", stale.Title)
+	}
+
+	got, err := repo.GetByID(ctx, "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Title != "New" {
+		t.Errorf("got title %q, want New", got.Title)
+	}
+}
+
+func TestRepo_GetByIDAfterReserve(t *testing.T) {
+	ctx := context.Background()
+	repo, _ := newCachedRepo(map[string]book.Book{"1": {ID: "1", Quantity: 5}})
+
+	if _, err := repo.GetByID(ctx, "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := repo.Reserve(ctx, "r", []book.StockItem{{BookID: "1", Quantity: 2}}, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Reserved != 2 {
+		t.Errorf("got %d reserved, want 2", got.Reserved)
+	}
+}
+
+func TestRepo_GetByField(t *testing.T) {
+	ctx := context.Background()
+	repo, _ := newCachedRepo(map[string]book.Book{
+		"1": {ID: "1", Author: "horror", Genre: "fantasy"},
+		"2": {ID: "2", Author: "Stephen King", Genre: "horror"},
+	})
+
+	byAuthor, err := repo.GetByAuthor(ctx, "horror")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	byGenre, err := repo.GetByGenre(ctx, "horror")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(byAuthor) != 1 || byAuthor[0].ID != "1" {
+		t.Errorf("by author: got %v, want book 1", byAuthor)
+	}
+	if len(byGenre) != 1 || byGenre[0].ID != "2" {
+		t.Errorf("by genre: got %v, want book 2", byGenre)
+	}
+
+	if _, err := repo.Create(ctx, book.Book{ID: "3", Author: "Stephen King", Genre: "horror"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	byGenre, err = repo.GetByGenre(ctx, "horror")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(byGenre) != 2 {
+		t.Errorf("by genre after create: got %d books, want 2", len(byGenre))
+	}
+
+	if _, err := repo.GetByAuthor(ctx, "nobody"); !errors.Is(err, domain.ErrBookNotFound) {
+		t.Errorf("got %v, want %v", err, domain.ErrBookNotFound)
+	}
+}
+
+func TestRepo_GetByIDOutlivesCancelledCaller(t *testing.T) {
+	repo, inner := newCachedRepo(map[string]book.Book{"1": {ID: "1", Title: "It"}})
+
+	loading, release := make(chan struct{}), make(chan struct{})
+	inner.onGet = func() {
+		close(loading)
+		<-release
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		_, err := repo.GetByID(ctx, "1")
+		errs <- err
+	}()
+
+	<-loading
+	cancel()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("cancelled caller: got %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		close(release)
+		t.Fatal("cancelled caller is still waiting for the load")
+	}
+
+	// The load goes on for the callers that still wait and fills the cache.
+	waiter := make(chan book.Book)
+	go func() {
+		b, _ := repo.GetByID(context.Background(), "1")
+		waiter <- b
+	}()
+	close(release)
+
+	if got := <-waiter; got.Title != "It" {
+		t.Errorf("got title %q, want It", got.Title)
+	}
+	if inner.getErr != nil {
+		t.Errorf("the load ran on a context that ended: %v", inner.getErr)
+	}
+}
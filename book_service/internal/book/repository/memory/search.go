@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/Levap123/book_service/internal/book"
+	"github.com/Levap123/book_service/internal/search"
+)
+
+// SearchIndex is an in-memory inverted index that ranks books the way the
+// mongo text index does: stemmed terms, weighted by the field they occur in.
+type SearchIndex struct {
+	mu sync.RWMutex
+
+	books map[string]book.Book
+	// postings maps a term to the weighted number of its occurrences per book.
+	postings map[string]map[string]float64
+}
+
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		books:    make(map[string]book.Book),
+		postings: make(map[string]map[string]float64),
+	}
+}
+
+func (si *SearchIndex) Add(b book.Book) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	si.remove(b.ID)
+	si.books[b.ID] = b
+
+	fields := map[string]string{
+		"title":       b.Title,
+		"author":      b.Author,
+		"series":      b.Series,
+		"description": b.Description,
+	}
+
+	for field, text := range fields {
+		for _, term := range search.Terms(text) {
+			if si.postings[term] == nil {
+				si.postings[term] = make(map[string]float64)
+			}
+			si.postings[term][b.ID] += float64(search.FieldWeights[field])
+		}
+	}
+}
+
+func (si *SearchIndex) Remove(bookID string) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	si.remove(bookID)
+}
+
+func (si *SearchIndex) remove(bookID string) {
+	if _, ok := si.books[bookID]; !ok {
+		return
+	}
+
+	delete(si.books, bookID)
+	for term, postings := range si.postings {
+		delete(postings, bookID)
+		if len(postings) == 0 {
+			delete(si.postings, term)
+		}
+	}
+}
+
+// Search returns the books matching any of the query terms, the most relevant
+// first. Rare terms weigh more than the ones most books contain.
+func (si *SearchIndex) Search(ctx context.Context, query book.SearchQuery) ([]book.Book, error) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	scores := make(map[string]float64)
+	for _, term := range search.Terms(query.Text) {
+		postings := si.postings[term]
+		idf := math.Log(1 + float64(len(si.books))/float64(len(postings)+1))
+
+		for bookID, weight := range postings {
+			scores[bookID] += weight * idf
+		}
+	}
+
+	books := make([]book.Book, 0, len(scores))
+	for bookID := range scores {
+		books = append(books, si.books[bookID])
+	}
+
+	sort.Slice(books, func(i, j int) bool {
+		if scores[books[i].ID] != scores[books[j].ID] {
+			return scores[books[i].ID] > scores[books[j].ID]
+		}
+		return books[i].ID < books[j].ID
+	})
+
+	if query.Limit > 0 && int64(len(books)) > query.Limit {
+		books = books[:query.Limit]
+	}
+
+	return books, nil
+}
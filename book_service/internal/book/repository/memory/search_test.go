@@ -0,0 +1,136 @@
+package memory_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Levap123/book_service/internal/book"
+	"github.com/Levap123/book_service/internal/book/repository/memory"
+	"github.com/Levap123/book_service/proto"
+)
+
+var books = []book.Book{
+	{
+		ID:          "1",
+		Title:       "Преступление и наказание",
+		Author:      "Фёдор Достоевский",
+		Description: "Роман о студенте, совершившем преступление",
+	},
+	{
+		ID:          "2",
+		Title:       "The Running Man",
+		Author:      "Stephen King",
+		Description: "A dystopian game show",
+	},
+	{
+		ID:          "3",
+		Title:       "Born to Run",
+		Author:      "Christopher McDougall",
+		Description: "A hidden tribe of running superathletes",
+	},
+	{
+		ID:          "4",
+		Title:       "Гарри Поттер и философский камень",
+		Author:      "J. K. Rowling",
+		Series:      "Гарри Поттер",
+		Description: "Мальчик узнаёт, что он волшебник",
+	},
+}
+
+func newIndex() *memory.SearchIndex {
+	index := memory.NewSearchIndex()
+	for _, b := range books {
+		index.Add(b)
+	}
+	return index
+}
+
+func bookIDs(books []book.Book) []string {
+	IDs := make([]string, 0, len(books))
+	for _, b := range books {
+		IDs = append(IDs, b.ID)
+	}
+	return IDs
+}
+
+func TestSearchIndex_Search(t *testing.T) {
+	index := newIndex()
+
+	tests := []struct {
+		name  string
+		query string
+		limit uint32
+		want  []string
+	}{
+		{
+			name:  "should match english word forms and rank title matches first",
+			query: "runs",
+			want:  []string{"3", "2"},
+		},
+		{
+			name:  "should match russian word forms",
+			query: "преступления",
+			want:  []string{"1"},
+		},
+		{
+			name:  "should match author",
+			query: "king",
+			want:  []string{"2"},
+		},
+		{
+			name:  "should rank a book matching in title and series above others",
+			query: "поттера волшебники",
+			want:  []string{"4"},
+		},
+		{
+			name:  "should respect limit",
+			query: "running",
+			limit: 1,
+			want:  []string{"3"},
+		},
+		{
+			name:  "should return nothing for unknown words",
+			query: "quantum",
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := book.NewSearchQuery(&proto.SearchRequest{Query: tt.query, Limit: tt.limit})
+			if err != nil {
+				t.Fatalf("NewSearchQuery(), err = %v", err)
+			}
+
+			got, err := index.Search(context.Background(), query)
+			if err != nil {
+				t.Errorf("SearchIndex.Search(), err = %v", err)
+				return
+			}
+
+			if !reflect.DeepEqual(bookIDs(got), tt.want) {
+				t.Errorf("SearchIndex.Search(), expected = %v, got %v", tt.want, bookIDs(got))
+			}
+		})
+	}
+}
+
+func TestSearchIndex_Remove(t *testing.T) {
+	index := newIndex()
+	index.Remove("3")
+
+	query, err := book.NewSearchQuery(&proto.SearchRequest{Query: "running"})
+	if err != nil {
+		t.Fatalf("NewSearchQuery(), err = %v", err)
+	}
+
+	got, err := index.Search(context.Background(), query)
+	if err != nil {
+		t.Fatalf("SearchIndex.Search(), err = %v", err)
+	}
+
+	if want := []string{"2"}; !reflect.DeepEqual(bookIDs(got), want) {
+		t.Errorf("SearchIndex.Search(), expected = %v, got %v", want, bookIDs(got))
+	}
+}
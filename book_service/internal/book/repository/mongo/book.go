@@ -4,87 +4,199 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Levap123/book_service/internal/book"
 	"github.com/Levap123/book_service/internal/domain"
+	"github.com/Levap123/book_service/internal/search"
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type BookRepo struct {
-	coll *mongo.Collection
-	log  *logrus.Logger
+	coll         *mongo.Collection
+	reservations *mongo.Collection
+	prices       *mongo.Collection
+	log          *logrus.Logger
 }
 
 func NewBookRepo(DB *mongo.Client, log *logrus.Logger) *BookRepo {
 	return &BookRepo{
-		coll: DB.Database("bookstore").Collection("books"),
-		log:  log,
+		coll:         DB.Database("bookstore").Collection("books"),
+		reservations: DB.Database("bookstore").Collection("reservations"),
+		prices:       DB.Database("bookstore").Collection("price_history"),
+		log:          log,
 	}
 }
 
+// EnsureIndexes creates the indexes the repo relies on. The text index reads
+// the stemming language from text_language, because language already holds
+// the book language in a free form the index does not understand.
+func (br *BookRepo) EnsureIndexes(ctx context.Context) error {
+	weights := bson.D{}
+	keys := bson.D{}
+	for _, field := range []string{"title", "author", "series", "description"} {
+		keys = append(keys, bson.E{Key: field, Value: "text"})
+		weights = append(weights, bson.E{Key: field, Value: search.FieldWeights[field]})
+	}
+
+	textIndex := mongo.IndexModel{
+		Keys: keys,
+		Options: options.Index().
+			SetName("books_text").
+			SetWeights(weights).
+			SetDefaultLanguage(search.English).
+			SetLanguageOverride("text_language"),
+	}
+
+	if _, err := br.coll.Indexes().CreateOne(ctx, textIndex); err != nil {
+		return fmt.Errorf("book repo - ensure indexes - %w", err)
+	}
+
+	// Books without an ISBN are left out of the unique index.
+	isbnIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "isbn", Value: 1}},
+		Options: options.Index().
+			SetName("books_isbn").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"isbn": bson.M{"$gt": ""}}),
+	}
+
+	if _, err := br.coll.Indexes().CreateOne(ctx, isbnIndex); err != nil {
+		return fmt.Errorf("book repo - ensure indexes - %w", err)
+	}
+
+	if _, err := br.prices.Indexes().CreateMany(ctx, priceIndexes); err != nil {
+		return fmt.Errorf("book repo - ensure indexes - %w", err)
+	}
+
+	// Books stored before title and pages were always written are given the
+	// empty values, so paging by them does not skip these books.
+	for field, empty := range map[string]interface{}{"title": "", "pages": int64(0)} {
+		filter := bson.M{field: bson.M{"$exists": false}}
+		if _, err := br.coll.UpdateMany(ctx, filter, bson.M{"$set": bson.M{field: empty}}); err != nil {
+			return fmt.Errorf("book repo - ensure indexes - fill %s - %w", field, err)
+		}
+	}
+
+	return nil
+}
+
 func (br *BookRepo) Create(ctx context.Context, book book.Book) (string, error) {
 	res, err := br.coll.InsertOne(ctx, book)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return "", domain.ErrDuplicateISBN
+		}
 		return "", fmt.Errorf("book repo - create - %w", err)
 	}
 
 	ID := res.InsertedID.(primitive.ObjectID).Hex()
+
+	if book.Currency != "" {
+		book.ID = ID
+		if _, err := br.recordPrice(ctx, book, book.AddedAt); err != nil {
+			return "", fmt.Errorf("book repo - create - %w", err)
+		}
+	}
+
 	return ID, nil
 }
 
-func (br *BookRepo) GetByID(ctx context.Context, bookID string) (book.Book, error) {
+// Update sets the fields of the update. A price change, which carries both
+// the amount and the currency, is recorded in the price history first and
+// undone if the book can't be updated, so the history never misses the price
+// a book has.
+func (br *BookRepo) Update(ctx context.Context, bookID string, update map[string]any) (book.Book, error) {
 	objectID, err := primitive.ObjectIDFromHex(bookID)
 	if err != nil {
-		return book.Book{}, fmt.Errorf("book repo - get object ID from hex - %w", err)
+		return book.Book{}, fmt.Errorf("book repo - get object ID from hex - %w", domain.ErrBookNotFound)
+	}
+
+	var change priceChange
+	if book.PriceChanged(update) {
+		amount, _ := update["price_amount"].(int64)
+		currency, _ := update["currency"].(string)
+
+		price := book.Book{ID: bookID, PriceAmount: amount, Currency: currency}
+		if change, err = br.recordPrice(ctx, price, time.Now()); err != nil {
+			return book.Book{}, fmt.Errorf("book repo - update - %w", err)
+		}
 	}
 
 	filter := bson.D{
-		{"_id", objectID},
+		{Key: "_id", Value: objectID},
 	}
 
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
 	var bookBody book.Book
-	if err := br.coll.FindOne(ctx, filter).Decode(&bookBody); err != nil {
+	if err := br.coll.FindOneAndUpdate(ctx, filter, bson.M{"$set": update}, opts).Decode(&bookBody); err != nil {
+		if undoErr := br.undoPrice(ctx, change); undoErr != nil {
+			br.log.Errorf("error in undoing price of book %s: %v", bookID, undoErr)
+		}
+
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return book.Book{}, domain.ErrBookNotFound
 		}
-		return book.Book{}, fmt.Errorf("book repo - get one - %w", err)
+		if mongo.IsDuplicateKeyError(err) {
+			return book.Book{}, domain.ErrDuplicateISBN
+		}
+		return book.Book{}, fmt.Errorf("book repo - update - %w", err)
 	}
 
-	return bookBody, err
+	return bookBody, nil
 }
 
-func (br *BookRepo) GetAll(ctx context.Context) ([]book.Book, error) {
-	cur, err := br.coll.Find(ctx, bson.D{})
+func (br *BookRepo) GetByID(ctx context.Context, bookID string) (book.Book, error) {
+	objectID, err := primitive.ObjectIDFromHex(bookID)
 	if err != nil {
-		return nil, fmt.Errorf("book repo - get all - %w", err)
+		return book.Book{}, fmt.Errorf("book repo - get object ID from hex - %w", domain.ErrBookNotFound)
 	}
 
-	books := make([]book.Book, 0, 10)
+	filter := bson.D{
+		{Key: "_id", Value: objectID},
+	}
 
-	for cur.Next(ctx) {
-		var buffer book.Book
-		if err := cur.Decode(&buffer); err != nil {
-			return nil, fmt.Errorf("book repo - get all - %w", err)
+	var bookBody book.Book
+	if err := br.coll.FindOne(ctx, filter).Decode(&bookBody); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return book.Book{}, domain.ErrBookNotFound
 		}
+		return book.Book{}, fmt.Errorf("book repo - get one - %w", err)
+	}
 
-		books = append(books, buffer)
+	return bookBody, err
+}
+
+func (br *BookRepo) GetByISBN(ctx context.Context, isbn string) (book.Book, error) {
+	var bookBody book.Book
+	if err := br.coll.FindOne(ctx, bson.M{"isbn": isbn}).Decode(&bookBody); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return book.Book{}, domain.ErrBookNotFound
+		}
+		return book.Book{}, fmt.Errorf("book repo - get by ISBN - %w", err)
 	}
 
-	if err := cur.Err(); err != nil {
-		return nil, fmt.Errorf("book repo - get all - %w", err)
+	return bookBody, nil
+}
+
+func (br *BookRepo) GetAll(ctx context.Context, page book.Page) ([]book.Book, string, error) {
+	books, nextPageToken, err := br.getPage(ctx, bson.M{}, page)
+	if err != nil {
+		return nil, "", fmt.Errorf("book repo - get all - %w", err)
 	}
 
-	if len(books) == 0 {
-		return nil, domain.ErrBookNotFound
+	if len(books) == 0 && page.Token == "" {
+		return nil, "", domain.ErrBookNotFound
 	}
 
-	return books, nil
+	return books, nextPageToken, nil
 }
 
-
 func (br *BookRepo) Delete(ctx context.Context, bookID string) (string, error) {
 	objectID, err := primitive.ObjectIDFromHex(bookID)
 	if err != nil {
@@ -92,7 +204,7 @@ func (br *BookRepo) Delete(ctx context.Context, bookID string) (string, error) {
 	}
 
 	filter := bson.D{
-		{"_id", objectID},
+		{Key: "_id", Value: objectID},
 	}
 
 	res, err := br.coll.DeleteOne(ctx, filter)
@@ -106,7 +218,18 @@ func (br *BookRepo) Delete(ctx context.Context, bookID string) (string, error) {
 	return bookID, nil
 }
 
-func (br *BookRepo) BooksFilter(ctx context.Context, genre, author, language, publisher []string) ([]book.Book, error) {
+func (br *BookRepo) BooksFilter(ctx context.Context, genre, author, language, publisher []string, page book.Page) ([]book.Book, string, error) {
+	filter := newBooksFilter(genre, author, language, publisher)
+
+	books, nextPageToken, err := br.getPage(ctx, filter, page)
+	if err != nil {
+		return nil, "", fmt.Errorf("book repo - books filter - %w", err)
+	}
+
+	return books, nextPageToken, nil
+}
+
+func newBooksFilter(genre, author, language, publisher []string) bson.M {
 	filter := bson.M{}
 
 	if len(genre) != 0 {
@@ -125,7 +248,97 @@ func (br *BookRepo) BooksFilter(ctx context.Context, genre, author, language, pu
 		filter["publisher"] = bson.M{"$in": publisher}
 	}
 
-	return br.getByFilter(ctx, filter)
+	return filter
+}
+
+// exportBatchSize is how many books the export cursor fetches at a time.
+const exportBatchSize = 500
+
+// Export walks the matching books in _id order with a cursor and passes them
+// to send one by one, so the catalog is never held in memory at once.
+func (br *BookRepo) Export(ctx context.Context, genre, author, language, publisher []string, send func(book.Book) error) error {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetBatchSize(exportBatchSize)
+
+	cur, err := br.coll.Find(ctx, newBooksFilter(genre, author, language, publisher), opts)
+	if err != nil {
+		return fmt.Errorf("book repo - export - %w", err)
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var buffer book.Book
+		if err := cur.Decode(&buffer); err != nil {
+			return fmt.Errorf("book repo - export - decode - %w", err)
+		}
+		if err := send(buffer); err != nil {
+			return fmt.Errorf("book repo - export - send - %w", err)
+		}
+	}
+
+	if err := cur.Err(); err != nil {
+		return fmt.Errorf("book repo - export - %w", err)
+	}
+
+	return nil
+}
+
+func (br *BookRepo) Search(ctx context.Context, query book.SearchQuery) ([]book.Book, error) {
+	filter := bson.M{
+		"$text": bson.M{
+			"$search":   query.Text,
+			"$language": query.Language,
+		},
+	}
+
+	score := bson.M{"score": bson.M{"$meta": "textScore"}}
+	opts := options.Find().
+		SetProjection(score).
+		SetSort(score).
+		SetLimit(query.Limit)
+
+	cur, err := br.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("book repo - search - %w", err)
+	}
+	defer cur.Close(ctx)
+
+	books := make([]book.Book, 0, query.Limit)
+	if err := cur.All(ctx, &books); err != nil {
+		return nil, fmt.Errorf("book repo - search - %w", err)
+	}
+
+	return books, nil
+}
+
+func (br *BookRepo) GetByAuthor(ctx context.Context, author string) ([]book.Book, error) {
+	return br.getByField(ctx, "author", author)
+}
+
+func (br *BookRepo) GetByPublisher(ctx context.Context, publisher string) ([]book.Book, error) {
+	return br.getByField(ctx, "publisher", publisher)
+}
+
+func (br *BookRepo) GetByGenre(ctx context.Context, genre string) ([]book.Book, error) {
+	return br.getByField(ctx, "genre", genre)
+}
+
+func (br *BookRepo) GetByLanguage(ctx context.Context, language string) ([]book.Book, error) {
+	return br.getByField(ctx, "language", language)
+}
+
+func (br *BookRepo) getByField(ctx context.Context, field, value string) ([]book.Book, error) {
+	books, err := br.getByFilter(ctx, bson.M{field: value})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(books) == 0 {
+		return nil, domain.ErrBookNotFound
+	}
+
+	return books, nil
 }
 
 func (br *BookRepo) getByFilter(ctx context.Context, filter bson.M) ([]book.Book, error) {
@@ -146,3 +359,74 @@ func (br *BookRepo) getByFilter(ctx context.Context, filter bson.M) ([]book.Book
 
 	return books, nil
 }
+
+// getPage returns one page of books matching the filter, ordered by the page
+// sort key with _id as a tie breaker, and the token of the next page. The next
+// page token is empty when there are no more books.
+func (br *BookRepo) getPage(ctx context.Context, filter bson.M, page book.Page) ([]book.Book, string, error) {
+	sortField := sortFields[page.SortBy]
+
+	direction, compare := 1, "$gt"
+	if page.Desc {
+		direction, compare = -1, "$lt"
+	}
+
+	if page.Token != "" {
+		cursor, err := decodePageCursor(page.Token, page.SortBy)
+		if err != nil {
+			return nil, "", err
+		}
+
+		filter = bson.M{
+			"$and": bson.A{
+				filter,
+				bson.M{"$or": bson.A{
+					bson.M{sortField: bson.M{compare: cursor.Value}},
+					bson.M{sortField: cursor.Value, "_id": bson.M{compare: cursor.ID}},
+				}},
+			},
+		}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: sortField, Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(page.Size + 1)
+
+	cur, err := br.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("get page - find - %w", err)
+	}
+	defer cur.Close(ctx)
+
+	books := make([]book.Book, 0, page.Size+1)
+
+	for cur.Next(ctx) {
+		var buffer book.Book
+		if err := cur.Decode(&buffer); err != nil {
+			return nil, "", fmt.Errorf("get page - decode - %w", err)
+		}
+		books = append(books, buffer)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, "", fmt.Errorf("get page - %w", err)
+	}
+
+	if int64(len(books)) <= page.Size {
+		return books, "", nil
+	}
+
+	books = books[:page.Size]
+
+	cursor, err := newPageCursor(page.SortBy, books[len(books)-1])
+	if err != nil {
+		return nil, "", fmt.Errorf("get page - %w", err)
+	}
+
+	nextPageToken, err := cursor.encode()
+	if err != nil {
+		return nil, "", fmt.Errorf("get page - %w", err)
+	}
+
+	return books, nextPageToken, nil
+}
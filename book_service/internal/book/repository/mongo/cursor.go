@@ -0,0 +1,75 @@
+package mongo
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/Levap123/book_service/internal/book"
+	"github.com/Levap123/book_service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// sortFields maps the public sort keys to the document fields they sort by.
+var sortFields = map[string]string{
+	book.SortByAddedAt: "created_at",
+	book.SortByTitle:   "title",
+	book.SortByPages:   "pages",
+}
+
+// pageCursor points right after the last book of a page. It keeps the sort key
+// it was built for, so a token can not be reused with a different ordering.
+type pageCursor struct {
+	SortBy string             `bson:"s"`
+	Value  interface{}        `bson:"v"`
+	ID     primitive.ObjectID `bson:"i"`
+}
+
+func newPageCursor(sortBy string, last book.Book) (pageCursor, error) {
+	ID, err := primitive.ObjectIDFromHex(last.ID)
+	if err != nil {
+		return pageCursor{}, fmt.Errorf("page cursor - get object ID from hex - %w", err)
+	}
+
+	var value interface{}
+	switch sortBy {
+	case book.SortByTitle:
+		value = last.Title
+	case book.SortByPages:
+		value = int64(last.Pages)
+	default:
+		value = primitive.NewDateTimeFromTime(last.AddedAt)
+	}
+
+	return pageCursor{
+		SortBy: sortBy,
+		Value:  value,
+		ID:     ID,
+	}, nil
+}
+
+func (c pageCursor) encode() (string, error) {
+	raw, err := bson.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("page cursor - encode - %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodePageCursor(token, sortBy string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return pageCursor{}, fmt.Errorf("page cursor - decode - %w", domain.ErrInvalidPage)
+	}
+
+	var c pageCursor
+	if err := bson.Unmarshal(raw, &c); err != nil {
+		return pageCursor{}, fmt.Errorf("page cursor - decode - %w", domain.ErrInvalidPage)
+	}
+
+	if c.SortBy != sortBy {
+		return pageCursor{}, fmt.Errorf("page cursor - token was issued for sorting by %q - %w", c.SortBy, domain.ErrInvalidPage)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,64 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/Levap123/book_service/internal/book"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPageCursorMatchesBookWithoutPages(t *testing.T) {
+	b := book.Book{
+		ID:     primitive.NewObjectID().Hex(),
+		Author: "Author",
+	}
+
+	raw, err := bson.Marshal(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var stored bson.M
+	if err := bson.Unmarshal(raw, &stored); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for sortBy, field := range map[string]string{book.SortByPages: "pages", book.SortByTitle: "title"} {
+		cursor, err := newPageCursor(sortBy, b)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", sortBy, err)
+		}
+
+		token, err := cursor.encode()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", sortBy, err)
+		}
+		decoded, err := decodePageCursor(token, sortBy)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", sortBy, err)
+		}
+
+		value, ok := stored[field]
+		if !ok {
+			t.Errorf("%s: %s is not stored", sortBy, field)
+			continue
+		}
+		if value != decoded.Value {
+			t.Errorf("%s: stored %s is %#v, cursor holds %#v", sortBy, field, value, decoded.Value)
+		}
+	}
+}
+
+func TestImportedFieldsKeepStoredPages(t *testing.T) {
+	fields, err := importedFields(book.Book{Title: "Title", Author: "Author"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := fields["pages"]; ok {
+		t.Errorf("import without pages sets pages to %v", fields["pages"])
+	}
+	if fields["title"] != "Title" {
+		t.Errorf("got title %v, want Title", fields["title"])
+	}
+}
@@ -0,0 +1,109 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Levap123/book_service/internal/book"
+	"github.com/Levap123/book_service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Upsert writes an imported book over the one with the same ISBN, or the same
+// title and author when it has no ISBN, and tells whether it created a new one.
+// Empty fields of the imported book leave the stored ones as they are, and the
+// quantity is only set for new books: stock is changed through SetStock.
+func (br *BookRepo) Upsert(ctx context.Context, b book.Book, dryRun bool) (string, bool, error) {
+	filter := bson.M{"title": b.Title, "author": b.Author}
+	if b.ISBN != "" {
+		filter = bson.M{"isbn": b.ISBN}
+	}
+
+	if dryRun {
+		return br.checkUpsert(ctx, filter)
+	}
+
+	set, err := importedFields(b)
+	if err != nil {
+		return "", false, fmt.Errorf("book repo - upsert - %w", err)
+	}
+
+	update := bson.M{
+		"$set": set,
+		"$setOnInsert": bson.M{
+			"created_at": b.AddedAt,
+			"quantity":   b.Quantity,
+			"reserved":   0,
+		},
+	}
+
+	res, err := br.coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return "", false, domain.ErrDuplicateISBN
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("book repo - upsert - %w", err)
+	}
+
+	created := res.UpsertedID != nil
+	if created {
+		b.ID = res.UpsertedID.(primitive.ObjectID).Hex()
+	} else {
+		var stored book.Book
+		if err := br.coll.FindOne(ctx, filter, options.FindOne().SetProjection(bson.M{"_id": 1})).Decode(&stored); err != nil {
+			return "", false, fmt.Errorf("book repo - upsert - %w", err)
+		}
+		b.ID = stored.ID
+	}
+
+	if b.Currency != "" {
+		if _, err := br.recordPrice(ctx, b, b.AddedAt); err != nil {
+			return "", false, fmt.Errorf("book repo - upsert - %w", err)
+		}
+	}
+
+	return b.ID, created, nil
+}
+
+// checkUpsert tells what Upsert would do with the book without writing it.
+func (br *BookRepo) checkUpsert(ctx context.Context, filter bson.M) (string, bool, error) {
+	var existing book.Book
+	err := br.coll.FindOne(ctx, filter, options.FindOne().SetProjection(bson.M{"_id": 1})).Decode(&existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", true, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("book repo - upsert - %w", err)
+	}
+	return existing.ID, false, nil
+}
+
+// importedFields returns the non-empty fields of the book that an import sets,
+// leaving out the ones that belong to stock or only make sense for new books.
+func importedFields(b book.Book) (bson.M, error) {
+	raw, err := bson.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields bson.M
+	if err := bson.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+
+	for _, field := range []string{"_id", "created_at", "quantity", "reserved"} {
+		delete(fields, field)
+	}
+	if b.Currency == "" {
+		delete(fields, "price_amount")
+	}
+	if b.Pages == 0 {
+		delete(fields, "pages")
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,127 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Levap123/book_service/internal/book"
+	"github.com/Levap123/book_service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The price in effect now is marked current, and the unique index on it keeps
+// concurrent price changes from leaving a book with two open records.
+var priceIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: "book_id", Value: 1}, {Key: "effective_from", Value: -1}},
+		Options: options.Index().SetName("price_history_book_time"),
+	},
+	{
+		Keys: bson.D{{Key: "book_id", Value: 1}},
+		Options: options.Index().
+			SetName("price_history_current").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"current": true}),
+	},
+}
+
+// priceChange is what recordPrice did, so that undoPrice can revert it.
+type priceChange struct {
+	bookID string
+	from   time.Time
+	closed bool
+	opened bool
+}
+
+// recordPrice closes the current price record of the book and opens a new one
+// from the given time, unless the price did not actually change.
+func (br *BookRepo) recordPrice(ctx context.Context, b book.Book, from time.Time) (priceChange, error) {
+	change := priceChange{bookID: b.ID, from: from}
+	current := bson.M{"book_id": b.ID, "current": true}
+
+	var price book.Price
+	err := br.prices.FindOne(ctx, current).Decode(&price)
+	switch {
+	case err == nil:
+		if price.Amount == b.PriceAmount && price.Currency == b.Currency {
+			return change, nil
+		}
+
+		update := bson.M{
+			"$set":   bson.M{"effective_to": from},
+			"$unset": bson.M{"current": ""},
+		}
+		if _, err := br.prices.UpdateOne(ctx, current, update); err != nil {
+			return change, fmt.Errorf("record price - close current - %w", err)
+		}
+		change.closed = true
+	case !errors.Is(err, mongo.ErrNoDocuments):
+		return change, fmt.Errorf("record price - find current - %w", err)
+	}
+
+	_, err = br.prices.InsertOne(ctx, bson.M{
+		"book_id":        b.ID,
+		"amount":         b.PriceAmount,
+		"currency":       b.Currency,
+		"effective_from": from,
+		"current":        true,
+	})
+	if err != nil {
+		if undoErr := br.undoPrice(ctx, change); undoErr != nil {
+			err = errors.Join(err, undoErr)
+		}
+		return priceChange{}, fmt.Errorf("record price - insert - %w", err)
+	}
+	change.opened = true
+
+	return change, nil
+}
+
+// undoPrice drops the record recordPrice opened and opens the one it closed
+// again.
+func (br *BookRepo) undoPrice(ctx context.Context, change priceChange) error {
+	if change.opened {
+		opened := bson.M{"book_id": change.bookID, "current": true, "effective_from": change.from}
+		if _, err := br.prices.DeleteOne(ctx, opened); err != nil {
+			return fmt.Errorf("undo price - delete opened - %w", err)
+		}
+	}
+
+	if change.closed {
+		closed := bson.M{"book_id": change.bookID, "current": bson.M{"$exists": false}, "effective_to": change.from}
+		reopen := bson.M{
+			"$set":   bson.M{"current": true},
+			"$unset": bson.M{"effective_to": ""},
+		}
+		if _, err := br.prices.UpdateOne(ctx, closed, reopen); err != nil {
+			return fmt.Errorf("undo price - reopen closed - %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetPriceAt returns the price record in effect at the given time, which is
+// the latest one that started no later than it.
+func (br *BookRepo) GetPriceAt(ctx context.Context, bookID string, at time.Time) (book.Price, error) {
+	filter := bson.M{
+		"book_id":        bookID,
+		"effective_from": bson.M{"$lte": at},
+	}
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "effective_from", Value: -1}, {Key: "_id", Value: -1}})
+
+	var price book.Price
+	if err := br.prices.FindOne(ctx, filter, opts).Decode(&price); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return book.Price{}, domain.ErrPriceNotFound
+		}
+		return book.Price{}, fmt.Errorf("book repo - get price at - %w", err)
+	}
+
+	return price, nil
+}
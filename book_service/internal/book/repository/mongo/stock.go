@@ -0,0 +1,362 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Levap123/book_service/internal/book"
+	"github.com/Levap123/book_service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Stock is kept on the book document as quantity, the copies on hand, and
+// reserved, the copies held by open reservations. Every change is a single
+// conditional update of one document, so concurrent reservations can never
+// take more copies than there are.
+
+func (br *BookRepo) SetStock(ctx context.Context, bookID string, quantity int64) (book.Book, error) {
+	objectID, err := primitive.ObjectIDFromHex(bookID)
+	if err != nil {
+		return book.Book{}, fmt.Errorf("book repo - get object ID from hex - %w", domain.ErrBookNotFound)
+	}
+
+	filter := bson.M{
+		"_id": objectID,
+		"$or": bson.A{
+			bson.M{"reserved": bson.M{"$lte": quantity}},
+			bson.M{"reserved": bson.M{"$exists": false}},
+		},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var bookBody book.Book
+	err = br.coll.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"quantity": quantity}}, opts).Decode(&bookBody)
+	if err == nil {
+		return bookBody, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return book.Book{}, fmt.Errorf("book repo - set stock - %w", err)
+	}
+
+	if err := br.bookExists(ctx, objectID); err != nil {
+		return book.Book{}, fmt.Errorf("book repo - set stock - %w", err)
+	}
+
+	return book.Book{}, fmt.Errorf("book repo - set stock - quantity is below the reserved copies - %w", domain.ErrOutOfStock)
+}
+
+// Reserve holds the items under the reservation. Unless extend is set, only
+// an empty reservation takes items: one that already holds exactly these items
+// is a retry and is returned as it is.
+func (br *BookRepo) Reserve(ctx context.Context, reservationID string, items []book.StockItem, extend bool) (book.Reservation, error) {
+	// A closed reservation with the same ID makes the upsert collide with it.
+	_, err := br.reservations.UpdateOne(ctx,
+		bson.M{"_id": reservationID, "status": book.ReservationReserved},
+		bson.M{
+			"$set":         bson.M{"updated_at": time.Now()},
+			"$setOnInsert": bson.M{"items": bson.M{}},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return book.Reservation{}, domain.ErrReservationClosed
+		}
+		return book.Reservation{}, fmt.Errorf("book repo - reserve - %w", err)
+	}
+
+	if !extend {
+		reservation, held, err := br.heldReservation(ctx, reservationID, items)
+		if err != nil || held {
+			return reservation, err
+		}
+	}
+
+	reserved := make([]book.StockItem, 0, len(items))
+	for _, item := range items {
+		if err := br.reserveBook(ctx, item); err != nil {
+			br.unreserveBooks(ctx, reserved)
+			return book.Reservation{}, fmt.Errorf("book repo - reserve - %w", err)
+		}
+		reserved = append(reserved, item)
+	}
+
+	inc := bson.M{}
+	for _, item := range items {
+		inc["items."+item.BookID] = item.Quantity
+	}
+
+	filter := bson.M{"_id": reservationID, "status": book.ReservationReserved}
+	if !extend {
+		filter["$expr"] = holdsNothing
+	}
+
+	var reservation book.Reservation
+	err = br.reservations.FindOneAndUpdate(ctx,
+		filter,
+		bson.M{"$inc": inc, "$set": bson.M{"updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&reservation)
+	if err == nil {
+		return reservation, nil
+	}
+
+	br.unreserveBooks(ctx, reserved)
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return book.Reservation{}, fmt.Errorf("book repo - reserve - %w", err)
+	}
+	if extend {
+		return book.Reservation{}, domain.ErrReservationClosed
+	}
+
+	// A retry running at the same time filled the reservation first.
+	reservation, held, err := br.heldReservation(ctx, reservationID, items)
+	if err != nil || held {
+		return reservation, err
+	}
+	return book.Reservation{}, fmt.Errorf("book repo - reserve - %w", domain.ErrReservationClosed)
+}
+
+// heldReservation tells whether the open reservation already holds exactly the
+// items. A reservation holding other items can't take them without extend.
+func (br *BookRepo) heldReservation(ctx context.Context, reservationID string, items []book.StockItem) (book.Reservation, bool, error) {
+	reservation, err := br.openReservation(ctx, reservationID)
+	if err != nil {
+		return book.Reservation{}, false, fmt.Errorf("book repo - reserve - %w", err)
+	}
+
+	held := reservedItems(reservation)
+	if len(held) == 0 {
+		return reservation, false, nil
+	}
+	if !sameItems(held, items) {
+		return book.Reservation{}, false, fmt.Errorf("book repo - reserve - reservation %s already holds other books - %w", reservationID, domain.ErrInvalidStock)
+	}
+	return reservation, true, nil
+}
+
+// Release gives the items back to stock. With no items it releases everything
+// the reservation still holds.
+func (br *BookRepo) Release(ctx context.Context, reservationID string, items []book.StockItem) (book.Reservation, error) {
+	reservation, err := br.openReservation(ctx, reservationID)
+	if err != nil {
+		return book.Reservation{}, fmt.Errorf("book repo - release - %w", err)
+	}
+
+	if len(items) == 0 {
+		items = reservedItems(reservation)
+	}
+
+	for _, item := range items {
+		filter := bson.M{
+			"_id":                  reservationID,
+			"status":               book.ReservationReserved,
+			"items." + item.BookID: bson.M{"$gte": item.Quantity},
+		}
+
+		res, err := br.reservations.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"items." + item.BookID: -item.Quantity}})
+		if err != nil {
+			return reservation, fmt.Errorf("book repo - release - %w", err)
+		}
+		if res.MatchedCount == 0 {
+			return reservation, fmt.Errorf("book repo - release - book %s has fewer copies reserved - %w", item.BookID, domain.ErrInvalidStock)
+		}
+
+		if err := br.incStock(ctx, item.BookID, 0, -item.Quantity); err != nil {
+			return reservation, fmt.Errorf("book repo - release - %w", err)
+		}
+	}
+
+	reservation, err = br.openReservation(ctx, reservationID)
+	if err != nil {
+		return book.Reservation{}, fmt.Errorf("book repo - release - %w", err)
+	}
+
+	if len(reservedItems(reservation)) != 0 {
+		return reservation, nil
+	}
+
+	// A reservation refilled since is left open with what it holds now.
+	reservation, err = br.closeReservation(ctx, reservationID, book.ReservationReleased, holdsNothing)
+	if errors.Is(err, errReservationHolds) {
+		return br.openReservation(ctx, reservationID)
+	}
+	return reservation, err
+}
+
+// Commit takes the reserved copies out of stock for good.
+func (br *BookRepo) Commit(ctx context.Context, reservationID string) (book.Reservation, error) {
+	reservation, err := br.closeReservation(ctx, reservationID, book.ReservationCommitted, nil)
+	if err != nil {
+		return book.Reservation{}, fmt.Errorf("book repo - commit - %w", err)
+	}
+
+	for _, item := range reservedItems(reservation) {
+		if err := br.incStock(ctx, item.BookID, -item.Quantity, -item.Quantity); err != nil {
+			return reservation, fmt.Errorf("book repo - commit - %w", err)
+		}
+	}
+
+	return reservation, nil
+}
+
+func (br *BookRepo) reserveBook(ctx context.Context, item book.StockItem) error {
+	objectID, err := primitive.ObjectIDFromHex(item.BookID)
+	if err != nil {
+		return fmt.Errorf("book %s - %w", item.BookID, domain.ErrBookNotFound)
+	}
+
+	available := bson.M{"$subtract": bson.A{
+		bson.M{"$ifNull": bson.A{"$quantity", 0}},
+		bson.M{"$ifNull": bson.A{"$reserved", 0}},
+	}}
+
+	filter := bson.M{
+		"_id":   objectID,
+		"$expr": bson.M{"$gte": bson.A{available, item.Quantity}},
+	}
+
+	res, err := br.coll.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"reserved": item.Quantity}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 1 {
+		return nil
+	}
+
+	if err := br.bookExists(ctx, objectID); err != nil {
+		return fmt.Errorf("book %s - %w", item.BookID, err)
+	}
+
+	return fmt.Errorf("book %s - %w", item.BookID, domain.ErrOutOfStock)
+}
+
+// unreserveBooks undoes reserveBook for a reservation that failed half way.
+func (br *BookRepo) unreserveBooks(ctx context.Context, items []book.StockItem) {
+	for _, item := range items {
+		if err := br.incStock(ctx, item.BookID, 0, -item.Quantity); err != nil {
+			br.log.Errorf("error in rolling back reservation of book %s: %v", item.BookID, err)
+		}
+	}
+}
+
+func (br *BookRepo) incStock(ctx context.Context, bookID string, quantity, reserved int64) error {
+	objectID, err := primitive.ObjectIDFromHex(bookID)
+	if err != nil {
+		return fmt.Errorf("book %s - %w", bookID, domain.ErrBookNotFound)
+	}
+
+	inc := bson.M{"quantity": quantity, "reserved": reserved}
+	if _, err := br.coll.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$inc": inc}); err != nil {
+		return fmt.Errorf("book %s - %w", bookID, err)
+	}
+
+	return nil
+}
+
+func (br *BookRepo) bookExists(ctx context.Context, objectID primitive.ObjectID) error {
+	count, err := br.coll.CountDocuments(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return domain.ErrBookNotFound
+	}
+	return nil
+}
+
+func (br *BookRepo) openReservation(ctx context.Context, reservationID string) (book.Reservation, error) {
+	var reservation book.Reservation
+	if err := br.reservations.FindOne(ctx, bson.M{"_id": reservationID}).Decode(&reservation); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return book.Reservation{}, domain.ErrReservationNotFound
+		}
+		return book.Reservation{}, err
+	}
+
+	if reservation.Status != book.ReservationReserved {
+		return book.Reservation{}, domain.ErrReservationClosed
+	}
+
+	return reservation, nil
+}
+
+// holdsNothing matches reservations none of whose books have copies held.
+var holdsNothing = bson.M{"$not": bson.A{
+	bson.M{"$anyElementTrue": bson.A{
+		bson.M{"$map": bson.M{
+			"input": bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{"$items", bson.M{}}}},
+			"in":    bson.M{"$gt": bson.A{"$$this.v", 0}},
+		}},
+	}},
+}}
+
+// errReservationHolds tells that the reservation is open but not in the state
+// closeReservation was asked to close it in.
+var errReservationHolds = errors.New("reservation holds books")
+
+// closeReservation moves an open reservation to the given status and returns
+// it as it was before, so the caller knows which copies it held. A non-nil
+// expr must match the reservation too, or errReservationHolds is returned.
+func (br *BookRepo) closeReservation(ctx context.Context, reservationID, status string, expr bson.M) (book.Reservation, error) {
+	filter := bson.M{"_id": reservationID, "status": book.ReservationReserved}
+	if expr != nil {
+		filter["$expr"] = expr
+	}
+
+	var reservation book.Reservation
+	err := br.reservations.FindOneAndUpdate(ctx,
+		filter,
+		bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}},
+	).Decode(&reservation)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return book.Reservation{}, err
+		}
+		if _, err := br.openReservation(ctx, reservationID); err != nil {
+			return book.Reservation{}, err
+		}
+		if expr != nil {
+			return book.Reservation{}, errReservationHolds
+		}
+		return book.Reservation{}, domain.ErrReservationClosed
+	}
+
+	reservation.Status = status
+	return reservation, nil
+}
+
+func reservedItems(reservation book.Reservation) []book.StockItem {
+	items := make([]book.StockItem, 0, len(reservation.Items))
+	for bookID, quantity := range reservation.Items {
+		if quantity > 0 {
+			items = append(items, book.StockItem{BookID: bookID, Quantity: quantity})
+		}
+	}
+	return items
+}
+
+// sameItems tells whether both lists hold the same copies of the same books.
+func sameItems(a, b []book.StockItem) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	quantities := make(map[string]int64, len(a))
+	for _, item := range a {
+		quantities[item.BookID] += item.Quantity
+	}
+	for _, item := range b {
+		if quantities[item.BookID] != item.Quantity {
+			return false
+		}
+		delete(quantities, item.BookID)
+	}
+	return len(quantities) == 0
+}
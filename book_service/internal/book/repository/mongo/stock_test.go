@@ -0,0 +1,29 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/Levap123/book_service/internal/book"
+)
+
+func TestSameItems(t *testing.T) {
+	items := []book.StockItem{{BookID: "a", Quantity: 1}, {BookID: "b", Quantity: 2}}
+
+	tests := []struct {
+		name  string
+		other []book.StockItem
+		same  bool
+	}{
+		{"same items in another order", []book.StockItem{{BookID: "b", Quantity: 2}, {BookID: "a", Quantity: 1}}, true},
+		{"another quantity", []book.StockItem{{BookID: "a", Quantity: 1}, {BookID: "b", Quantity: 3}}, false},
+		{"another book", []book.StockItem{{BookID: "a", Quantity: 1}, {BookID: "c", Quantity: 2}}, false},
+		{"fewer books", []book.StockItem{{BookID: "a", Quantity: 1}}, false},
+		{"no books", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := sameItems(items, tt.other); got != tt.same {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.same)
+		}
+	}
+}
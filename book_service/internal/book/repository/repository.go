@@ -2,18 +2,25 @@ package repository
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/Levap123/book_service/internal/book"
 	"github.com/go-redis/redis/v8"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
 )
 
 type Repo struct {
 	repo  IBookRepo
 	cache *redis.Client
 	log   *logrus.Logger
+	group singleflight.Group
 }
 
 func NewRepo(repo IBookRepo, cache *redis.Client, log *logrus.Logger) *Repo {
@@ -27,57 +34,302 @@ func NewRepo(repo IBookRepo, cache *redis.Client, log *logrus.Logger) *Repo {
 type IBookRepo interface {
 	Delete(ctx context.Context, bookID string) (string, error)
 	Create(ctx context.Context, book book.Book) (string, error)
+	Update(ctx context.Context, bookID string, update map[string]any) (book.Book, error)
 	GetByID(ctx context.Context, bookID string) (book.Book, error)
-	GetAll(ctx context.Context) ([]book.Book, error)
-	BooksFilter(ctx context.Context, genre, author, language, publisher []string) ([]book.Book, error)
+	GetByISBN(ctx context.Context, isbn string) (book.Book, error)
+	GetAll(ctx context.Context, page book.Page) ([]book.Book, string, error)
+	BooksFilter(ctx context.Context, genre, author, language, publisher []string, page book.Page) ([]book.Book, string, error)
+	GetByAuthor(ctx context.Context, author string) ([]book.Book, error)
+	GetByPublisher(ctx context.Context, publisher string) ([]book.Book, error)
+	GetByGenre(ctx context.Context, genre string) ([]book.Book, error)
+	GetByLanguage(ctx context.Context, language string) ([]book.Book, error)
+	Search(ctx context.Context, query book.SearchQuery) ([]book.Book, error)
+	SetStock(ctx context.Context, bookID string, quantity int64) (book.Book, error)
+	Reserve(ctx context.Context, reservationID string, items []book.StockItem, extend bool) (book.Reservation, error)
+	Release(ctx context.Context, reservationID string, items []book.StockItem) (book.Reservation, error)
+	Commit(ctx context.Context, reservationID string) (book.Reservation, error)
+	GetPriceAt(ctx context.Context, bookID string, at time.Time) (book.Price, error)
+	Upsert(ctx context.Context, b book.Book, dryRun bool) (string, bool, error)
+	Export(ctx context.Context, genre, author, language, publisher []string, send func(book.Book) error) error
 }
 
-const allBooksKey = "books:all"
+// List results can not be invalidated one by one, because a single book may
+// land on any page of any filter. Instead every key carries the current value
+// of versionKey, and every mutation bumps it, so stale entries are never read
+// again and expire on their own. Books are versioned too: a read that loaded a
+// book before a write stores it under the old version, where it is never found.
+const (
+	versionKey  = "books:version"
+	bookKey     = "books:v%d:id:%s"
+	allBooksKey = "books:v%d:all:%s"
+	filterKey   = "books:v%d:filter:%s"
+	isbnKey     = "books:v%d:isbn:%s"
+
+	cacheTTL = time.Minute * 5
+
+	// loadTimeout bounds a load shared by the callers missing the same key.
+	loadTimeout = time.Second * 5
+)
 
 func (r *Repo) Delete(ctx context.Context, bookID string) (string, error) {
-	return r.repo.Delete(ctx, bookID)
+	bookID, err := r.repo.Delete(ctx, bookID)
+	if err != nil {
+		return "", err
+	}
+
+	r.invalidate(ctx)
+	return bookID, nil
 }
 
 func (r *Repo) Create(ctx context.Context, book book.Book) (string, error) {
-	return r.repo.Create(ctx, book)
+	bookID, err := r.repo.Create(ctx, book)
+	if err != nil {
+		return "", err
+	}
+
+	r.invalidate(ctx)
+	return bookID, nil
+}
+
+func (r *Repo) Update(ctx context.Context, bookID string, update map[string]any) (book.Book, error) {
+	updated, err := r.repo.Update(ctx, bookID, update)
+	if err != nil {
+		return book.Book{}, err
+	}
+
+	r.invalidate(ctx)
+	return updated, nil
+}
+
+func (r *Repo) SetStock(ctx context.Context, bookID string, quantity int64) (book.Book, error) {
+	updated, err := r.repo.SetStock(ctx, bookID, quantity)
+	if err != nil {
+		return book.Book{}, err
+	}
+
+	r.invalidate(ctx)
+	return updated, nil
+}
+
+// Stock operations may fail half way and undo the part already done, so the
+// cache is invalidated whatever the outcome.
+
+func (r *Repo) Reserve(ctx context.Context, reservationID string, items []book.StockItem, extend bool) (book.Reservation, error) {
+	defer r.invalidate(ctx)
+	return r.repo.Reserve(ctx, reservationID, items, extend)
+}
+
+func (r *Repo) Release(ctx context.Context, reservationID string, items []book.StockItem) (book.Reservation, error) {
+	defer r.invalidate(ctx)
+	return r.repo.Release(ctx, reservationID, items)
+}
+
+func (r *Repo) Commit(ctx context.Context, reservationID string) (book.Reservation, error) {
+	defer r.invalidate(ctx)
+	return r.repo.Commit(ctx, reservationID)
+}
+
+// GetPriceAt is not cached: past prices never change and are rarely asked for
+// twice, while the current one is already part of the cached book.
+func (r *Repo) GetPriceAt(ctx context.Context, bookID string, at time.Time) (book.Price, error) {
+	return r.repo.GetPriceAt(ctx, bookID, at)
+}
+
+func (r *Repo) Upsert(ctx context.Context, b book.Book, dryRun bool) (string, bool, error) {
+	bookID, created, err := r.repo.Upsert(ctx, b, dryRun)
+	if err != nil {
+		return "", false, err
+	}
+
+	if !dryRun {
+		r.invalidate(ctx)
+	}
+	return bookID, created, nil
+}
+
+// Export reads straight from the database: an export is a single pass over
+// the catalog that would only flush everything else out of the cache.
+func (r *Repo) Export(ctx context.Context, genre, author, language, publisher []string, send func(book.Book) error) error {
+	return r.repo.Export(ctx, genre, author, language, publisher, send)
 }
 
 func (r *Repo) GetByID(ctx context.Context, bookID string) (book.Book, error) {
-	return r.repo.GetByID(ctx, bookID)
+	version, ok := r.version(ctx)
+	if !ok {
+		return r.repo.GetByID(ctx, bookID)
+	}
+
+	return cached(ctx, r, fmt.Sprintf(bookKey, version, bookID), func(ctx context.Context) (book.Book, error) {
+		return r.repo.GetByID(ctx, bookID)
+	})
 }
 
-func (r *Repo) BooksFilter(ctx context.Context, genre, author, language, publisher []string) ([]book.Book, error) {
-	return r.repo.BooksFilter(ctx, genre, author, language, publisher)
+// GetByISBN caches by ISBN rather than by book ID, as any write may move an
+// ISBN to another book.
+func (r *Repo) GetByISBN(ctx context.Context, isbn string) (book.Book, error) {
+	version, ok := r.version(ctx)
+	if !ok {
+		return r.repo.GetByISBN(ctx, isbn)
+	}
+
+	return cached(ctx, r, fmt.Sprintf(isbnKey, version, isbn), func(ctx context.Context) (book.Book, error) {
+		return r.repo.GetByISBN(ctx, isbn)
+	})
 }
 
-func (r *Repo) GetAll(ctx context.Context) ([]book.Book, error) {
-	getAllBytes := r.getFromRedis(ctx, allBooksKey)
+func (r *Repo) BooksFilter(ctx context.Context, genre, author, language, publisher []string, page book.Page) ([]book.Book, string, error) {
+	version, ok := r.version(ctx)
+	if !ok {
+		return r.repo.BooksFilter(ctx, genre, author, language, publisher, page)
+	}
 
-	if len(getAllBytes) != 0 {
-		var books []book.Book
-		if err := json.Unmarshal(getAllBytes, &books); err == nil {
-			r.log.Debug("get all books - getting from redis")
-			return books, nil
-		} else {
-			r.log.Error("repo - error in unmarshalling request - %v", err)
-		}
+	filter := booksFilter{
+		Genre:     genre,
+		Author:    author,
+		Language:  language,
+		Publisher: publisher,
+		Page:      page,
 	}
 
-	books, err := r.repo.GetAll(ctx)
+	key := fmt.Sprintf(filterKey, version, filter.hash())
+
+	cached, err := cached(ctx, r, key, func(ctx context.Context) (cachedPage, error) {
+		books, nextPageToken, err := r.repo.BooksFilter(ctx, genre, author, language, publisher, page)
+		return cachedPage{Books: books, NextPageToken: nextPageToken}, err
+	})
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	booksBytes, err := json.Marshal(books)
+	return cached.Books, cached.NextPageToken, nil
+}
+
+func (r *Repo) GetByAuthor(ctx context.Context, author string) ([]book.Book, error) {
+	return r.getByField(ctx, booksFilter{Author: []string{author}}, r.repo.GetByAuthor, author)
+}
+
+func (r *Repo) GetByPublisher(ctx context.Context, publisher string) ([]book.Book, error) {
+	return r.getByField(ctx, booksFilter{Publisher: []string{publisher}}, r.repo.GetByPublisher, publisher)
+}
+
+func (r *Repo) GetByGenre(ctx context.Context, genre string) ([]book.Book, error) {
+	return r.getByField(ctx, booksFilter{Genre: []string{genre}}, r.repo.GetByGenre, genre)
+}
+
+func (r *Repo) GetByLanguage(ctx context.Context, language string) ([]book.Book, error) {
+	return r.getByField(ctx, booksFilter{Language: []string{language}}, r.repo.GetByLanguage, language)
+}
+
+func (r *Repo) getByField(ctx context.Context, filter booksFilter, get func(ctx context.Context, value string) ([]book.Book, error), value string) ([]book.Book, error) {
+	version, ok := r.version(ctx)
+	if !ok {
+		return get(ctx, value)
+	}
+
+	key := fmt.Sprintf(filterKey, version, filter.hash())
+
+	return cached(ctx, r, key, func(ctx context.Context) ([]book.Book, error) {
+		return get(ctx, value)
+	})
+}
+
+func (r *Repo) Search(ctx context.Context, query book.SearchQuery) ([]book.Book, error) {
+	return r.repo.Search(ctx, query)
+}
+
+// cachedPage is the redis representation of one page of books.
+type cachedPage struct {
+	Books         []book.Book `json:"books"`
+	NextPageToken string      `json:"next_page_token"`
+}
+
+func (r *Repo) GetAll(ctx context.Context, page book.Page) ([]book.Book, string, error) {
+	version, ok := r.version(ctx)
+	if !ok {
+		return r.repo.GetAll(ctx, page)
+	}
+
+	key := fmt.Sprintf(allBooksKey, version, booksFilter{Page: page}.hash())
+
+	cached, err := cached(ctx, r, key, func(ctx context.Context) (cachedPage, error) {
+		books, nextPageToken, err := r.repo.GetAll(ctx, page)
+		return cachedPage{Books: books, NextPageToken: nextPageToken}, err
+	})
 	if err != nil {
-		r.log.Errorf("repo - error in marshalling request - %w", err)
-	} else {
-		status := r.cache.Set(ctx, allBooksKey, booksBytes, time.Minute*5)
-		if status.Err() != nil {
-			r.log.Errorf("repo - error in setting request to redis - %w", err)
+		return nil, "", err
+	}
+
+	return cached.Books, cached.NextPageToken, nil
+}
+
+// cached returns the value stored under key, loading and storing it on a miss.
+// Concurrent misses on the same key share a single load. The load runs on a
+// context of its own, so a caller that gives up fails alone instead of failing
+// every caller waiting on the same key.
+func cached[T any](ctx context.Context, r *Repo, key string, load func(ctx context.Context) (T, error)) (T, error) {
+	var value T
+
+	if bytes := r.getFromRedis(ctx, key); len(bytes) != 0 {
+		if err := json.Unmarshal(bytes, &value); err == nil {
+			r.log.Debugf("repo - %s - getting from redis", key)
+			return value, nil
+		} else {
+			r.log.Errorf("repo - error in unmarshalling request - %v", err)
 		}
 	}
-	return books, nil
+
+	loaded := r.group.DoChan(key, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+		defer cancel()
+
+		value, err := load(loadCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		r.set(loadCtx, key, value)
+		return value, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return value, ctx.Err()
+	case res := <-loaded:
+		if res.Err != nil {
+			return value, res.Err
+		}
+		return res.Val.(T), nil
+	}
+}
+
+func (r *Repo) set(ctx context.Context, key string, value any) {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		r.log.Errorf("repo - error in marshalling request - %v", err)
+		return
+	}
+
+	if err := r.cache.Set(ctx, key, bytes, cacheTTL).Err(); err != nil {
+		r.log.Errorf("repo - error in setting request to redis - %v", err)
+	}
+}
+
+// version returns the current list cache version. It reports false when redis
+// can not be reached, in which case lists are read straight from the repo.
+func (r *Repo) version(ctx context.Context) (int64, bool) {
+	version, err := r.cache.Get(ctx, versionKey).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		r.log.Errorf("repo - error in getting cache version - %v", err)
+		return 0, false
+	}
+	return version, true
+}
+
+// invalidate drops every cached book and list.
+func (r *Repo) invalidate(ctx context.Context) {
+	if err := r.cache.Incr(ctx, versionKey).Err(); err != nil {
+		r.log.Errorf("repo - error in bumping cache version - %v", err)
+	}
 }
 
 func (r *Repo) getFromRedis(ctx context.Context, key string) []byte {
@@ -87,3 +339,41 @@ func (r *Repo) getFromRedis(ctx context.Context, key string) []byte {
 	}
 	return cache
 }
+
+// booksFilter is a list query in a normalized form: the same set of values in
+// any order and with duplicates gives the same hash.
+type booksFilter struct {
+	Genre     []string  `json:"genre,omitempty"`
+	Author    []string  `json:"author,omitempty"`
+	Language  []string  `json:"language,omitempty"`
+	Publisher []string  `json:"publisher,omitempty"`
+	Page      book.Page `json:"page"`
+}
+
+func (f booksFilter) hash() string {
+	f.Genre = normalize(f.Genre)
+	f.Author = normalize(f.Author)
+	f.Language = normalize(f.Language)
+	f.Publisher = normalize(f.Publisher)
+
+	bytes, _ := json.Marshal(f)
+	sum := sha256.Sum256(bytes)
+	return hex.EncodeToString(sum[:16])
+}
+
+func normalize(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+
+	unique := sorted[:1]
+	for _, value := range sorted[1:] {
+		if value != unique[len(unique)-1] {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/Levap123/book_service/internal/book"
+)
+
+func TestBooksFilter_hash(t *testing.T) {
+	base := booksFilter{
+		Genre:  []string{"fantasy", "horror"},
+		Author: []string{"Stephen King"},
+		Page:   book.Page{Size: 20, SortBy: book.SortByTitle},
+	}
+
+	tests := []struct {
+		name   string
+		filter booksFilter
+		equal  bool
+	}{
+		{
+			name: "should ignore order of values",
+			filter: booksFilter{
+				Genre:  []string{"horror", "fantasy"},
+				Author: []string{"Stephen King"},
+				Page:   book.Page{Size: 20, SortBy: book.SortByTitle},
+			},
+			equal: true,
+		},
+		{
+			name: "should ignore duplicated values",
+			filter: booksFilter{
+				Genre:  []string{"fantasy", "horror", "fantasy"},
+				Author: []string{"Stephen King", "Stephen King"},
+				Page:   book.Page{Size: 20, SortBy: book.SortByTitle},
+			},
+			equal: true,
+		},
+		{
+			name: "should tell apart the fields values belong to",
+			filter: booksFilter{
+				Genre:     []string{"fantasy", "horror"},
+				Publisher: []string{"Stephen King"},
+				Page:      book.Page{Size: 20, SortBy: book.SortByTitle},
+			},
+			equal: false,
+		},
+		{
+			name: "should tell apart pages",
+			filter: booksFilter{
+				Genre:  []string{"fantasy", "horror"},
+				Author: []string{"Stephen King"},
+				Page:   book.Page{Size: 20, SortBy: book.SortByTitle, Token: "next"},
+			},
+			equal: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.hash() == base.hash(); got != tt.equal {
+				t.Errorf("booksFilter.hash(), expected equal = %v, got %v", tt.equal, got)
+			}
+		})
+	}
+}
@@ -2,6 +2,9 @@ package book
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/Levap123/book_service/internal/domain"
 )
@@ -13,9 +16,23 @@ type BookService struct {
 type IBookRepo interface {
 	Delete(ctx context.Context, bookID string) (string, error)
 	Create(ctx context.Context, book Book) (string, error)
+	Update(ctx context.Context, bookID string, update map[string]any) (Book, error)
 	GetByID(ctx context.Context, bookID string) (Book, error)
-	GetAll(ctx context.Context) ([]Book, error)
-	BooksFilter(ctx context.Context, genre, author, language, publisher []string) ([]Book, error)
+	GetByISBN(ctx context.Context, isbn string) (Book, error)
+	GetAll(ctx context.Context, page Page) ([]Book, string, error)
+	BooksFilter(ctx context.Context, genre, author, language, publisher []string, page Page) ([]Book, string, error)
+	GetByAuthor(ctx context.Context, author string) ([]Book, error)
+	GetByPublisher(ctx context.Context, publisher string) ([]Book, error)
+	GetByGenre(ctx context.Context, genre string) ([]Book, error)
+	GetByLanguage(ctx context.Context, language string) ([]Book, error)
+	Search(ctx context.Context, query SearchQuery) ([]Book, error)
+	SetStock(ctx context.Context, bookID string, quantity int64) (Book, error)
+	Reserve(ctx context.Context, reservationID string, items []StockItem, extend bool) (Reservation, error)
+	Release(ctx context.Context, reservationID string, items []StockItem) (Reservation, error)
+	Commit(ctx context.Context, reservationID string) (Reservation, error)
+	GetPriceAt(ctx context.Context, bookID string, at time.Time) (Price, error)
+	Upsert(ctx context.Context, book Book, dryRun bool) (string, bool, error)
+	Export(ctx context.Context, genre, author, language, publisher []string, send func(Book) error) error
 }
 
 func NewBookService(repo IBookRepo) *BookService {
@@ -32,6 +49,21 @@ func (bs *BookService) Create(ctx context.Context, book Book) (string, error) {
 	return bookID, nil
 }
 
+// Update applies the update to the book. It is completed with the stored book
+// first, as what is valid depends on it.
+func (bs *BookService) Update(ctx context.Context, bookID string, update map[string]any) (Book, error) {
+	stored, err := bs.GetByID(ctx, bookID)
+	if err != nil {
+		return Book{}, fmt.Errorf("book service - update - %w", err)
+	}
+
+	if err := completeUpdate(stored, update); err != nil {
+		return Book{}, fmt.Errorf("book service - update - %w", err)
+	}
+
+	return bs.repo.Update(ctx, bookID, update)
+}
+
 func (bs *BookService) GetByID(ctx context.Context, bookID string) (Book, error) {
 	book, err := bs.repo.GetByID(ctx, bookID)
 	if err != nil {
@@ -45,14 +77,132 @@ func (bs *BookService) GetByID(ctx context.Context, bookID string) (Book, error)
 	return book, err
 }
 
-func (bs *BookService) GetAll(ctx context.Context) ([]Book, error) {
-	return bs.repo.GetAll(ctx)
+func (bs *BookService) GetByISBN(ctx context.Context, isbn string) (Book, error) {
+	isbn13, _, err := normalizeISBN(isbn)
+	if err != nil {
+		return Book{}, fmt.Errorf("book service - get by ISBN - %w", err)
+	}
+	if isbn13 == "" {
+		return Book{}, fmt.Errorf("book service - get by ISBN - ISBN is empty - %w", domain.ErrInvalidISBN)
+	}
+
+	return bs.repo.GetByISBN(ctx, isbn13)
+}
+
+func (bs *BookService) GetAll(ctx context.Context, page Page) ([]Book, string, error) {
+	return bs.repo.GetAll(ctx, page)
 }
 
 func (bs *BookService) Delete(ctx context.Context, bookID string) (string, error) {
 	return bs.repo.Delete(ctx, bookID)
 }
 
-func (bs *BookService) BooksFilter(ctx context.Context, genre, author, language, publisher []string) ([]Book, error) {
-	return bs.repo.BooksFilter(ctx, genre, author, language, publisher)
+func (bs *BookService) BooksFilter(ctx context.Context, genre, author, language, publisher []string, page Page) ([]Book, string, error) {
+	return bs.repo.BooksFilter(ctx, genre, author, language, publisher, page)
+}
+
+func (bs *BookService) GetByAuthor(ctx context.Context, author string) ([]Book, error) {
+	return bs.repo.GetByAuthor(ctx, author)
+}
+
+func (bs *BookService) GetByPublisher(ctx context.Context, publisher string) ([]Book, error) {
+	return bs.repo.GetByPublisher(ctx, publisher)
+}
+
+func (bs *BookService) GetByGenre(ctx context.Context, genre string) ([]Book, error) {
+	return bs.repo.GetByGenre(ctx, genre)
+}
+
+func (bs *BookService) GetByLanguage(ctx context.Context, language string) ([]Book, error) {
+	return bs.repo.GetByLanguage(ctx, language)
+}
+
+func (bs *BookService) Search(ctx context.Context, query SearchQuery) ([]Book, error) {
+	return bs.repo.Search(ctx, query)
+}
+
+func (bs *BookService) SetStock(ctx context.Context, bookID string, quantity int64) (Book, error) {
+	if quantity < 0 {
+		return Book{}, fmt.Errorf("book service - set stock - quantity must not be negative - %w", domain.ErrInvalidStock)
+	}
+	return bs.repo.SetStock(ctx, bookID, quantity)
+}
+
+// Reserve holds the items under the reservation. Unless extend is set, the
+// reservation must be new or hold exactly the items already, so that a
+// retried reservation holds the books once.
+func (bs *BookService) Reserve(ctx context.Context, reservationID string, items []StockItem, extend bool) (Reservation, error) {
+	if reservationID == "" {
+		return Reservation{}, fmt.Errorf("book service - reserve - reservation ID is empty - %w", domain.ErrInvalidStock)
+	}
+	if len(items) == 0 {
+		return Reservation{}, fmt.Errorf("book service - reserve - nothing to reserve - %w", domain.ErrInvalidStock)
+	}
+	return bs.repo.Reserve(ctx, reservationID, items, extend)
+}
+
+func (bs *BookService) Release(ctx context.Context, reservationID string, items []StockItem) (Reservation, error) {
+	if reservationID == "" {
+		return Reservation{}, fmt.Errorf("book service - release - reservation ID is empty - %w", domain.ErrInvalidStock)
+	}
+	return bs.repo.Release(ctx, reservationID, items)
+}
+
+func (bs *BookService) Commit(ctx context.Context, reservationID string) (Reservation, error) {
+	if reservationID == "" {
+		return Reservation{}, fmt.Errorf("book service - commit - reservation ID is empty - %w", domain.ErrInvalidStock)
+	}
+	return bs.repo.Commit(ctx, reservationID)
+}
+
+func (bs *BookService) GetPriceAt(ctx context.Context, bookID string, at time.Time) (Price, error) {
+	return bs.repo.GetPriceAt(ctx, bookID, at)
+}
+
+// Import creates the book or updates the one with the same ISBN, or with the
+// same title and author when the book has no ISBN. Invalid books are reported
+// as rejected rather than failing the import.
+func (bs *BookService) Import(ctx context.Context, run *ImportRun, book Book) (ImportResult, error) {
+	if book.Title == "" || book.Author == "" {
+		return ImportResult{
+			Status: ImportRejected,
+			Reason: "title and author are required",
+		}, nil
+	}
+
+	if run.DryRun {
+		if bookID, ok := run.writtenBook(book); ok {
+			run.write(book, bookID)
+			return ImportResult{Status: ImportUpdated, BookID: bookID}, nil
+		}
+	}
+
+	bookID, created, err := bs.repo.Upsert(ctx, book, run.DryRun)
+	if errors.Is(err, domain.ErrDuplicateISBN) {
+		return ImportResult{
+			Status: ImportRejected,
+			Reason: domain.ErrDuplicateISBN.Error(),
+		}, nil
+	}
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("book service - import - %w", err)
+	}
+
+	if run.DryRun {
+		run.write(book, bookID)
+	}
+
+	result := ImportResult{
+		Status: ImportUpdated,
+		BookID: bookID,
+	}
+	if created {
+		result.Status = ImportCreated
+	}
+
+	return result, nil
+}
+
+func (bs *BookService) Export(ctx context.Context, genre, author, language, publisher []string, send func(Book) error) error {
+	return bs.repo.Export(ctx, genre, author, language, publisher, send)
 }
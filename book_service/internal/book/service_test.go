@@ -0,0 +1,79 @@
+package book
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// importRepo upserts books into a slice the way the mongo repo matches them:
+// by ISBN, or by title and author when the book has no ISBN.
+type importRepo struct {
+	IBookRepo
+	books []Book
+}
+
+func (r *importRepo) Upsert(ctx context.Context, b Book, dryRun bool) (string, bool, error) {
+	for i, stored := range r.books {
+		if (b.ISBN != "" && stored.ISBN == b.ISBN) || (b.ISBN == "" && stored.Title == b.Title && stored.Author == b.Author) {
+			if !dryRun {
+				r.books[i] = b
+				r.books[i].ID = stored.ID
+			}
+			return stored.ID, false, nil
+		}
+	}
+
+	if dryRun {
+		return "", true, nil
+	}
+	b.ID = fmt.Sprint(len(r.books) + 1)
+	r.books = append(r.books, b)
+	return b.ID, true, nil
+}
+
+func TestBookService_ImportDryRunReportsLikeImport(t *testing.T) {
+	rows := []Book{
+		{Title: "It", Author: "Stephen King", ISBN: "9780670813025"},
+		{Title: "It", Author: "Stephen King", ISBN: "9780670813025"},
+		{Title: "Misery", Author: "Stephen King"},
+		{Title: "Misery", Author: "Stephen King", Pages: 320},
+		{Title: "It", Author: "Stephen King"},
+		{Title: "Carrie", Author: "Stephen King", ISBN: "9780385086950"},
+		{Title: "Carrie"},
+		{Title: "Dune", Author: "Frank Herbert", ISBN: "9780441013593"},
+	}
+	stored := []Book{{ID: "stored", Title: "Dune", Author: "Frank Herbert", ISBN: "9780441013593"}}
+
+	report := func(dryRun bool) []string {
+		repo := &importRepo{books: append([]Book(nil), stored...)}
+		service := NewBookService(repo)
+		run := NewImportRun(dryRun)
+
+		statuses := make([]string, 0, len(rows))
+		for _, row := range rows {
+			result, err := service.Import(context.Background(), run, row)
+			if err != nil {
+				t.Fatalf("dry run %v: unexpected error: %v", dryRun, err)
+			}
+			statuses = append(statuses, result.Status)
+		}
+		return statuses
+	}
+
+	want := []string{
+		ImportCreated, ImportUpdated,
+		ImportCreated, ImportUpdated,
+		ImportUpdated,
+		ImportCreated,
+		ImportRejected,
+		ImportUpdated,
+	}
+	if got := report(false); !reflect.DeepEqual(got, want) {
+		t.Fatalf("import: got %v, want %v", got, want)
+	}
+	if got := report(true); !reflect.DeepEqual(got, want) {
+		t.Errorf("dry run: got %v, want %v", got, want)
+	}
+}
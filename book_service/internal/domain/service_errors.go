@@ -2,4 +2,19 @@ package domain
 
 import "errors"
 
-var ErrBookNotFound = errors.New("book not found")
+var (
+	ErrBookNotFound      = errors.New("book not found")
+	ErrInvalidUpdateMask = errors.New("update mask is invalid")
+	ErrInvalidPage       = errors.New("page request is invalid")
+	ErrInvalidQuery      = errors.New("search query is invalid")
+
+	ErrInvalidStock        = errors.New("stock request is invalid")
+	ErrOutOfStock          = errors.New("not enough books in stock")
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrReservationClosed   = errors.New("reservation is already released or committed")
+
+	ErrInvalidISBN   = errors.New("ISBN is invalid")
+	ErrDuplicateISBN = errors.New("book with this ISBN already exists")
+	ErrInvalidPrice  = errors.New("price is invalid")
+	ErrPriceNotFound = errors.New("price not found")
+)
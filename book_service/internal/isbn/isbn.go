@@ -0,0 +1,91 @@
+// Package isbn validates ISBN-10 and ISBN-13 numbers and converts them to the
+// ISBN-13 form the catalog stores.
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrLength   = errors.New("ISBN must have 10 or 13 digits")
+	ErrFormat   = errors.New("ISBN must only contain digits, hyphens and spaces")
+	ErrPrefix   = errors.New("ISBN-13 must start with 978 or 979")
+	ErrChecksum = errors.New("ISBN check digit is wrong")
+)
+
+// Normalize validates an ISBN-10 or ISBN-13, with or without hyphens and
+// spaces, and returns it as a bare ISBN-13.
+func Normalize(value string) (string, error) {
+	digits := strings.NewReplacer("-", "", " ", "").Replace(strings.ToUpper(strings.TrimSpace(value)))
+
+	switch len(digits) {
+	case 10:
+		if !isDigits(digits[:9]) || !(isDigits(digits[9:]) || digits[9] == 'X') {
+			return "", ErrFormat
+		}
+		if check10(digits[:9]) != digits[9] {
+			return "", ErrChecksum
+		}
+		return "978" + digits[:9] + string(check13("978"+digits[:9])), nil
+	case 13:
+		if !isDigits(digits) {
+			return "", ErrFormat
+		}
+		if !strings.HasPrefix(digits, "978") && !strings.HasPrefix(digits, "979") {
+			return "", ErrPrefix
+		}
+		if check13(digits[:12]) != digits[12] {
+			return "", ErrChecksum
+		}
+		return digits, nil
+	default:
+		return "", ErrLength
+	}
+}
+
+// To10 returns the ISBN-10 form of a normalized ISBN-13, which only exists
+// for the 978 prefix.
+func To10(isbn13 string) (string, bool) {
+	if len(isbn13) != 13 || !strings.HasPrefix(isbn13, "978") {
+		return "", false
+	}
+	return isbn13[3:12] + string(check10(isbn13[3:12])), true
+}
+
+// check10 returns the ISBN-10 check digit of the first nine digits.
+func check10(digits string) byte {
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(digits[i]-'0') * (10 - i)
+	}
+
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return 'X'
+	}
+	return byte('0' + check)
+}
+
+// check13 returns the ISBN-13 check digit of the first twelve digits.
+func check13(digits string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(digits[i]-'0') * weight
+	}
+
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isDigits(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package isbn
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+		err   error
+	}{
+		{name: "should keep ISBN-13", value: "9780441013593", want: "9780441013593"},
+		{name: "should strip hyphens", value: "978-0-441-01359-3", want: "9780441013593"},
+		{name: "should convert ISBN-10", value: "0-441-01359-7", want: "9780441013593"},
+		{name: "should accept X check digit", value: "080442957x", want: "9780804429573"},
+		{name: "should accept 979 prefix", value: "979-10-90636-07-1", want: "9791090636071"},
+		{name: "should reject wrong ISBN-13 check digit", value: "9780441013590", err: ErrChecksum},
+		{name: "should reject wrong ISBN-10 check digit", value: "0441013590", err: ErrChecksum},
+		{name: "should reject unknown prefix", value: "9770441013593", err: ErrPrefix},
+		{name: "should reject letters", value: "97804410135A3", err: ErrFormat},
+		{name: "should reject X inside ISBN-10", value: "04410X3597", err: ErrFormat},
+		{name: "should reject wrong length", value: "12345", err: ErrLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.value)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTo10(t *testing.T) {
+	if got, ok := To10("9780804429573"); !ok || got != "080442957X" {
+		t.Errorf("got %q, %v, want 080442957X", got, ok)
+	}
+	if _, ok := To10("9791090636071"); ok {
+		t.Errorf("979 ISBN has no ISBN-10 form")
+	}
+}
@@ -0,0 +1,89 @@
+// Package search turns book text into search terms. The catalog mixes Russian
+// and English books, so every word is stemmed with the rules of its own script.
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/snowballstem"
+	"github.com/blevesearch/snowballstem/english"
+	"github.com/blevesearch/snowballstem/russian"
+)
+
+const (
+	English = "english"
+	Russian = "russian"
+)
+
+// FieldWeights is the relevance weight of every searchable book field, keyed
+// by the field bson name.
+var FieldWeights = map[string]int{
+	"title":       10,
+	"author":      5,
+	"series":      3,
+	"description": 1,
+}
+
+var stopWords = wordSet(
+	"a an and are as at be by for from in is it of on or the to with",
+	"а в во да для до же за и из к как ли на не но о об от по с со то у",
+)
+
+func wordSet(lines ...string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, line := range lines {
+		for _, word := range strings.Fields(line) {
+			set[word] = struct{}{}
+		}
+	}
+	return set
+}
+
+// Language reports which language's stemming rules suit the text best:
+// Russian when it has more Cyrillic than Latin letters, English otherwise.
+func Language(text string) string {
+	var cyrillic, latin int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+
+	if cyrillic > latin {
+		return Russian
+	}
+	return English
+}
+
+// Terms splits the text into lowercase words, keeping apostrophes so the
+// english stemmer can strip possessives, drops stop words and returns
+// the stems of the rest in their original order.
+func Terms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+
+	env := snowballstem.NewEnv("")
+	terms := make([]string, 0, len(words))
+
+	for _, word := range words {
+		word = strings.ReplaceAll(strings.Trim(word, "'"), "ё", "е")
+		if _, ok := stopWords[word]; ok || word == "" {
+			continue
+		}
+
+		env.SetCurrent(word)
+		if Language(word) == Russian {
+			russian.Stem(env)
+		} else {
+			english.Stem(env)
+		}
+		terms = append(terms, env.Current())
+	}
+
+	return terms
+}
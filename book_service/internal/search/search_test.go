@@ -0,0 +1,82 @@
+package search_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Levap123/book_service/internal/search"
+)
+
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "should detect english",
+			text: "The Lord of the Rings",
+			want: search.English,
+		},
+		{
+			name: "should detect russian",
+			text: "Война и мир",
+			want: search.Russian,
+		},
+		{
+			name: "should pick the dominant script",
+			text: "Гарри Поттер и Harry",
+			want: search.Russian,
+		},
+		{
+			name: "should fall back to english",
+			text: "1984",
+			want: search.English,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := search.Language(tt.text); got != tt.want {
+				t.Errorf("Language(), expected = %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "should stem english words and drop stop words",
+			text: "The Running of the Wolves",
+			want: []string{"run", "wolv"},
+		},
+		{
+			name: "should stem russian words and drop stop words",
+			text: "Преступление и наказание",
+			want: []string{"преступлен", "наказан"},
+		},
+		{
+			name: "should stem every word with the rules of its script",
+			text: "Мастер и Margarita's cats",
+			want: []string{"мастер", "margarita", "cat"},
+		},
+		{
+			name: "should treat ё as е",
+			text: "Ёлка",
+			want: []string{"елк"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := search.Terms(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Terms(), expected = %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
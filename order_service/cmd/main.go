@@ -8,8 +8,13 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Levap123/order_service/internal/cart"
+	cartrepo "github.com/Levap123/order_service/internal/cart/repository"
+	cartpostgres "github.com/Levap123/order_service/internal/cart/repository/postgres"
+	"github.com/Levap123/order_service/internal/cart/repository/redis"
 	"github.com/Levap123/order_service/internal/configs"
 	"github.com/Levap123/order_service/internal/order"
+	"github.com/Levap123/order_service/internal/order/catalog"
 	"github.com/Levap123/order_service/internal/order/repository/postgres"
 	"github.com/Levap123/order_service/proto"
 	"github.com/Levap123/utils/lg"
@@ -41,10 +46,35 @@ func main() {
 		log.Fatalf("fatal in pinging db: %v", err)
 	}
 
+	redisClient := redis.InitRedis(cfg)
+	defer redisClient.Close()
+
+	ctxRedis, cancelRedis := context.WithTimeout(context.Background(), time.Second)
+	defer cancelRedis()
+
+	// Carts are read from postgres while redis is down, so it is not fatal.
+	if err := redisClient.Ping(ctxRedis).Err(); err != nil {
+		log.Errorf("error in pinging redis: %v", err)
+	}
+
+	ctxBooksrv, cancelBooksrv := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancelBooksrv()
+
+	connBooksrv, err := grpc.DialContext(ctxBooksrv, cfg.BookService.Addr, grpc.WithInsecure())
+	if err != nil {
+		log.Fatalf("fatal in connect to book service: %v", err)
+	}
+	defer connBooksrv.Close()
+
 	repo := postgres.NewOrderRepoPostgres(DB)
-	service := order.NewService(repo)
+	bookCatalog := catalog.NewBookCatalog(connBooksrv)
+	service := order.NewService(repo, bookCatalog)
 	handler := order.NewOrderHandler(service, log)
 
+	cartRepo := cartrepo.NewRepo(cartpostgres.NewCartRepoPostgres(DB), redisClient, log)
+	cartService := cart.NewService(cartRepo, bookCatalog, service)
+	cartHandler := cart.NewCartHandler(cartService, log)
+
 	listener, err := net.Listen("tcp", cfg.Server.Addr)
 	if err != nil {
 		log.Fatalf("error in starting listener: %v", err)
@@ -52,9 +82,10 @@ func main() {
 
 	srv := grpc.NewServer()
 	proto.RegisterOrdersServer(srv, handler)
+	proto.RegisterCartServer(srv, cartHandler)
 	reflection.Register(srv)
 
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
 
 	log.Info("server started")
@@ -0,0 +1,134 @@
+package cart
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Levap123/order_service/internal/domain"
+	"github.com/Levap123/order_service/proto"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type CartHandler struct {
+	service ICartService
+	proto.UnimplementedCartServer
+	logger *logrus.Logger
+}
+
+type ICartService interface {
+	Get(ctx context.Context, owner Owner) (Cart, error)
+	AddItem(ctx context.Context, owner Owner, bookID string, quantity uint32) (Cart, error)
+	SetQuantity(ctx context.Context, owner Owner, bookID string, quantity uint32) (Cart, error)
+	RemoveItem(ctx context.Context, owner Owner, bookID string) (Cart, error)
+	Checkout(ctx context.Context, userID uint64, currency, idempotencyKey string) (uint64, error)
+	MergeGuestCart(ctx context.Context, guestID string, userID uint64) (MergeReport, error)
+}
+
+func NewCartHandler(service ICartService, logger *logrus.Logger) *CartHandler {
+	return &CartHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *CartHandler) GetCart(ctx context.Context, req *proto.GetCartRequest) (*proto.CartInfo, error) {
+	owner, err := ownerFromReq(req.UserId, req.GuestId)
+	if err != nil {
+		return nil, cartError(err)
+	}
+
+	cart, err := h.service.Get(ctx, owner)
+	if err != nil {
+		h.logger.Errorf("error in getting cart: %v", err)
+		return nil, cartError(err)
+	}
+
+	return fromCartToResp(cart), nil
+}
+
+func (h *CartHandler) AddItem(ctx context.Context, req *proto.CartItemRequest) (*proto.CartInfo, error) {
+	owner, err := ownerFromReq(req.UserId, req.GuestId)
+	if err != nil {
+		return nil, cartError(err)
+	}
+
+	cart, err := h.service.AddItem(ctx, owner, req.BookId, req.Quantity)
+	if err != nil {
+		h.logger.Errorf("error in adding cart item: %v", err)
+		return nil, cartError(err)
+	}
+
+	return fromCartToResp(cart), nil
+}
+
+func (h *CartHandler) SetQuantity(ctx context.Context, req *proto.CartItemRequest) (*proto.CartInfo, error) {
+	owner, err := ownerFromReq(req.UserId, req.GuestId)
+	if err != nil {
+		return nil, cartError(err)
+	}
+
+	cart, err := h.service.SetQuantity(ctx, owner, req.BookId, req.Quantity)
+	if err != nil {
+		h.logger.Errorf("error in setting cart item quantity: %v", err)
+		return nil, cartError(err)
+	}
+
+	return fromCartToResp(cart), nil
+}
+
+func (h *CartHandler) RemoveItem(ctx context.Context, req *proto.RemoveCartItemRequest) (*proto.CartInfo, error) {
+	owner, err := ownerFromReq(req.UserId, req.GuestId)
+	if err != nil {
+		return nil, cartError(err)
+	}
+
+	cart, err := h.service.RemoveItem(ctx, owner, req.BookId)
+	if err != nil {
+		h.logger.Errorf("error in removing cart item: %v", err)
+		return nil, cartError(err)
+	}
+
+	return fromCartToResp(cart), nil
+}
+
+func (h *CartHandler) Checkout(ctx context.Context, req *proto.CheckoutRequest) (*proto.CheckoutResponse, error) {
+	orderID, err := h.service.Checkout(ctx, req.UserId, req.Currency, req.IdempotencyKey)
+	if err != nil {
+		h.logger.Errorf("error in checking out cart: %v", err)
+		return nil, cartError(err)
+	}
+
+	return &proto.CheckoutResponse{
+		OrderId: orderID,
+	}, nil
+}
+
+func (h *CartHandler) MergeGuestCart(ctx context.Context, req *proto.MergeGuestCartRequest) (*proto.MergeReport, error) {
+	report, err := h.service.MergeGuestCart(ctx, req.GuestId, req.UserId)
+	if err != nil {
+		h.logger.Errorf("error in merging guest cart: %v", err)
+		return nil, cartError(err)
+	}
+
+	return fromMergeReportToResp(report), nil
+}
+
+// cartError maps the cart errors and, for checkout, the order errors.
+func cartError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCartItem), errors.Is(err, domain.ErrInvalidCartOwner),
+		errors.Is(err, domain.ErrInvalidOrder):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrBookNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrCartEmpty), errors.Is(err, domain.ErrBookNotForSale),
+		errors.Is(err, domain.ErrPriceChanged), errors.Is(err, domain.ErrOutOfStock):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrIdempotencyConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	default:
+		return err
+	}
+}
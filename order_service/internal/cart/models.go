@@ -0,0 +1,204 @@
+package cart
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Levap123/order_service/internal/domain"
+	"github.com/Levap123/order_service/internal/order"
+	"github.com/Levap123/order_service/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MaxQuantity bounds the copies of one book in a cart.
+const MaxQuantity = 99
+
+// maxGuestIDLen bounds the guest IDs the gateway makes up for its cookies.
+const maxGuestIDLen = 64
+
+// Owner is whose cart it is: a user, or a guest who has not signed in yet.
+// Exactly one of the fields is set.
+type Owner struct {
+	UserID  uint64
+	GuestID string
+}
+
+func UserOwner(userID uint64) Owner {
+	return Owner{UserID: userID}
+}
+
+func GuestOwner(guestID string) Owner {
+	return Owner{GuestID: guestID}
+}
+
+func (o Owner) IsGuest() bool {
+	return o.GuestID != ""
+}
+
+func (o Owner) String() string {
+	if o.IsGuest() {
+		return "guest:" + o.GuestID
+	}
+	return fmt.Sprintf("user:%d", o.UserID)
+}
+
+func ownerFromReq(userID uint64, guestID string) (Owner, error) {
+	switch {
+	case userID != 0 && guestID != "":
+		return Owner{}, fmt.Errorf("cart belongs either to a user or to a guest - %w", domain.ErrInvalidCartOwner)
+	case userID != 0:
+		return UserOwner(userID), nil
+	case guestID != "" && len(guestID) <= maxGuestIDLen:
+		return GuestOwner(guestID), nil
+	default:
+		return Owner{}, fmt.Errorf("user or guest is required - %w", domain.ErrInvalidCartOwner)
+	}
+}
+
+type Cart struct {
+	UserID  uint64 `json:"user_id,omitempty"`
+	GuestID string `json:"guest_id,omitempty"`
+	Items   []Item `json:"items"`
+}
+
+func newCart(owner Owner, items []Item) Cart {
+	return Cart{
+		UserID:  owner.UserID,
+		GuestID: owner.GuestID,
+		Items:   items,
+	}
+}
+
+func (c Cart) Owner() Owner {
+	return Owner{UserID: c.UserID, GuestID: c.GuestID}
+}
+
+type Item struct {
+	BookID   string    `db:"book_id" json:"book_id"`
+	Quantity uint32    `db:"quantity" json:"quantity"`
+	AddedAt  time.Time `db:"added_at" json:"added_at"`
+}
+
+// Quantity returns how many copies of the book are in the cart.
+func (c Cart) Quantity(bookID string) uint32 {
+	for _, item := range c.Items {
+		if item.BookID == bookID {
+			return item.Quantity
+		}
+	}
+	return 0
+}
+
+func validateItem(bookID string, quantity uint32) error {
+	if bookID == "" {
+		return fmt.Errorf("book ID is empty - %w", domain.ErrInvalidCartItem)
+	}
+	if quantity == 0 || quantity > MaxQuantity {
+		return fmt.Errorf("quantity of book %s must be from 1 to %d - %w", bookID, MaxQuantity, domain.ErrInvalidCartItem)
+	}
+	return nil
+}
+
+// DropReason tells why books of a guest cart did not make it into the cart
+// of the user.
+type DropReason string
+
+const (
+	DropNotFound     DropReason = "not_found"
+	DropNotForSale   DropReason = "not_for_sale"
+	DropOutOfStock   DropReason = "out_of_stock"
+	DropLimitReached DropReason = "limit_reached"
+)
+
+type MergedItem struct {
+	BookID   string
+	Quantity uint32
+	Total    uint32
+}
+
+type DroppedItem struct {
+	BookID   string
+	Quantity uint32
+	Reason   DropReason
+}
+
+// MergeReport tells the client what became of its guest cart on sign-in.
+type MergeReport struct {
+	Cart    Cart
+	Merged  []MergedItem
+	Dropped []DroppedItem
+}
+
+// mergeQuantity splits the copies of a book in a guest cart into those that
+// can move into the user cart, which already holds some, and those that
+// can't. The user cart may hold no more than MaxQuantity copies and no more
+// than the catalog has available.
+func mergeQuantity(guest, user uint32, book order.CatalogBook) (moved, dropped uint32, reason DropReason) {
+	if book.Currency == "" {
+		return 0, guest, DropNotForSale
+	}
+
+	limit, reason := quantityLimit(book)
+	if user >= limit {
+		return 0, guest, reason
+	}
+	if room := limit - user; guest > room {
+		return room, guest - room, reason
+	}
+	return guest, 0, ""
+}
+
+// quantityLimit is how many copies of the book a cart may hold: no more than
+// MaxQuantity and no more than the catalog has available. The reason tells
+// which of the two bounds the limit.
+func quantityLimit(book order.CatalogBook) (uint32, DropReason) {
+	if book.Available >= MaxQuantity {
+		return MaxQuantity, DropLimitReached
+	}
+	if book.Available > 0 {
+		return uint32(book.Available), DropOutOfStock
+	}
+	return 0, DropOutOfStock
+}
+
+func fromCartToResp(cart Cart) *proto.CartInfo {
+	items := make([]*proto.CartItem, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		items = append(items, &proto.CartItem{
+			BookId:   item.BookID,
+			Quantity: item.Quantity,
+			AddedAt:  timestamppb.New(item.AddedAt),
+		})
+	}
+
+	return &proto.CartInfo{
+		UserId:  cart.UserID,
+		GuestId: cart.GuestID,
+		Items:   items,
+	}
+}
+
+func fromMergeReportToResp(report MergeReport) *proto.MergeReport {
+	resp := &proto.MergeReport{
+		Cart:    fromCartToResp(report.Cart),
+		Merged:  make([]*proto.MergedItem, 0, len(report.Merged)),
+		Dropped: make([]*proto.DroppedItem, 0, len(report.Dropped)),
+	}
+
+	for _, item := range report.Merged {
+		resp.Merged = append(resp.Merged, &proto.MergedItem{
+			BookId:   item.BookID,
+			Quantity: item.Quantity,
+			Total:    item.Total,
+		})
+	}
+	for _, item := range report.Dropped {
+		resp.Dropped = append(resp.Dropped, &proto.DroppedItem{
+			BookId:   item.BookID,
+			Quantity: item.Quantity,
+			Reason:   string(item.Reason),
+		})
+	}
+
+	return resp
+}
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Levap123/order_service/internal/cart"
+	"github.com/jmoiron/sqlx"
+)
+
+type CartRepo struct {
+	DB *sqlx.DB
+}
+
+func NewCartRepoPostgres(db *sqlx.DB) *CartRepo {
+	return &CartRepo{
+		DB: db,
+	}
+}
+
+const (
+	cartItemTable      = "cart_items"
+	guestCartItemTable = "guest_cart_items"
+	cartItemColumns    = "book_id, quantity, added_at"
+)
+
+// cartOf returns the table holding the carts of the owner's kind, the column
+// naming the owner in it and the owner's ID.
+func cartOf(owner cart.Owner) (table, column string, id any) {
+	if owner.IsGuest() {
+		return guestCartItemTable, "guest_id", owner.GuestID
+	}
+	return cartItemTable, "user_id", owner.UserID
+}
+
+func (cr *CartRepo) Get(ctx context.Context, owner cart.Owner) (cart.Cart, error) {
+	c, err := selectCart(ctx, cr.DB, owner)
+	if err != nil {
+		return cart.Cart{}, fmt.Errorf("cart repo - get - %w", err)
+	}
+	return c, nil
+}
+
+// SetQuantity puts the book into the cart or changes its quantity. A book
+// already in the cart keeps the time it was first added.
+func (cr *CartRepo) SetQuantity(ctx context.Context, owner cart.Owner, bookID string, quantity uint32, addedAt time.Time) (cart.Cart, error) {
+	table, column, id := cartOf(owner)
+	query := fmt.Sprintf(`INSERT INTO %s (%s, %s) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (%s, book_id) DO UPDATE SET quantity = EXCLUDED.quantity`, table, column, cartItemColumns, column)
+
+	if _, err := cr.DB.ExecContext(ctx, query, id, bookID, quantity, addedAt); err != nil {
+		return cart.Cart{}, fmt.Errorf("cart repo - set quantity - %w", err)
+	}
+
+	c, err := selectCart(ctx, cr.DB, owner)
+	if err != nil {
+		return cart.Cart{}, fmt.Errorf("cart repo - set quantity - %w", err)
+	}
+	return c, nil
+}
+
+// AddQuantity puts quantity more copies of the book into the cart in one
+// statement, so adds running at the same time all count. The cart ends up
+// with no more than limit copies, but an add never takes copies out of it.
+func (cr *CartRepo) AddQuantity(ctx context.Context, owner cart.Owner, bookID string, quantity, limit uint32, addedAt time.Time) (cart.Cart, error) {
+	if quantity > limit {
+		quantity = limit
+	}
+
+	table, column, id := cartOf(owner)
+	query := fmt.Sprintf(`INSERT INTO %s (%s, %s) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (%s, book_id) DO UPDATE
+		SET quantity = GREATEST(%s.quantity, LEAST(%s.quantity + EXCLUDED.quantity, $5))`,
+		table, column, cartItemColumns, column, table, table)
+
+	if _, err := cr.DB.ExecContext(ctx, query, id, bookID, quantity, addedAt, limit); err != nil {
+		return cart.Cart{}, fmt.Errorf("cart repo - add quantity - %w", err)
+	}
+
+	c, err := selectCart(ctx, cr.DB, owner)
+	if err != nil {
+		return cart.Cart{}, fmt.Errorf("cart repo - add quantity - %w", err)
+	}
+	return c, nil
+}
+
+func (cr *CartRepo) RemoveItem(ctx context.Context, owner cart.Owner, bookID string) (cart.Cart, error) {
+	table, column, id := cartOf(owner)
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = $1 AND book_id = $2", table, column)
+
+	if _, err := cr.DB.ExecContext(ctx, query, id, bookID); err != nil {
+		return cart.Cart{}, fmt.Errorf("cart repo - remove item - %w", err)
+	}
+
+	c, err := selectCart(ctx, cr.DB, owner)
+	if err != nil {
+		return cart.Cart{}, fmt.Errorf("cart repo - remove item - %w", err)
+	}
+	return c, nil
+}
+
+func (cr *CartRepo) Clear(ctx context.Context, owner cart.Owner) error {
+	table, column, id := cartOf(owner)
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = $1", table, column)
+
+	if _, err := cr.DB.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("cart repo - clear - %w", err)
+	}
+	return nil
+}
+
+func selectCart(ctx context.Context, db *sqlx.DB, owner cart.Owner) (cart.Cart, error) {
+	table, column, id := cartOf(owner)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1 ORDER BY added_at, book_id", cartItemColumns, table, column)
+
+	items := []cart.Item{}
+	if err := db.SelectContext(ctx, &items, query, id); err != nil {
+		return cart.Cart{}, err
+	}
+
+	return cart.Cart{
+		UserID:  owner.UserID,
+		GuestID: owner.GuestID,
+		Items:   items,
+	}, nil
+}
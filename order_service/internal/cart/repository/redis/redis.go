@@ -0,0 +1,12 @@
+package redis
+
+import (
+	"github.com/Levap123/order_service/internal/configs"
+	"github.com/go-redis/redis/v8"
+)
+
+func InitRedis(cfg *configs.Configs) *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr: cfg.Redis.Addr,
+	})
+}
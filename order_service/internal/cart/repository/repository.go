@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Levap123/order_service/internal/cart"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// Repo keeps carts in redis in front of postgres. Every change is written to
+// postgres, and carts are read from redis, filled from postgres on a miss.
+// When redis can't be reached, carts are read from postgres, so losing redis
+// loses no cart.
+//
+// A cart is cached under the version of its owner, which every change bumps.
+// A fill that read postgres before a change stores the cart under the old
+// version, where it is never read again, so checkout never sees a stale cart.
+type Repo struct {
+	repo  cart.ICartRepo
+	cache *redis.Client
+	log   *logrus.Logger
+}
+
+func NewRepo(repo cart.ICartRepo, cache *redis.Client, log *logrus.Logger) *Repo {
+	return &Repo{
+		repo:  repo,
+		cache: cache,
+		log:   log,
+	}
+}
+
+const (
+	versionKey = "carts:%s:version"
+	cartKey    = "carts:%s:v%d"
+
+	cacheTTL = time.Hour * 24
+)
+
+func (r *Repo) Get(ctx context.Context, owner cart.Owner) (cart.Cart, error) {
+	version, err := r.cache.Get(ctx, fmt.Sprintf(versionKey, owner)).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		r.log.Errorf("repo - error in getting cart version of %s - %v", owner, err)
+		return r.repo.Get(ctx, owner)
+	}
+	key := fmt.Sprintf(cartKey, owner, version)
+
+	bytes, err := r.cache.Get(ctx, key).Bytes()
+	if err == nil {
+		var c cart.Cart
+		if err := json.Unmarshal(bytes, &c); err == nil {
+			return c, nil
+		}
+		r.log.Errorf("repo - error in unmarshalling cart of %s - %v", owner, err)
+	} else if !errors.Is(err, redis.Nil) {
+		r.log.Errorf("repo - error in getting cart of %s from redis - %v", owner, err)
+	}
+
+	c, err := r.repo.Get(ctx, owner)
+	if err != nil {
+		return cart.Cart{}, err
+	}
+
+	r.set(ctx, owner, key, c)
+	return c, nil
+}
+
+func (r *Repo) SetQuantity(ctx context.Context, owner cart.Owner, bookID string, quantity uint32, addedAt time.Time) (cart.Cart, error) {
+	defer r.invalidate(ctx, owner)
+	return r.repo.SetQuantity(ctx, owner, bookID, quantity, addedAt)
+}
+
+func (r *Repo) AddQuantity(ctx context.Context, owner cart.Owner, bookID string, quantity, limit uint32, addedAt time.Time) (cart.Cart, error) {
+	defer r.invalidate(ctx, owner)
+	return r.repo.AddQuantity(ctx, owner, bookID, quantity, limit, addedAt)
+}
+
+func (r *Repo) RemoveItem(ctx context.Context, owner cart.Owner, bookID string) (cart.Cart, error) {
+	defer r.invalidate(ctx, owner)
+	return r.repo.RemoveItem(ctx, owner, bookID)
+}
+
+func (r *Repo) Clear(ctx context.Context, owner cart.Owner) error {
+	defer r.invalidate(ctx, owner)
+	return r.repo.Clear(ctx, owner)
+}
+
+func (r *Repo) set(ctx context.Context, owner cart.Owner, key string, c cart.Cart) {
+	bytes, err := json.Marshal(c)
+	if err != nil {
+		r.log.Errorf("repo - error in marshalling cart - %v", err)
+		return
+	}
+
+	if err := r.cache.Set(ctx, key, bytes, cacheTTL).Err(); err != nil {
+		r.log.Errorf("repo - error in setting cart to redis - %v", err)
+		return
+	}
+	r.keepVersion(ctx, owner)
+}
+
+// invalidate bumps the version of the owner's cart, so the copy in redis is
+// not read again. Changes may fail half way, so it is done whatever the
+// outcome.
+func (r *Repo) invalidate(ctx context.Context, owner cart.Owner) {
+	if err := r.cache.Incr(ctx, fmt.Sprintf(versionKey, owner)).Err(); err != nil {
+		r.log.Errorf("repo - error in bumping cart version of %s - %v", owner, err)
+		return
+	}
+	r.keepVersion(ctx, owner)
+}
+
+// keepVersion makes the version outlive every cart cached under it or before
+// it. Once the version expires it starts over from zero, and no old cart may
+// be left to be found under the numbers it goes through again.
+func (r *Repo) keepVersion(ctx context.Context, owner cart.Owner) {
+	if err := r.cache.Expire(ctx, fmt.Sprintf(versionKey, owner), cacheTTL).Err(); err != nil {
+		r.log.Errorf("repo - error in setting expiry of cart version of %s - %v", owner, err)
+	}
+}
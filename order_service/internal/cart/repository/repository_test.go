@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Levap123/order_service/internal/cart"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeRedis serves the few redis commands the repo uses from a map.
+type fakeRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (f *fakeRedis) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, server := net.Pipe()
+	go f.serve(server)
+	return client, nil
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, f.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) exec(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "GET":
+		value, ok := f.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		f.values[args[1]] = args[2]
+		return "+OK\r\n"
+	case "INCR":
+		n, _ := strconv.ParseInt(f.values[args[1]], 10, 64)
+		n++
+		f.values[args[1]] = strconv.FormatInt(n, 10)
+		return fmt.Sprintf(":%d\r\n", n)
+	case "EXPIRE":
+		_, ok := f.values[args[1]]
+		if !ok {
+			return ":0\r\n"
+		}
+		return ":1\r\n"
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := f.values[key]; ok {
+				delete(f.values, key)
+				deleted++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", deleted)
+	default:
+		return fmt.Sprintf("-ERR unknown command %s\r\n", args[0])
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line)[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, strings.TrimSuffix(arg, "\r\n"))
+	}
+	return args, nil
+}
+
+// memoryRepo keeps carts in a map. onGet, when set, runs after a cart was read
+// and before it is returned.
+type memoryRepo struct {
+	carts map[cart.Owner][]cart.Item
+	onGet func()
+}
+
+func (m *memoryRepo) Get(ctx context.Context, owner cart.Owner) (cart.Cart, error) {
+	c := cart.Cart{UserID: owner.UserID, GuestID: owner.GuestID, Items: append([]cart.Item(nil), m.carts[owner]...)}
+	if m.onGet != nil {
+		onGet := m.onGet
+		m.onGet = nil
+		onGet()
+	}
+	return c, nil
+}
+
+func (m *memoryRepo) SetQuantity(ctx context.Context, owner cart.Owner, bookID string, quantity uint32, addedAt time.Time) (cart.Cart, error) {
+	items := m.carts[owner][:0:0]
+	for _, item := range m.carts[owner] {
+		if item.BookID != bookID {
+			items = append(items, item)
+		}
+	}
+	m.carts[owner] = append(items, cart.Item{BookID: bookID, Quantity: quantity, AddedAt: addedAt})
+	return m.Get(ctx, owner)
+}
+
+func (m *memoryRepo) AddQuantity(ctx context.Context, owner cart.Owner, bookID string, quantity, limit uint32, addedAt time.Time) (cart.Cart, error) {
+	c, _ := m.Get(ctx, owner)
+	quantity += c.Quantity(bookID)
+	if quantity > limit {
+		quantity = limit
+	}
+	return m.SetQuantity(ctx, owner, bookID, quantity, addedAt)
+}
+
+func (m *memoryRepo) RemoveItem(ctx context.Context, owner cart.Owner, bookID string) (cart.Cart, error) {
+	items := m.carts[owner][:0:0]
+	for _, item := range m.carts[owner] {
+		if item.BookID != bookID {
+			items = append(items, item)
+		}
+	}
+	m.carts[owner] = items
+	return m.Get(ctx, owner)
+}
+
+func (m *memoryRepo) Clear(ctx context.Context, owner cart.Owner) error {
+	delete(m.carts, owner)
+	return nil
+}
+
+func newCachedRepo() (*Repo, *memoryRepo) {
+	cache := redis.NewClient(&redis.Options{
+		Dialer: (&fakeRedis{values: map[string]string{}}).dial,
+	})
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	inner := &memoryRepo{carts: map[cart.Owner][]cart.Item{}}
+	return NewRepo(inner, cache, log), inner
+}
+
+func TestRepo_GetAfterSetQuantity(t *testing.T) {
+	ctx := context.Background()
+	repo, _ := newCachedRepo()
+	owner := cart.UserOwner(7)
+
+	if _, err := repo.SetQuantity(ctx, owner, "a", 1, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := repo.Get(ctx, owner); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := repo.SetQuantity(ctx, owner, "a", 5, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c, err := repo.Get(ctx, owner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := c.Quantity("a"); got != 5 {
+		t.Errorf("got quantity %d, want 5", got)
+	}
+}
+
+func TestRepo_SlowFillLosesToWrite(t *testing.T) {
+	ctx := context.Background()
+	repo, inner := newCachedRepo()
+	owner := cart.UserOwner(7)
+
+	if _, err := repo.SetQuantity(ctx, owner, "a", 1, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The write lands after the fill read postgres and before it is cached.
+	inner.onGet = func() {
+		if _, err := repo.SetQuantity(ctx, owner, "a", 5, time.Now()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	stale, err := repo.Get(ctx, owner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := stale.Quantity("a"); got != 1 {
+		t.Fatalf("got quantity %d, want 1", got)
+	}
+
+	c, err := repo.Get(ctx, owner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := c.Quantity("a"); got != 5 {
+		t.Errorf("got quantity %d, want 5", got)
+	}
+}
+
+func TestRepo_GetAfterClear(t *testing.T) {
+	ctx := context.Background()
+	repo, _ := newCachedRepo()
+	owner := cart.GuestOwner("guest")
+
+	if _, err := repo.SetQuantity(ctx, owner, "a", 1, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := repo.Get(ctx, owner); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.Clear(ctx, owner); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c, err := repo.Get(ctx, owner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(c.Items) != 0 {
+		t.Errorf("got %v, want an empty cart", c.Items)
+	}
+}
@@ -0,0 +1,225 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Levap123/order_service/internal/domain"
+	"github.com/Levap123/order_service/internal/order"
+	"github.com/Levap123/order_service/proto"
+)
+
+type CartService struct {
+	repo    ICartRepo
+	catalog IBookCatalog
+	orders  IOrderCreator
+}
+
+func NewService(repo ICartRepo, catalog IBookCatalog, orders IOrderCreator) *CartService {
+	return &CartService{
+		repo:    repo,
+		catalog: catalog,
+		orders:  orders,
+	}
+}
+
+type ICartRepo interface {
+	Get(ctx context.Context, owner Owner) (Cart, error)
+	SetQuantity(ctx context.Context, owner Owner, bookID string, quantity uint32, addedAt time.Time) (Cart, error)
+	AddQuantity(ctx context.Context, owner Owner, bookID string, quantity, limit uint32, addedAt time.Time) (Cart, error)
+	RemoveItem(ctx context.Context, owner Owner, bookID string) (Cart, error)
+	Clear(ctx context.Context, owner Owner) error
+}
+
+type IBookCatalog interface {
+	GetBook(ctx context.Context, bookID string) (order.CatalogBook, error)
+}
+
+type IOrderCreator interface {
+	Create(ctx context.Context, dto order.CreateOrderDTO) (uint64, error)
+	OrderForKey(ctx context.Context, userID uint64, key string) (uint64, error)
+}
+
+func (cs *CartService) Get(ctx context.Context, owner Owner) (Cart, error) {
+	return cs.repo.Get(ctx, owner)
+}
+
+// AddItem puts quantity more copies of the book into the cart, as many as
+// fit under MaxQuantity and the copies the catalog has available. The copies
+// are added up by the repo, so adds running at the same time all count.
+func (cs *CartService) AddItem(ctx context.Context, owner Owner, bookID string, quantity uint32) (Cart, error) {
+	if err := validateItem(bookID, quantity); err != nil {
+		return Cart{}, fmt.Errorf("cart service - add item - %w", err)
+	}
+
+	book, err := cs.catalog.GetBook(ctx, bookID)
+	if err != nil {
+		return Cart{}, fmt.Errorf("cart service - add item - %w", err)
+	}
+
+	limit, _ := quantityLimit(book)
+	if limit == 0 {
+		return Cart{}, fmt.Errorf("cart service - add item - book %s - %w", bookID, domain.ErrOutOfStock)
+	}
+
+	return cs.repo.AddQuantity(ctx, owner, bookID, quantity, limit, time.Now())
+}
+
+// SetQuantity sets how many copies of the book the cart holds. Zero removes
+// the book. Only books of the catalog can be put into a cart.
+func (cs *CartService) SetQuantity(ctx context.Context, owner Owner, bookID string, quantity uint32) (Cart, error) {
+	if quantity == 0 {
+		return cs.RemoveItem(ctx, owner, bookID)
+	}
+
+	if err := validateItem(bookID, quantity); err != nil {
+		return Cart{}, fmt.Errorf("cart service - set quantity - %w", err)
+	}
+
+	if _, err := cs.catalog.GetBook(ctx, bookID); err != nil {
+		return Cart{}, fmt.Errorf("cart service - set quantity - %w", err)
+	}
+
+	return cs.repo.SetQuantity(ctx, owner, bookID, quantity, time.Now())
+}
+
+func (cs *CartService) RemoveItem(ctx context.Context, owner Owner, bookID string) (Cart, error) {
+	return cs.repo.RemoveItem(ctx, owner, bookID)
+}
+
+// Checkout orders every book of the cart at the current catalog price and
+// empties the cart once the order is made. Guests have to sign in first.
+//
+// A retry with the idempotency key of a checkout that went through finds the
+// cart already emptied, so it returns the order made for the key instead.
+func (cs *CartService) Checkout(ctx context.Context, userID uint64, currency, idempotencyKey string) (uint64, error) {
+	if userID == 0 {
+		return 0, fmt.Errorf("cart service - checkout - user is required - %w", domain.ErrInvalidCartOwner)
+	}
+
+	owner := UserOwner(userID)
+	cart, err := cs.repo.Get(ctx, owner)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(cart.Items) == 0 {
+		if idempotencyKey != "" {
+			orderID, err := cs.orders.OrderForKey(ctx, userID, idempotencyKey)
+			if err != nil {
+				return 0, fmt.Errorf("cart service - checkout - %w", err)
+			}
+			if orderID != 0 {
+				return orderID, nil
+			}
+		}
+		return 0, fmt.Errorf("cart service - checkout - %w", domain.ErrCartEmpty)
+	}
+
+	req := &proto.CreateOrderRequest{
+		UserId:         userID,
+		Currency:       currency,
+		IdempotencyKey: idempotencyKey,
+	}
+	for _, item := range cart.Items {
+		req.Items = append(req.Items, &proto.OrderItem{
+			BookId:   item.BookID,
+			Quantity: item.Quantity,
+		})
+	}
+
+	dto, err := order.NewCreateOrderDTO(req)
+	if err != nil {
+		return 0, fmt.Errorf("cart service - checkout - %w", err)
+	}
+
+	orderID, err := cs.orders.Create(ctx, dto)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := cs.repo.Clear(ctx, owner); err != nil {
+		return 0, fmt.Errorf("cart service - checkout - order %d is made but the cart is not emptied - %w", orderID, err)
+	}
+
+	return orderID, nil
+}
+
+// MergeGuestCart moves the books a guest has put into the cart before
+// signing in into the cart of the user. Quantities of books in both carts are
+// added up as far as the stock and MaxQuantity allow; books gone from sale
+// are dropped. Every book leaves the guest cart as soon as it is handled, so
+// a merge cut short can be run again without adding books twice.
+func (cs *CartService) MergeGuestCart(ctx context.Context, guestID string, userID uint64) (MergeReport, error) {
+	guestOwner, err := ownerFromReq(0, guestID)
+	if err != nil {
+		return MergeReport{}, fmt.Errorf("cart service - merge guest cart - %w", err)
+	}
+	if userID == 0 {
+		return MergeReport{}, fmt.Errorf("cart service - merge guest cart - user is required - %w", domain.ErrInvalidCartOwner)
+	}
+	userOwner := UserOwner(userID)
+
+	guest, err := cs.repo.Get(ctx, guestOwner)
+	if err != nil {
+		return MergeReport{}, err
+	}
+
+	report := MergeReport{}
+	report.Cart, err = cs.repo.Get(ctx, userOwner)
+	if err != nil {
+		return MergeReport{}, err
+	}
+
+	if len(guest.Items) == 0 {
+		return report, nil
+	}
+
+	for _, item := range guest.Items {
+		book, err := cs.catalog.GetBook(ctx, item.BookID)
+		if errors.Is(err, domain.ErrBookNotFound) {
+			report.Dropped = append(report.Dropped, DroppedItem{
+				BookID:   item.BookID,
+				Quantity: item.Quantity,
+				Reason:   DropNotFound,
+			})
+			if _, err := cs.repo.RemoveItem(ctx, guestOwner, item.BookID); err != nil {
+				return MergeReport{}, err
+			}
+			continue
+		}
+		if err != nil {
+			return MergeReport{}, fmt.Errorf("cart service - merge guest cart - %w", err)
+		}
+
+		inCart := report.Cart.Quantity(item.BookID)
+		moved, dropped, reason := mergeQuantity(item.Quantity, inCart, book)
+
+		if moved > 0 {
+			report.Cart, err = cs.repo.SetQuantity(ctx, userOwner, item.BookID, inCart+moved, item.AddedAt)
+			if err != nil {
+				return MergeReport{}, err
+			}
+			report.Merged = append(report.Merged, MergedItem{
+				BookID:   item.BookID,
+				Quantity: moved,
+				Total:    inCart + moved,
+			})
+		}
+		if dropped > 0 {
+			report.Dropped = append(report.Dropped, DroppedItem{
+				BookID:   item.BookID,
+				Quantity: dropped,
+				Reason:   reason,
+			})
+		}
+
+		if _, err := cs.repo.RemoveItem(ctx, guestOwner, item.BookID); err != nil {
+			return MergeReport{}, err
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,245 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Levap123/order_service/internal/domain"
+	"github.com/Levap123/order_service/internal/order"
+)
+
+type memoryRepo struct {
+	carts map[Owner]map[string]Item
+}
+
+func newMemoryRepo() *memoryRepo {
+	return &memoryRepo{carts: map[Owner]map[string]Item{}}
+}
+
+func (m *memoryRepo) Get(ctx context.Context, owner Owner) (Cart, error) {
+	cart := newCart(owner, nil)
+	for _, item := range m.carts[owner] {
+		cart.Items = append(cart.Items, item)
+	}
+	return cart, nil
+}
+
+func (m *memoryRepo) SetQuantity(ctx context.Context, owner Owner, bookID string, quantity uint32, addedAt time.Time) (Cart, error) {
+	if m.carts[owner] == nil {
+		m.carts[owner] = map[string]Item{}
+	}
+	m.carts[owner][bookID] = Item{BookID: bookID, Quantity: quantity, AddedAt: addedAt}
+	return m.Get(ctx, owner)
+}
+
+func (m *memoryRepo) AddQuantity(ctx context.Context, owner Owner, bookID string, quantity, limit uint32, addedAt time.Time) (Cart, error) {
+	item, ok := m.carts[owner][bookID]
+	if !ok {
+		item = Item{BookID: bookID, AddedAt: addedAt}
+	}
+
+	quantity += item.Quantity
+	if quantity > limit {
+		quantity = limit
+	}
+	if quantity > item.Quantity {
+		item.Quantity = quantity
+	}
+	return m.SetQuantity(ctx, owner, bookID, item.Quantity, item.AddedAt)
+}
+
+func (m *memoryRepo) RemoveItem(ctx context.Context, owner Owner, bookID string) (Cart, error) {
+	delete(m.carts[owner], bookID)
+	return m.Get(ctx, owner)
+}
+
+func (m *memoryRepo) Clear(ctx context.Context, owner Owner) error {
+	delete(m.carts, owner)
+	return nil
+}
+
+type fakeCatalog struct{}
+
+func (fakeCatalog) GetBook(ctx context.Context, bookID string) (order.CatalogBook, error) {
+	switch bookID {
+	case "missing":
+		return order.CatalogBook{}, domain.ErrBookNotFound
+	case "unpriced":
+		return order.CatalogBook{ID: bookID}, nil
+	case "scarce":
+		return order.CatalogBook{ID: bookID, Currency: "USD", Available: 3}, nil
+	case "sold-out":
+		return order.CatalogBook{ID: bookID, Currency: "USD"}, nil
+	}
+	return order.CatalogBook{ID: bookID, Currency: "USD", Available: 1000}, nil
+}
+
+type fakeOrders struct {
+	created []order.CreateOrderDTO
+	keys    map[string]uint64
+}
+
+func (f *fakeOrders) Create(ctx context.Context, dto order.CreateOrderDTO) (uint64, error) {
+	f.created = append(f.created, dto)
+	orderID := uint64(len(f.created))
+	if dto.IdempotencyKey != "" {
+		if f.keys == nil {
+			f.keys = map[string]uint64{}
+		}
+		f.keys[dto.IdempotencyKey] = orderID
+	}
+	return orderID, nil
+}
+
+func (f *fakeOrders) OrderForKey(ctx context.Context, userID uint64, key string) (uint64, error) {
+	return f.keys[key], nil
+}
+
+func TestCartService(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepo()
+	orders := &fakeOrders{}
+	service := NewService(repo, fakeCatalog{}, orders)
+	user := UserOwner(7)
+
+	if _, err := service.AddItem(ctx, user, "a", 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cart, err := service.AddItem(ctx, user, "a", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cart.Quantity("a"); got != 3 {
+		t.Errorf("got quantity %d, want the added quantities summed up", got)
+	}
+
+	if cart, _ := service.AddItem(ctx, user, "a", MaxQuantity); cart.Quantity("a") != MaxQuantity {
+		t.Errorf("got quantity %d, want no more than %d", cart.Quantity("a"), MaxQuantity)
+	}
+	if _, err := service.AddItem(ctx, user, "a", MaxQuantity+1); !errors.Is(err, domain.ErrInvalidCartItem) {
+		t.Errorf("got %v, want %v", err, domain.ErrInvalidCartItem)
+	}
+	guest := GuestOwner("guest")
+	if cart, _ := service.AddItem(ctx, guest, "scarce", 5); cart.Quantity("scarce") != 3 {
+		t.Errorf("got quantity %d, want the 3 available copies", cart.Quantity("scarce"))
+	}
+	if _, err := service.AddItem(ctx, guest, "sold-out", 1); !errors.Is(err, domain.ErrOutOfStock) {
+		t.Errorf("got %v, want %v", err, domain.ErrOutOfStock)
+	}
+	if _, err := service.AddItem(ctx, user, "missing", 1); !errors.Is(err, domain.ErrBookNotFound) {
+		t.Errorf("got %v, want %v", err, domain.ErrBookNotFound)
+	}
+
+	if cart, _ := service.SetQuantity(ctx, user, "a", 0); len(cart.Items) != 0 {
+		t.Errorf("zero quantity should remove the book: %+v", cart.Items)
+	}
+
+	if _, err := service.Checkout(ctx, 7, "", ""); !errors.Is(err, domain.ErrCartEmpty) {
+		t.Errorf("got %v, want %v", err, domain.ErrCartEmpty)
+	}
+
+	if _, err := service.SetQuantity(ctx, user, "b", 4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	orderID, err := service.Checkout(ctx, 7, "usd", "key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if orderID != 1 || len(orders.created) != 1 {
+		t.Fatalf("checkout should make one order, got %d", len(orders.created))
+	}
+	dto := orders.created[0]
+	if dto.UserID != 7 || dto.Currency != "USD" || dto.IdempotencyKey != "key" || len(dto.Items) != 1 || dto.Items[0].Quantity != 4 {
+		t.Errorf("unexpected order: %+v", dto)
+	}
+	if cart, _ := service.Get(ctx, user); len(cart.Items) != 0 {
+		t.Errorf("checkout should empty the cart: %+v", cart.Items)
+	}
+}
+
+func TestCartService_CheckoutRetry(t *testing.T) {
+	ctx := context.Background()
+	orders := &fakeOrders{}
+	service := NewService(newMemoryRepo(), fakeCatalog{}, orders)
+
+	if _, err := service.SetQuantity(ctx, UserOwner(7), "a", 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	orderID, err := service.Checkout(ctx, 7, "USD", "key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The client lost the response and retries with the same key.
+	retried, err := service.Checkout(ctx, 7, "USD", "key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if retried != orderID || len(orders.created) != 1 {
+		t.Errorf("got order %d after %d orders, want order %d made once", retried, len(orders.created), orderID)
+	}
+
+	if _, err := service.Checkout(ctx, 7, "USD", "other"); !errors.Is(err, domain.ErrCartEmpty) {
+		t.Errorf("got %v, want %v", err, domain.ErrCartEmpty)
+	}
+}
+
+func TestMergeGuestCart(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepo()
+	service := NewService(repo, fakeCatalog{}, &fakeOrders{})
+	guest, user := GuestOwner("g1"), UserOwner(7)
+
+	for bookID, quantity := range map[string]uint32{"a": 2, "b": MaxQuantity, "scarce": 2, "sold-out": 1, "unpriced": 1, "missing": 1} {
+		repo.SetQuantity(ctx, guest, bookID, quantity, time.Now())
+	}
+	repo.SetQuantity(ctx, user, "a", 1, time.Now())
+	repo.SetQuantity(ctx, user, "b", 1, time.Now())
+	repo.SetQuantity(ctx, user, "scarce", 2, time.Now())
+
+	report, err := service.MergeGuestCart(ctx, "g1", 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]uint32{"a": 3, "b": MaxQuantity, "scarce": 3}
+	for bookID, quantity := range want {
+		if got := report.Cart.Quantity(bookID); got != quantity {
+			t.Errorf("got %d copies of %s, want %d", got, bookID, quantity)
+		}
+	}
+	if len(report.Cart.Items) != len(want) {
+		t.Errorf("unexpected cart: %+v", report.Cart.Items)
+	}
+
+	dropped := map[string]DroppedItem{}
+	for _, item := range report.Dropped {
+		dropped[item.BookID] = item
+	}
+	wantDropped := map[string]DroppedItem{
+		"b":        {BookID: "b", Quantity: 1, Reason: DropLimitReached},
+		"scarce":   {BookID: "scarce", Quantity: 1, Reason: DropOutOfStock},
+		"sold-out": {BookID: "sold-out", Quantity: 1, Reason: DropOutOfStock},
+		"unpriced": {BookID: "unpriced", Quantity: 1, Reason: DropNotForSale},
+		"missing":  {BookID: "missing", Quantity: 1, Reason: DropNotFound},
+	}
+	if len(dropped) != len(wantDropped) {
+		t.Errorf("got dropped %+v, want %+v", report.Dropped, wantDropped)
+	}
+	for bookID, item := range wantDropped {
+		if dropped[bookID] != item {
+			t.Errorf("got dropped %+v, want %+v", dropped[bookID], item)
+		}
+	}
+
+	if cart, _ := service.Get(ctx, guest); len(cart.Items) != 0 {
+		t.Errorf("merge should empty the guest cart: %+v", cart.Items)
+	}
+
+	if _, err := service.MergeGuestCart(ctx, "", 7); !errors.Is(err, domain.ErrInvalidCartOwner) {
+		t.Errorf("got %v, want %v", err, domain.ErrInvalidCartOwner)
+	}
+}
@@ -7,6 +7,10 @@ type Configs struct {
 		Addr string `yaml:"addr"`
 	} `yaml:"server"`
 
+	BookService struct {
+		Addr string `yaml:"addr"`
+	} `yaml:"book_service"`
+
 	Postgres struct {
 		Port     string `yaml:"port"`
 		Host     string `yaml:"host"`
@@ -14,6 +18,10 @@ type Configs struct {
 		Username string `yaml:"username"`
 		DBName   string `yaml:"db_name"`
 	} `yaml:"postgres"`
+
+	Redis struct {
+		Addr string `yaml:"addr"`
+	} `yaml:"redis"`
 }
 
 func GetConfigs() (*Configs, error) {
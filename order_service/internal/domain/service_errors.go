@@ -0,0 +1,28 @@
+package domain
+
+import "errors"
+
+var (
+	ErrOrderNotFound = errors.New("order not found")
+	ErrInvalidOrder  = errors.New("order is invalid")
+
+	ErrInvalidStatus     = errors.New("order status is invalid")
+	ErrIllegalTransition = errors.New("order can not move to this status")
+	ErrVersionConflict   = errors.New("order was changed concurrently")
+	ErrCancelNotAllowed  = errors.New("order can not be cancelled")
+	ErrOrderNotEditable  = errors.New("order can not be changed")
+
+	ErrBookNotFound   = errors.New("book not found")
+	ErrBookNotForSale = errors.New("book can not be ordered")
+	ErrPriceChanged   = errors.New("book price has changed")
+	ErrOutOfStock     = errors.New("not enough books in stock")
+
+	ErrReservationClosed = errors.New("reservation is already released or committed")
+
+	ErrIdempotencyKeyTaken = errors.New("idempotency key is taken")
+	ErrIdempotencyConflict = errors.New("idempotency key was used for another order")
+
+	ErrInvalidCartItem  = errors.New("cart item is invalid")
+	ErrInvalidCartOwner = errors.New("cart owner is invalid")
+	ErrCartEmpty        = errors.New("cart is empty")
+)
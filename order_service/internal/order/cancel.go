@@ -0,0 +1,137 @@
+package order
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Levap123/order_service/internal/domain"
+	"github.com/Levap123/order_service/proto"
+)
+
+// CancelReason is why an order was cancelled, as stored in its history and
+// in its refund.
+type CancelReason string
+
+const (
+	CancelReasonCustomerRequest CancelReason = "customer_request"
+	CancelReasonDuplicateOrder  CancelReason = "duplicate_order"
+	CancelReasonPaymentFailed   CancelReason = "payment_failed"
+	CancelReasonOutOfStock      CancelReason = "out_of_stock"
+	CancelReasonFraudSuspected  CancelReason = "fraud_suspected"
+	CancelReasonOther           CancelReason = "other"
+)
+
+var cancelReasonsFromProto = map[proto.CancelReason]CancelReason{
+	proto.CancelReason_CANCEL_REASON_CUSTOMER_REQUEST: CancelReasonCustomerRequest,
+	proto.CancelReason_CANCEL_REASON_DUPLICATE_ORDER:  CancelReasonDuplicateOrder,
+	proto.CancelReason_CANCEL_REASON_PAYMENT_FAILED:   CancelReasonPaymentFailed,
+	proto.CancelReason_CANCEL_REASON_OUT_OF_STOCK:     CancelReasonOutOfStock,
+	proto.CancelReason_CANCEL_REASON_FRAUD_SUSPECTED:  CancelReasonFraudSuspected,
+	proto.CancelReason_CANCEL_REASON_OTHER:            CancelReasonOther,
+}
+
+// Cancellation is a request of a user to cancel an order.
+type Cancellation struct {
+	OrderID         uint64
+	UserID          uint64
+	Admin           bool
+	Reason          CancelReason
+	Comment         string
+	ExpectedVersion uint64
+}
+
+type RefundStatus string
+
+const (
+	RefundPending   RefundStatus = "pending"
+	RefundCompleted RefundStatus = "completed"
+	RefundFailed    RefundStatus = "failed"
+)
+
+// Refund is the money of a paid order owed back after it was cancelled.
+type Refund struct {
+	ID        uint64       `db:"id"`
+	OrderID   uint64       `db:"order_id"`
+	Amount    int64        `db:"amount"`
+	Currency  string       `db:"currency"`
+	Status    RefundStatus `db:"status"`
+	Reason    CancelReason `db:"reason"`
+	CreatedAt time.Time    `db:"created_at"`
+}
+
+// CancelResult tells what was done to make up for a cancelled order.
+type CancelResult struct {
+	Order         Order
+	StockReleased bool
+	Refund        *Refund
+}
+
+// canCancel tells whether an order in status s may be cancelled. Owners may
+// cancel only until the order is shipped, admins at any point before it is
+// closed. Cancelling is a transition of its own that ChangeOrderStatus never
+// makes, so it always releases the stock and opens the refund it owes.
+func canCancel(s Status, admin bool) bool {
+	switch s {
+	case StatusCreated, StatusPaid:
+		return true
+	case StatusShipped, StatusDelivered:
+		return admin
+	default:
+		return false
+	}
+}
+
+// holdsStock tells whether an order in status s still has its books reserved
+// in the book service. Shipping commits the reservation, so the books of a
+// shipped order have left the stock.
+func (s Status) holdsStock() bool {
+	return s == StatusCreated || s == StatusPaid
+}
+
+// isPaid tells whether the money of an order in status s was taken.
+func (s Status) isPaid() bool {
+	return s == StatusPaid || s == StatusShipped || s == StatusDelivered
+}
+
+func fromReqToCancellation(req *proto.CancelOrderRequest) (Cancellation, error) {
+	reason, ok := cancelReasonsFromProto[req.Reason]
+	if !ok {
+		return Cancellation{}, fmt.Errorf("cancel reason %s - %w", req.Reason, domain.ErrInvalidOrder)
+	}
+
+	return Cancellation{
+		OrderID:         req.Id,
+		UserID:          req.UserId,
+		Admin:           req.Admin,
+		Reason:          reason,
+		Comment:         strings.TrimSpace(req.Comment),
+		ExpectedVersion: req.ExpectedVersion,
+	}, nil
+}
+
+// eventReason is the reason recorded in the order history.
+func (c Cancellation) eventReason() string {
+	if c.Comment == "" {
+		return string(c.Reason)
+	}
+	return string(c.Reason) + ": " + c.Comment
+}
+
+func fromCancelResultToResp(result CancelResult) *proto.CancelOrderResponse {
+	resp := &proto.CancelOrderResponse{
+		Order:         fromOrderToResp(result.Order),
+		StockReleased: result.StockReleased,
+	}
+
+	if result.Refund != nil {
+		resp.Refund = &proto.Refund{
+			Id:       result.Refund.ID,
+			Amount:   result.Refund.Amount,
+			Currency: result.Refund.Currency,
+			Status:   string(result.Refund.Status),
+		}
+	}
+
+	return resp
+}
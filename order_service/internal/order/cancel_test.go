@@ -0,0 +1,69 @@
+package order
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Levap123/order_service/internal/domain"
+	"github.com/Levap123/order_service/proto"
+)
+
+func TestCanCancel(t *testing.T) {
+	tests := []struct {
+		status       Status
+		owner, admin bool
+	}{
+		{StatusCreated, true, true},
+		{StatusPaid, true, true},
+		{StatusShipped, false, true},
+		{StatusDelivered, false, true},
+		{StatusCancelled, false, false},
+		{StatusRefunded, false, false},
+	}
+
+	for _, tt := range tests {
+		if got := canCancel(tt.status, false); got != tt.owner {
+			t.Errorf("owner cancelling %s: got %v, want %v", tt.status, got, tt.owner)
+		}
+		if got := canCancel(tt.status, true); got != tt.admin {
+			t.Errorf("admin cancelling %s: got %v, want %v", tt.status, got, tt.admin)
+		}
+	}
+}
+
+func TestHoldsStock(t *testing.T) {
+	tests := map[Status]bool{
+		StatusCreated:   true,
+		StatusPaid:      true,
+		StatusShipped:   false,
+		StatusDelivered: false,
+		StatusCancelled: false,
+		StatusRefunded:  false,
+	}
+
+	for status, want := range tests {
+		if got := status.holdsStock(); got != want {
+			t.Errorf("%s holds stock: got %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestFromReqToCancellation(t *testing.T) {
+	c, err := fromReqToCancellation(&proto.CancelOrderRequest{
+		Id:      3,
+		UserId:  7,
+		Reason:  proto.CancelReason_CANCEL_REASON_DUPLICATE_ORDER,
+		Comment: " ordered twice ",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if reason := c.eventReason(); reason != "duplicate_order: ordered twice" {
+		t.Errorf("got event reason %q", reason)
+	}
+
+	if _, err := fromReqToCancellation(&proto.CancelOrderRequest{Id: 3}); !errors.Is(err, domain.ErrInvalidOrder) {
+		t.Errorf("got %v, want %v", err, domain.ErrInvalidOrder)
+	}
+}
@@ -0,0 +1,163 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Levap123/order_service/internal/domain"
+	"github.com/Levap123/order_service/internal/order"
+	"github.com/Levap123/order_service/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BookCatalog reads books and holds their stock through the book service.
+type BookCatalog struct {
+	cl proto.BookClient
+}
+
+func NewBookCatalog(conn *grpc.ClientConn) *BookCatalog {
+	return &BookCatalog{
+		cl: proto.NewBookClient(conn),
+	}
+}
+
+func (bc *BookCatalog) GetBook(ctx context.Context, bookID string) (order.CatalogBook, error) {
+	resp, err := bc.cl.GetByID(ctx, &proto.GetBookRequset{BookID: bookID})
+	if err != nil {
+		return order.CatalogBook{}, fmt.Errorf("book catalog - get book %s - %w", bookID, catalogError(err))
+	}
+
+	return order.CatalogBook{
+		ID:          resp.ID,
+		Title:       resp.Title,
+		PriceAmount: resp.PriceAmount,
+		Currency:    resp.Currency,
+		Available:   resp.Available,
+	}, nil
+}
+
+// Reserve holds the items under a new reservation. Either all of them are
+// held or none is. Reserving again with the same items holds them once, so a
+// reservation can be retried.
+func (bc *BookCatalog) Reserve(ctx context.Context, reservationID string, items []order.OrderItem) error {
+	req := &proto.ReserveRequest{
+		ReservationId: reservationID,
+		Items:         toStockItems(items),
+	}
+
+	if _, err := bc.cl.Reserve(ctx, req); err != nil {
+		return fmt.Errorf("book catalog - reserve - %w", catalogError(err))
+	}
+
+	return nil
+}
+
+// Extend holds the items under the reservation on top of what it holds
+// already. Either all of them are held or none is.
+func (bc *BookCatalog) Extend(ctx context.Context, reservationID string, items []order.OrderItem) error {
+	req := &proto.ReserveRequest{
+		ReservationId: reservationID,
+		Items:         toStockItems(items),
+		Extend:        true,
+	}
+
+	if _, err := bc.cl.Reserve(ctx, req); err != nil {
+		return fmt.Errorf("book catalog - extend - %w", catalogError(err))
+	}
+
+	return nil
+}
+
+// Release returns the items of the reservation to stock, all of them when
+// items is empty. Releasing a reservation that is already closed does
+// nothing, so a release can be retried.
+func (bc *BookCatalog) Release(ctx context.Context, reservationID string, items []order.OrderItem) error {
+	req := &proto.ReleaseRequest{
+		ReservationId: reservationID,
+		Items:         toStockItems(items),
+	}
+
+	if _, err := bc.cl.Release(ctx, req); err != nil {
+		err = catalogError(err)
+		if errors.Is(err, domain.ErrReservationClosed) {
+			return nil
+		}
+		return fmt.Errorf("book catalog - release - %w", err)
+	}
+
+	return nil
+}
+
+// Commit takes the reserved items out of stock for good. Committing a
+// reservation that is already closed does nothing, so a commit can be
+// retried.
+func (bc *BookCatalog) Commit(ctx context.Context, reservationID string) error {
+	req := &proto.CommitRequest{
+		ReservationId: reservationID,
+	}
+
+	if _, err := bc.cl.Commit(ctx, req); err != nil {
+		err = catalogError(err)
+		if errors.Is(err, domain.ErrReservationClosed) {
+			return nil
+		}
+		return fmt.Errorf("book catalog - commit - %w", err)
+	}
+
+	return nil
+}
+
+func toStockItems(items []order.OrderItem) []*proto.StockItem {
+	stock := make([]*proto.StockItem, 0, len(items))
+	for _, item := range items {
+		stock = append(stock, &proto.StockItem{
+			BookID:   item.BookID,
+			Quantity: int64(item.Quantity),
+		})
+	}
+	return stock
+}
+
+// Reasons the book service puts in the ErrorInfo of its stock errors.
+const (
+	reasonOutOfStock        = "OUT_OF_STOCK"
+	reasonReservationClosed = "RESERVATION_CLOSED"
+)
+
+// catalogError turns the errors of the book service that depend on the order
+// into domain errors and leaves the rest as they are. FailedPrecondition
+// covers more than stock, so it is told apart by the reason of the error.
+func catalogError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	switch st.Code() {
+	case codes.NotFound:
+		return fmt.Errorf("%s - %w", st.Message(), domain.ErrBookNotFound)
+	case codes.FailedPrecondition:
+		switch errorReason(st) {
+		case reasonOutOfStock:
+			return fmt.Errorf("%s - %w", st.Message(), domain.ErrOutOfStock)
+		case reasonReservationClosed:
+			return fmt.Errorf("%s - %w", st.Message(), domain.ErrReservationClosed)
+		}
+		return err
+	default:
+		return err
+	}
+}
+
+func errorReason(st *status.Status) string {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
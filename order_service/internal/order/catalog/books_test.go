@@ -0,0 +1,44 @@
+package catalog
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Levap123/order_service/internal/domain"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func withReason(t *testing.T, code codes.Code, reason string) error {
+	t.Helper()
+
+	st, err := status.New(code, "book service error").WithDetails(&errdetails.ErrorInfo{Reason: reason})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return st.Err()
+}
+
+func TestCatalogError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"not found", status.Error(codes.NotFound, "book not found"), domain.ErrBookNotFound},
+		{"out of stock", withReason(t, codes.FailedPrecondition, reasonOutOfStock), domain.ErrOutOfStock},
+		{"reservation closed", withReason(t, codes.FailedPrecondition, reasonReservationClosed), domain.ErrReservationClosed},
+		{"other precondition", status.Error(codes.FailedPrecondition, "something else"), nil},
+	}
+
+	for _, tt := range tests {
+		got := catalogError(tt.err)
+
+		for _, domainErr := range []error{domain.ErrBookNotFound, domain.ErrOutOfStock, domain.ErrReservationClosed} {
+			if errors.Is(got, domainErr) != (domainErr == tt.want) {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			}
+		}
+	}
+}
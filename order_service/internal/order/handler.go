@@ -2,9 +2,13 @@ package order
 
 import (
 	"context"
+	"errors"
 
+	"github.com/Levap123/order_service/internal/domain"
 	"github.com/Levap123/order_service/proto"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type OrderHandler struct {
@@ -17,8 +21,11 @@ type IOrderService interface {
 	Create(ctx context.Context, dto CreateOrderDTO) (uint64, error)
 	GetByID(ctx context.Context, ID uint64) (Order, error)
 	GetByUserID(ctx context.Context, userID uint64) ([]Order, error)
-	GetByUserIDAndStatus(ctx context.Context, userID uint64, status string) ([]Order, error)
-	ChangeOrderStatus(ctx context.Context, ID uint64, status string) (uint64, error)
+	GetByUserIDAndStatus(ctx context.Context, userID uint64, status Status) ([]Order, error)
+	ChangeOrderStatus(ctx context.Context, change StatusChange) (Order, error)
+	GetOrderHistory(ctx context.Context, orderID uint64) ([]StatusEvent, error)
+	CancelOrder(ctx context.Context, c Cancellation) (CancelResult, error)
+	UpdateOrderItems(ctx context.Context, update ItemsUpdate) (Order, error)
 }
 
 func NewOrderHandler(service IOrderService, logger *logrus.Logger) *OrderHandler {
@@ -29,13 +36,16 @@ func NewOrderHandler(service IOrderService, logger *logrus.Logger) *OrderHandler
 }
 
 func (h *OrderHandler) Create(ctx context.Context, req *proto.CreateOrderRequest) (*proto.CreateOrderResponse, error) {
-	dto := fromReqToCreateDTO(req)
+	dto, err := NewCreateOrderDTO(req)
+	if err != nil {
+		h.logger.Errorf("error in creating order: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 
 	resp, err := h.service.Create(ctx, dto)
 	if err != nil {
-		h.logger.Error("error in creating order: %v", err)
-
-		return nil, err
+		h.logger.Errorf("error in creating order: %v", err)
+		return nil, orderError(err)
 	}
 
 	return &proto.CreateOrderResponse{
@@ -46,7 +56,7 @@ func (h *OrderHandler) Create(ctx context.Context, req *proto.CreateOrderRequest
 func (h *OrderHandler) GetByUserID(ctx context.Context, req *proto.GetOrderByUserIDRequest) (*proto.OrderArray, error) {
 	resp, err := h.service.GetByUserID(ctx, req.UserId)
 	if err != nil {
-		h.logger.Error("error in getting order by user id: %v", err)
+		h.logger.Errorf("error in getting order by user id: %v", err)
 		return nil, err
 	}
 
@@ -64,29 +74,39 @@ func (h *OrderHandler) GetByUserID(ctx context.Context, req *proto.GetOrderByUse
 func (h *OrderHandler) GetByID(ctx context.Context, req *proto.GetOrderByIDRequest) (*proto.Order, error) {
 	order, err := h.service.GetByID(ctx, req.Id)
 	if err != nil {
-		h.logger.Error("error in getting order by id: %v", err)
-		return nil, err
+		h.logger.Errorf("error in getting order by id: %v", err)
+		return nil, orderError(err)
 	}
 
 	return fromOrderToResp(order), nil
 }
 
-func (h *OrderHandler) ChangeStatus(ctx context.Context, req *proto.ChangeStatusRequest) (*proto.CreateOrderResponse, error) {
-	resp, err := h.service.ChangeOrderStatus(ctx, req.Id, req.Status)
+func (h *OrderHandler) ChangeStatus(ctx context.Context, req *proto.ChangeStatusRequest) (*proto.Order, error) {
+	change, err := fromReqToStatusChange(req)
 	if err != nil {
-		h.logger.Error("error in changing order status: %v", err)
-		return nil, err
+		h.logger.Errorf("error in changing order status: %v", err)
+		return nil, orderError(err)
 	}
 
-	return &proto.CreateOrderResponse{
-		Id: resp,
-	}, nil
+	order, err := h.service.ChangeOrderStatus(ctx, change)
+	if err != nil {
+		h.logger.Errorf("error in changing order status: %v", err)
+		return nil, orderError(err)
+	}
+
+	return fromOrderToResp(order), nil
 }
 
 func (h *OrderHandler) GetByUserIDAndStatus(ctx context.Context, req *proto.GetOrderByUserIDAndStatusRequest) (*proto.OrderArray, error) {
-	resp, err := h.service.GetByUserIDAndStatus(ctx, req.UserId, req.Status)
+	status, err := statusFromProto(req.Status)
+	if err != nil {
+		h.logger.Errorf("error in getting orders by user id and status: %v", err)
+		return nil, orderError(err)
+	}
+
+	resp, err := h.service.GetByUserIDAndStatus(ctx, req.UserId, status)
 	if err != nil {
-		h.logger.Error("error in getting orders by user id and status: %v", err)
+		h.logger.Errorf("error in getting orders by user id and status: %v", err)
 		return nil, err
 	}
 
@@ -101,6 +121,69 @@ func (h *OrderHandler) GetByUserIDAndStatus(ctx context.Context, req *proto.GetO
 	}, nil
 }
 
+func (h *OrderHandler) GetOrderHistory(ctx context.Context, req *proto.GetOrderHistoryRequest) (*proto.OrderHistory, error) {
+	events, err := h.service.GetOrderHistory(ctx, req.Id)
+	if err != nil {
+		h.logger.Errorf("error in getting order history: %v", err)
+		return nil, orderError(err)
+	}
+
+	return fromStatusEventsToResp(req.Id, events), nil
+}
+
+func (h *OrderHandler) CancelOrder(ctx context.Context, req *proto.CancelOrderRequest) (*proto.CancelOrderResponse, error) {
+	cancellation, err := fromReqToCancellation(req)
+	if err != nil {
+		h.logger.Errorf("error in cancelling order: %v", err)
+		return nil, orderError(err)
+	}
+
+	result, err := h.service.CancelOrder(ctx, cancellation)
+	if err != nil {
+		h.logger.Errorf("error in cancelling order: %v", err)
+		return nil, orderError(err)
+	}
+
+	return fromCancelResultToResp(result), nil
+}
+
+func (h *OrderHandler) UpdateOrderItems(ctx context.Context, req *proto.UpdateOrderItemsRequest) (*proto.Order, error) {
+	update, err := fromReqToItemsUpdate(req)
+	if err != nil {
+		h.logger.Errorf("error in updating order items: %v", err)
+		return nil, orderError(err)
+	}
+
+	order, err := h.service.UpdateOrderItems(ctx, update)
+	if err != nil {
+		h.logger.Errorf("error in updating order items: %v", err)
+		return nil, orderError(err)
+	}
+
+	return fromOrderToResp(order), nil
+}
+
+func orderError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidOrder), errors.Is(err, domain.ErrInvalidStatus):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrOrderNotFound):
+		return status.Error(codes.NotFound, domain.ErrOrderNotFound.Error())
+	case errors.Is(err, domain.ErrBookNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrIllegalTransition), errors.Is(err, domain.ErrCancelNotAllowed),
+		errors.Is(err, domain.ErrOrderNotEditable), errors.Is(err, domain.ErrBookNotForSale),
+		errors.Is(err, domain.ErrPriceChanged), errors.Is(err, domain.ErrOutOfStock):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrVersionConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, domain.ErrIdempotencyConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	default:
+		return err
+	}
+}
+
 /*
 type OrdersServer interface {
 	mustEmbedUnimplementedOrdersServer()
@@ -1,47 +1,374 @@
 package order
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/Levap123/order_service/internal/domain"
 	"github.com/Levap123/order_service/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type CreateOrderDTO struct {
-	BookID string
-	UserID uint64
+	UserID         uint64
+	Currency       string
+	Items          []OrderItem
+	IdempotencyKey string
 }
 
+// IdempotencyKey ties a key the user sent with a create request to the order
+// made for it. RequestHash tells a retry of the request from another request
+// that reuses the key.
+type IdempotencyKey struct {
+	Key         string `db:"idempotency_key"`
+	RequestHash string `db:"request_hash"`
+	OrderID     uint64 `db:"order_id"`
+}
+
+// Order holds the stock of its books under ReservationID in the book service
+// until it is paid or cancelled.
 type Order struct {
-	ID      uint64    `db:"id"`
-	BookID  string    `db:"book_id"`
-	UserID  uint64    `db:"user_id"`
-	AddedAt time.Time `db:"added_at"`
-	Status  string    `db:"status"`
+	ID            uint64      `db:"id"`
+	UserID        uint64      `db:"user_id"`
+	AddedAt       time.Time   `db:"added_at"`
+	Status        Status      `db:"status"`
+	Version       uint64      `db:"version"`
+	Currency      string      `db:"currency"`
+	ReservationID string      `db:"reservation_id"`
+	Items         []OrderItem `db:"-"`
+}
+
+// OrderItem is one book of an order. Title and UnitPrice are copied from the
+// catalog when the order is made, so later catalog edits don't change the
+// order. UnitPrice is in minor units of the order currency.
+type OrderItem struct {
+	OrderID   uint64 `db:"order_id"`
+	BookID    string `db:"book_id"`
+	Title     string `db:"title"`
+	Quantity  uint32 `db:"quantity"`
+	UnitPrice int64  `db:"unit_price"`
+}
+
+// CatalogBook is what the book service knows about a book when it is ordered.
+type CatalogBook struct {
+	ID          string
+	Title       string
+	PriceAmount int64
+	Currency    string
+	Available   int64
+}
+
+func (i OrderItem) Subtotal() int64 {
+	return i.UnitPrice * int64(i.Quantity)
+}
+
+func (o Order) Total() int64 {
+	var total int64
+	for _, item := range o.Items {
+		total += item.Subtotal()
+	}
+	return total
 }
 
-func createOrderDTOToOrder(dto CreateOrderDTO) Order {
+// ItemsUpdate is a request to replace the items of an order.
+type ItemsUpdate struct {
+	OrderID         uint64
+	UserID          uint64
+	Items           []OrderItem
+	ExpectedVersion uint64
+}
+
+// StatusEvent records one transition of an order. From is empty for the
+// creation of the order.
+type StatusEvent struct {
+	ID        uint64    `db:"id"`
+	OrderID   uint64    `db:"order_id"`
+	From      Status    `db:"from_status"`
+	To        Status    `db:"to_status"`
+	ActorID   uint64    `db:"actor_id"`
+	Reason    string    `db:"reason"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// StatusChange is a request to move an order to another status. A zero
+// ExpectedVersion skips the check of the order version.
+type StatusChange struct {
+	OrderID         uint64
+	Status          Status
+	ExpectedVersion uint64
+	ActorID         uint64
+	Reason          string
+}
+
+func createOrderDTOToOrder(dto CreateOrderDTO, reservationID string) Order {
 	return Order{
-		BookID:  dto.BookID,
-		UserID:  dto.UserID,
-		Status:  "создан",
-		AddedAt: time.Now(),
+		UserID:        dto.UserID,
+		Status:        StatusCreated,
+		Version:       1,
+		AddedAt:       time.Now(),
+		Currency:      dto.Currency,
+		ReservationID: reservationID,
+		Items:         dto.Items,
+	}
+}
+
+// snapshotItems copies the title and price of each book into its item. All
+// the books must be priced in the order currency, which defaults to the
+// currency of the first book. A unit price set by the client is the price it
+// saw and must still be the catalog price.
+func snapshotItems(dto CreateOrderDTO, books []CatalogBook) (CreateOrderDTO, error) {
+	items := make([]OrderItem, 0, len(dto.Items))
+
+	for i, item := range dto.Items {
+		book := books[i]
+		if book.Currency == "" {
+			return CreateOrderDTO{}, fmt.Errorf("book %s has no price - %w", item.BookID, domain.ErrBookNotForSale)
+		}
+
+		if dto.Currency == "" {
+			dto.Currency = book.Currency
+		}
+		if book.Currency != dto.Currency {
+			return CreateOrderDTO{}, fmt.Errorf("book %s is priced in %s, not in %s - %w", item.BookID, book.Currency, dto.Currency, domain.ErrBookNotForSale)
+		}
+
+		if item.UnitPrice != 0 && item.UnitPrice != book.PriceAmount {
+			return CreateOrderDTO{}, fmt.Errorf("book %s costs %d, not %d - %w", item.BookID, book.PriceAmount, item.UnitPrice, domain.ErrPriceChanged)
+		}
+
+		item.Title = book.Title
+		item.UnitPrice = book.PriceAmount
+		items = append(items, item)
 	}
+
+	dto.Items = items
+	return dto, nil
 }
 
-func fromReqToCreateDTO(req *proto.CreateOrderRequest) CreateOrderDTO {
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// NewCreateOrderDTO validates the order and merges the items of the same
+// book, which must then have the same unit price. Orders made from a cart go
+// through it as well.
+func NewCreateOrderDTO(req *proto.CreateOrderRequest) (CreateOrderDTO, error) {
+	if len(req.Items) == 0 {
+		return CreateOrderDTO{}, fmt.Errorf("order has no items - %w", domain.ErrInvalidOrder)
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if currency != "" && !currencyCode.MatchString(currency) {
+		return CreateOrderDTO{}, fmt.Errorf("currency %q is not an ISO 4217 code - %w", req.Currency, domain.ErrInvalidOrder)
+	}
+
+	key := strings.TrimSpace(req.IdempotencyKey)
+	if len(key) > maxIdempotencyKeyLen {
+		return CreateOrderDTO{}, fmt.Errorf("idempotency key is longer than %d - %w", maxIdempotencyKeyLen, domain.ErrInvalidOrder)
+	}
+
+	items, err := newOrderItems(req.Items)
+	if err != nil {
+		return CreateOrderDTO{}, err
+	}
+
 	return CreateOrderDTO{
-		BookID: req.BookId,
-		UserID: req.UserId,
+		UserID:         req.UserId,
+		Currency:       currency,
+		Items:          items,
+		IdempotencyKey: key,
+	}, nil
+}
+
+const maxIdempotencyKeyLen = 255
+
+// requestHash fingerprints what the client asked for in a create request, so
+// the order of the items doesn't matter but any other change does.
+func requestHash(dto CreateOrderDTO) string {
+	items := make([]OrderItem, len(dto.Items))
+	copy(items, dto.Items)
+	sort.Slice(items, func(i, j int) bool { return items[i].BookID < items[j].BookID })
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", dto.Currency)
+	for _, item := range items {
+		fmt.Fprintf(h, "%q %d %d\n", item.BookID, item.Quantity, item.UnitPrice)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func fromReqToItemsUpdate(req *proto.UpdateOrderItemsRequest) (ItemsUpdate, error) {
+	if req.ExpectedVersion == 0 {
+		return ItemsUpdate{}, fmt.Errorf("expected version is required - %w", domain.ErrInvalidOrder)
+	}
+	if len(req.Items) == 0 {
+		return ItemsUpdate{}, fmt.Errorf("order has no items - %w", domain.ErrInvalidOrder)
+	}
+
+	items, err := newOrderItems(req.Items)
+	if err != nil {
+		return ItemsUpdate{}, err
+	}
+
+	return ItemsUpdate{
+		OrderID:         req.Id,
+		UserID:          req.UserId,
+		Items:           items,
+		ExpectedVersion: req.ExpectedVersion,
+	}, nil
+}
+
+// keepOrderedItems gives the items of books that are already in the order
+// the title and price they were ordered at and returns the items of the
+// books that are new to the order.
+func keepOrderedItems(ordered, items []OrderItem) (kept, added []OrderItem, err error) {
+	byBook := make(map[string]OrderItem, len(ordered))
+	for _, item := range ordered {
+		byBook[item.BookID] = item
+	}
+
+	for _, item := range items {
+		old, ok := byBook[item.BookID]
+		if !ok {
+			added = append(added, item)
+			continue
+		}
+
+		if item.UnitPrice != 0 && item.UnitPrice != old.UnitPrice {
+			return nil, nil, fmt.Errorf("book %s was ordered at %d, not %d - %w", item.BookID, old.UnitPrice, item.UnitPrice, domain.ErrPriceChanged)
+		}
+
+		item.Title = old.Title
+		item.UnitPrice = old.UnitPrice
+		kept = append(kept, item)
+	}
+
+	return kept, added, nil
+}
+
+// stockDelta returns how many more books of each kind the new items need
+// than the old ones, and how many fewer.
+func stockDelta(old, new []OrderItem) (more, less []OrderItem) {
+	quantities := make(map[string]int64, len(old))
+	for _, item := range old {
+		quantities[item.BookID] = int64(item.Quantity)
+	}
+
+	for _, item := range new {
+		switch diff := int64(item.Quantity) - quantities[item.BookID]; {
+		case diff > 0:
+			more = append(more, OrderItem{BookID: item.BookID, Quantity: uint32(diff)})
+		case diff < 0:
+			less = append(less, OrderItem{BookID: item.BookID, Quantity: uint32(-diff)})
+		}
+		delete(quantities, item.BookID)
+	}
+
+	for _, item := range old {
+		if quantity, ok := quantities[item.BookID]; ok {
+			less = append(less, OrderItem{BookID: item.BookID, Quantity: uint32(quantity)})
+		}
+	}
+
+	return more, less
+}
+
+func newOrderItems(req []*proto.OrderItem) ([]OrderItem, error) {
+	positions := make(map[string]int, len(req))
+	items := make([]OrderItem, 0, len(req))
+
+	for _, item := range req {
+		if item.BookId == "" {
+			return nil, fmt.Errorf("book ID is empty - %w", domain.ErrInvalidOrder)
+		}
+		if item.Quantity == 0 {
+			return nil, fmt.Errorf("quantity of book %s must be positive - %w", item.BookId, domain.ErrInvalidOrder)
+		}
+		if item.UnitPrice < 0 {
+			return nil, fmt.Errorf("unit price of book %s must not be negative - %w", item.BookId, domain.ErrInvalidOrder)
+		}
+
+		if i, ok := positions[item.BookId]; ok {
+			if items[i].UnitPrice != item.UnitPrice {
+				return nil, fmt.Errorf("book %s has different unit prices - %w", item.BookId, domain.ErrInvalidOrder)
+			}
+			items[i].Quantity += item.Quantity
+			continue
+		}
+
+		positions[item.BookId] = len(items)
+		items = append(items, OrderItem{
+			BookID:    item.BookId,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		})
+	}
+
+	return items, nil
+}
+
+func fromOrderItemToResp(item OrderItem) *proto.OrderItem {
+	return &proto.OrderItem{
+		BookId:    item.BookID,
+		Title:     item.Title,
+		Quantity:  item.Quantity,
+		UnitPrice: item.UnitPrice,
+		Subtotal:  item.Subtotal(),
 	}
 }
 
 func fromOrderToResp(order Order) *proto.Order {
+	items := make([]*proto.OrderItem, 0, len(order.Items))
+	for _, item := range order.Items {
+		items = append(items, fromOrderItemToResp(item))
+	}
+
 	return &proto.Order{
-		Id:      order.ID,
-		UserId:  order.UserID,
-		BookId:  order.BookID,
-		Status:  order.Status,
-		AddedAt: timestamppb.New(order.AddedAt),
+		Id:       order.ID,
+		UserId:   order.UserID,
+		Status:   statusToProto(order.Status),
+		Version:  order.Version,
+		AddedAt:  timestamppb.New(order.AddedAt),
+		Items:    items,
+		Currency: order.Currency,
+		Total:    order.Total(),
 	}
 }
+
+func fromReqToStatusChange(req *proto.ChangeStatusRequest) (StatusChange, error) {
+	status, err := statusFromProto(req.Status)
+	if err != nil {
+		return StatusChange{}, err
+	}
+
+	return StatusChange{
+		OrderID:         req.Id,
+		Status:          status,
+		ExpectedVersion: req.ExpectedVersion,
+		ActorID:         req.ActorId,
+		Reason:          strings.TrimSpace(req.Reason),
+	}, nil
+}
+
+func fromStatusEventsToResp(orderID uint64, events []StatusEvent) *proto.OrderHistory {
+	resp := &proto.OrderHistory{
+		Id:     orderID,
+		Events: make([]*proto.OrderStatusEvent, 0, len(events)),
+	}
+
+	for _, event := range events {
+		resp.Events = append(resp.Events, &proto.OrderStatusEvent{
+			From:    statusToProto(event.From),
+			To:      statusToProto(event.To),
+			ActorId: event.ActorID,
+			Reason:  event.Reason,
+			At:      timestamppb.New(event.CreatedAt),
+		})
+	}
+
+	return resp
+}
@@ -0,0 +1,186 @@
+package order
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Levap123/order_service/internal/domain"
+	"github.com/Levap123/order_service/proto"
+)
+
+func TestFromReqToCreateDTO(t *testing.T) {
+	dto, err := NewCreateOrderDTO(&proto.CreateOrderRequest{
+		UserId:   7,
+		Currency: "usd",
+		Items: []*proto.OrderItem{
+			{BookId: "a", Quantity: 1, UnitPrice: 1000},
+			{BookId: "b", Quantity: 2, UnitPrice: 250},
+			{BookId: "a", Quantity: 2, UnitPrice: 1000},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if dto.Currency != "USD" {
+		t.Errorf("got currency %q, want USD", dto.Currency)
+	}
+	if len(dto.Items) != 2 || dto.Items[0].Quantity != 3 {
+		t.Fatalf("items of the same book should be merged: %+v", dto.Items)
+	}
+
+	order := createOrderDTOToOrder(dto, "")
+	if total := order.Total(); total != 3500 {
+		t.Errorf("got total %d, want 3500", total)
+	}
+}
+
+func TestFromReqToCreateDTOInvalid(t *testing.T) {
+	tests := map[string]*proto.CreateOrderRequest{
+		"no items":       {UserId: 7},
+		"empty book ID":  {Items: []*proto.OrderItem{{Quantity: 1}}},
+		"zero quantity":  {Items: []*proto.OrderItem{{BookId: "a"}}},
+		"negative price": {Items: []*proto.OrderItem{{BookId: "a", Quantity: 1, UnitPrice: -1}}},
+		"bad currency":   {Currency: "dollars", Items: []*proto.OrderItem{{BookId: "a", Quantity: 1}}},
+		"price mismatch": {Items: []*proto.OrderItem{
+			{BookId: "a", Quantity: 1, UnitPrice: 100},
+			{BookId: "a", Quantity: 1, UnitPrice: 200},
+		}},
+	}
+
+	for name, req := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewCreateOrderDTO(req); !errors.Is(err, domain.ErrInvalidOrder) {
+				t.Errorf("got %v, want %v", err, domain.ErrInvalidOrder)
+			}
+		})
+	}
+}
+
+func TestSnapshotItems(t *testing.T) {
+	dto := CreateOrderDTO{
+		Items: []OrderItem{
+			{BookID: "a", Quantity: 2},
+			{BookID: "b", Quantity: 1, UnitPrice: 250},
+		},
+	}
+	books := []CatalogBook{
+		{ID: "a", Title: "Dune", PriceAmount: 1000, Currency: "USD"},
+		{ID: "b", Title: "Emma", PriceAmount: 250, Currency: "USD"},
+	}
+
+	dto, err := snapshotItems(dto, books)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if dto.Currency != "USD" {
+		t.Errorf("got currency %q, want the currency of the books", dto.Currency)
+	}
+	if dto.Items[0].Title != "Dune" || dto.Items[0].UnitPrice != 1000 {
+		t.Errorf("catalog title and price should be copied: %+v", dto.Items[0])
+	}
+}
+
+func TestSnapshotItemsRejected(t *testing.T) {
+	tests := map[string]struct {
+		dto  CreateOrderDTO
+		book CatalogBook
+		want error
+	}{
+		"no price": {
+			dto:  CreateOrderDTO{Items: []OrderItem{{BookID: "a", Quantity: 1}}},
+			book: CatalogBook{ID: "a"},
+			want: domain.ErrBookNotForSale,
+		},
+		"other currency": {
+			dto:  CreateOrderDTO{Currency: "EUR", Items: []OrderItem{{BookID: "a", Quantity: 1}}},
+			book: CatalogBook{ID: "a", PriceAmount: 100, Currency: "USD"},
+			want: domain.ErrBookNotForSale,
+		},
+		"price changed": {
+			dto:  CreateOrderDTO{Items: []OrderItem{{BookID: "a", Quantity: 1, UnitPrice: 90}}},
+			book: CatalogBook{ID: "a", PriceAmount: 100, Currency: "USD"},
+			want: domain.ErrPriceChanged,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := snapshotItems(tt.dto, []CatalogBook{tt.book}); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeepOrderedItems(t *testing.T) {
+	ordered := []OrderItem{{BookID: "a", Title: "Dune", Quantity: 1, UnitPrice: 1000}}
+
+	kept, added, err := keepOrderedItems(ordered, []OrderItem{
+		{BookID: "a", Quantity: 3},
+		{BookID: "b", Quantity: 1},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(kept) != 1 || kept[0].Quantity != 3 || kept[0].UnitPrice != 1000 || kept[0].Title != "Dune" {
+		t.Errorf("ordered book should keep its price and title: %+v", kept)
+	}
+	if len(added) != 1 || added[0].BookID != "b" {
+		t.Errorf("got added %+v, want book b", added)
+	}
+
+	if _, _, err := keepOrderedItems(ordered, []OrderItem{{BookID: "a", Quantity: 1, UnitPrice: 900}}); !errors.Is(err, domain.ErrPriceChanged) {
+		t.Errorf("got %v, want %v", err, domain.ErrPriceChanged)
+	}
+}
+
+func TestStockDelta(t *testing.T) {
+	old := []OrderItem{
+		{BookID: "a", Quantity: 2},
+		{BookID: "b", Quantity: 1},
+		{BookID: "c", Quantity: 4},
+	}
+	new := []OrderItem{
+		{BookID: "a", Quantity: 5},
+		{BookID: "c", Quantity: 1},
+		{BookID: "d", Quantity: 2},
+	}
+
+	more, less := stockDelta(old, new)
+
+	wantMore := []OrderItem{{BookID: "a", Quantity: 3}, {BookID: "d", Quantity: 2}}
+	wantLess := []OrderItem{{BookID: "c", Quantity: 3}, {BookID: "b", Quantity: 1}}
+
+	if !reflect.DeepEqual(more, wantMore) {
+		t.Errorf("got more %+v, want %+v", more, wantMore)
+	}
+	if !reflect.DeepEqual(less, wantLess) {
+		t.Errorf("got less %+v, want %+v", less, wantLess)
+	}
+}
+
+func TestRequestHash(t *testing.T) {
+	dto := CreateOrderDTO{
+		Currency: "USD",
+		Items:    []OrderItem{{BookID: "a", Quantity: 1}, {BookID: "b", Quantity: 2}},
+	}
+	reordered := CreateOrderDTO{
+		Currency: "USD",
+		Items:    []OrderItem{{BookID: "b", Quantity: 2}, {BookID: "a", Quantity: 1}},
+	}
+	changed := CreateOrderDTO{
+		Currency: "USD",
+		Items:    []OrderItem{{BookID: "a", Quantity: 1}, {BookID: "b", Quantity: 3}},
+	}
+
+	if requestHash(dto) != requestHash(reordered) {
+		t.Errorf("the order of the items should not change the hash")
+	}
+	if requestHash(dto) == requestHash(changed) {
+		t.Errorf("a different quantity should change the hash")
+	}
+}
@@ -2,9 +2,13 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
+	"github.com/Levap123/order_service/internal/domain"
 	"github.com/Levap123/order_service/internal/order"
+	"github.com/jackc/pgx"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -18,25 +22,80 @@ func NewOrderRepoPostgres(db *sqlx.DB) *OrderRepo {
 	}
 }
 
-const orderTable = "orders"
+const (
+	orderTable       = "orders"
+	orderItemTable   = "order_items"
+	statusEventTable = "order_status_events"
+	refundTable      = "refunds"
+	idempotencyTable = "order_idempotency_keys"
 
-func (or *OrderRepo) Create(ctx context.Context, order order.Order) (uint64, error) {
+	orderColumns       = "id, user_id, added_at, status, version, currency, reservation_id"
+	orderItemColumns   = "order_id, book_id, title, quantity, unit_price"
+	statusEventColumns = "id, order_id, COALESCE(from_status, '') AS from_status, to_status, actor_id, reason, created_at"
+)
+
+// Create writes the order, its items and the first event of its history in
+// one transaction, so an order is never seen without them. A non-empty key is
+// saved with the order; if the user already used it, nothing is written and
+// domain.ErrIdempotencyKeyTaken is returned.
+func (or *OrderRepo) Create(ctx context.Context, order order.Order, key order.IdempotencyKey) (uint64, error) {
 	tx, err := or.DB.BeginTxx(ctx, nil)
 	if err != nil {
 		return 0, fmt.Errorf("order repo - create - %w", err)
 	}
 	defer tx.Rollback()
 
-	query := fmt.Sprintf("INSERT INTO %s (user_id, book_id, added_at, status) VALUES ($1, $2, $3, $4) RETURNING id", orderTable)
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, added_at, status, version, currency, reservation_id)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`, orderTable)
 
 	var ID uint64
-	if err := tx.GetContext(ctx, &ID, query, order.UserID, order.BookID, order.AddedAt, order.Status); err != nil {
+	if err := tx.GetContext(ctx, &ID, query, order.UserID, order.AddedAt, order.Status, order.Version, order.Currency, order.ReservationID); err != nil {
+		return 0, fmt.Errorf("order repo - create - %w", err)
+	}
+
+	if err := insertItems(ctx, tx, ID, order.Items); err != nil {
+		return 0, fmt.Errorf("order repo - create - %w", err)
+	}
+
+	created := statusEventFromCreation(ID, order)
+	if err := insertStatusEvent(ctx, tx, created); err != nil {
 		return 0, fmt.Errorf("order repo - create - %w", err)
 	}
 
+	if key.Key != "" {
+		query := fmt.Sprintf(`INSERT INTO %s (user_id, idempotency_key, request_hash, order_id, created_at)
+			VALUES ($1, $2, $3, $4, $5)`, idempotencyTable)
+
+		if _, err := tx.ExecContext(ctx, query, order.UserID, key.Key, key.RequestHash, ID, order.AddedAt); err != nil {
+			var pgErr pgx.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+				return 0, domain.ErrIdempotencyKeyTaken
+			}
+			return 0, fmt.Errorf("order repo - create - %w", err)
+		}
+	}
+
 	return ID, tx.Commit()
 }
 
+const uniqueViolation = "23505"
+
+// GetIdempotencyKey returns the key the user sent with a create request. The
+// OrderID of the key is zero if the user never sent it.
+func (or *OrderRepo) GetIdempotencyKey(ctx context.Context, userID uint64, key string) (order.IdempotencyKey, error) {
+	query := fmt.Sprintf("SELECT idempotency_key, request_hash, order_id FROM %s WHERE user_id = $1 AND idempotency_key = $2", idempotencyTable)
+
+	var entity order.IdempotencyKey
+	if err := or.DB.GetContext(ctx, &entity, query, userID, key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return order.IdempotencyKey{}, nil
+		}
+		return order.IdempotencyKey{}, fmt.Errorf("order repo - get idempotency key - %w", err)
+	}
+
+	return entity, nil
+}
+
 func (or *OrderRepo) GetByID(ctx context.Context, ID uint64) (order.Order, error) {
 	tx, err := or.DB.BeginTxx(ctx, nil)
 	if err != nil {
@@ -44,14 +103,22 @@ func (or *OrderRepo) GetByID(ctx context.Context, ID uint64) (order.Order, error
 	}
 	defer tx.Rollback()
 
-	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", orderTable)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1", orderColumns, orderTable)
 
 	var entity order.Order
 	if err := tx.GetContext(ctx, &entity, query, ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return order.Order{}, domain.ErrOrderNotFound
+		}
 		return order.Order{}, fmt.Errorf("order repo - get by id - %w", err)
 	}
 
-	return entity, tx.Commit()
+	orders := []order.Order{entity}
+	if err := selectItems(ctx, tx, orders); err != nil {
+		return order.Order{}, fmt.Errorf("order repo - get by id - %w", err)
+	}
+
+	return orders[0], tx.Commit()
 }
 
 func (or *OrderRepo) GetByUserID(ctx context.Context, userID uint64) ([]order.Order, error) {
@@ -61,45 +128,243 @@ func (or *OrderRepo) GetByUserID(ctx context.Context, userID uint64) ([]order.Or
 	}
 	defer tx.Rollback()
 
-	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id = $1", orderTable)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE user_id = $1 ORDER BY id", orderColumns, orderTable)
 
 	var orders []order.Order
 	if err := tx.SelectContext(ctx, &orders, query, userID); err != nil {
 		return nil, fmt.Errorf("order repo - get by user id - %w", err)
 	}
 
+	if err := selectItems(ctx, tx, orders); err != nil {
+		return nil, fmt.Errorf("order repo - get by user id - %w", err)
+	}
+
 	return orders, tx.Commit()
 }
 
-func (or *OrderRepo) GetByUserIDAndStatus(ctx context.Context, userID uint64, status string) ([]order.Order, error) {
+func (or *OrderRepo) GetByUserIDAndStatus(ctx context.Context, userID uint64, status order.Status) ([]order.Order, error) {
 	tx, err := or.DB.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("order repo - get by user id - %w", err)
 	}
 	defer tx.Rollback()
 
-	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id = $1 and status = $2", orderTable)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE user_id = $1 and status = $2 ORDER BY id", orderColumns, orderTable)
 
 	var orders []order.Order
 	if err := tx.SelectContext(ctx, &orders, query, userID, status); err != nil {
 		return nil, fmt.Errorf("order repo - get by user id - %w", err)
 	}
 
+	if err := selectItems(ctx, tx, orders); err != nil {
+		return nil, fmt.Errorf("order repo - get by user id - %w", err)
+	}
+
 	return orders, tx.Commit()
 }
 
-func (or *OrderRepo) ChangeOrderStatus(ctx context.Context, ID uint64, status string) (uint64, error) {
+// ChangeOrderStatus applies the transition of the event only if the order is
+// still at the given version, bumps the version and records the event.
+func (or *OrderRepo) ChangeOrderStatus(ctx context.Context, event order.StatusEvent, version uint64) (order.Order, error) {
 	tx, err := or.DB.BeginTxx(ctx, nil)
 	if err != nil {
-		return 0, fmt.Errorf("order repo - change order status - %w", err)
+		return order.Order{}, fmt.Errorf("order repo - change order status - %w", err)
 	}
 	defer tx.Rollback()
 
-	query := fmt.Sprintf("UPDATE %s SET status = $1 WHERE id = $2", orderTable)
+	entity, err := updateStatus(ctx, tx, event, version)
+	if err != nil {
+		return order.Order{}, fmt.Errorf("order repo - change order status - %w", err)
+	}
 
-	if _, err := tx.ExecContext(ctx, query, status, ID); err != nil {
-		return 0, fmt.Errorf("order repo - change order status - %w", err)
+	return entity, tx.Commit()
+}
+
+// CancelOrder moves the order to cancelled like ChangeOrderStatus. When
+// refund is not nil it is saved in the same transaction and gets its ID.
+func (or *OrderRepo) CancelOrder(ctx context.Context, event order.StatusEvent, version uint64, refund *order.Refund) (order.Order, error) {
+	tx, err := or.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return order.Order{}, fmt.Errorf("order repo - cancel order - %w", err)
 	}
+	defer tx.Rollback()
 
-	return ID, tx.Commit()
+	entity, err := updateStatus(ctx, tx, event, version)
+	if err != nil {
+		return order.Order{}, fmt.Errorf("order repo - cancel order - %w", err)
+	}
+
+	if refund != nil {
+		query := fmt.Sprintf(`INSERT INTO %s (order_id, amount, currency, status, reason, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`, refundTable)
+
+		if err := tx.GetContext(ctx, &refund.ID, query,
+			refund.OrderID, refund.Amount, refund.Currency, refund.Status, refund.Reason, refund.CreatedAt); err != nil {
+			return order.Order{}, fmt.Errorf("order repo - cancel order - insert refund - %w", err)
+		}
+	}
+
+	return entity, tx.Commit()
+}
+
+// UpdateItems replaces the items of the order, its currency and its
+// reservation if the order is still at the given version, and bumps the
+// version.
+func (or *OrderRepo) UpdateItems(ctx context.Context, o order.Order, version uint64) (order.Order, error) {
+	tx, err := or.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return order.Order{}, fmt.Errorf("order repo - update items - %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`UPDATE %s SET currency = $1, reservation_id = $2, version = version + 1
+		WHERE id = $3 AND version = $4 RETURNING %s`, orderTable, orderColumns)
+
+	var entity order.Order
+	if err := tx.GetContext(ctx, &entity, query, o.Currency, o.ReservationID, o.ID, version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return order.Order{}, orderMissingOrChanged(ctx, tx, o.ID)
+		}
+		return order.Order{}, fmt.Errorf("order repo - update items - %w", err)
+	}
+
+	query = fmt.Sprintf("DELETE FROM %s WHERE order_id = $1", orderItemTable)
+	if _, err := tx.ExecContext(ctx, query, o.ID); err != nil {
+		return order.Order{}, fmt.Errorf("order repo - update items - %w", err)
+	}
+
+	if err := insertItems(ctx, tx, o.ID, o.Items); err != nil {
+		return order.Order{}, fmt.Errorf("order repo - update items - %w", err)
+	}
+
+	orders := []order.Order{entity}
+	if err := selectItems(ctx, tx, orders); err != nil {
+		return order.Order{}, fmt.Errorf("order repo - update items - %w", err)
+	}
+
+	return orders[0], tx.Commit()
+}
+
+// updateStatus applies the transition of the event only if the order is still
+// at the given version, bumps the version and records the event.
+func updateStatus(ctx context.Context, tx *sqlx.Tx, event order.StatusEvent, version uint64) (order.Order, error) {
+	query := fmt.Sprintf("UPDATE %s SET status = $1, version = version + 1 WHERE id = $2 AND version = $3 RETURNING %s", orderTable, orderColumns)
+
+	var entity order.Order
+	if err := tx.GetContext(ctx, &entity, query, event.To, event.OrderID, version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return order.Order{}, orderMissingOrChanged(ctx, tx, event.OrderID)
+		}
+		return order.Order{}, err
+	}
+
+	if err := insertStatusEvent(ctx, tx, event); err != nil {
+		return order.Order{}, err
+	}
+
+	orders := []order.Order{entity}
+	if err := selectItems(ctx, tx, orders); err != nil {
+		return order.Order{}, err
+	}
+
+	return orders[0], nil
+}
+
+// orderMissingOrChanged tells why a versioned update matched no order.
+func orderMissingOrChanged(ctx context.Context, tx *sqlx.Tx, ID uint64) error {
+	var exists bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1)", orderTable)
+	if err := tx.GetContext(ctx, &exists, query, ID); err != nil {
+		return fmt.Errorf("order repo - %w", err)
+	}
+
+	if !exists {
+		return domain.ErrOrderNotFound
+	}
+	return domain.ErrVersionConflict
+}
+
+func (or *OrderRepo) GetHistory(ctx context.Context, orderID uint64) ([]order.StatusEvent, error) {
+	tx, err := or.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("order repo - get history - %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE order_id = $1 ORDER BY id", statusEventColumns, statusEventTable)
+
+	var events []order.StatusEvent
+	if err := tx.SelectContext(ctx, &events, query, orderID); err != nil {
+		return nil, fmt.Errorf("order repo - get history - %w", err)
+	}
+
+	if len(events) == 0 {
+		return nil, domain.ErrOrderNotFound
+	}
+
+	return events, tx.Commit()
+}
+
+func statusEventFromCreation(orderID uint64, o order.Order) order.StatusEvent {
+	return order.StatusEvent{
+		OrderID:   orderID,
+		To:        o.Status,
+		ActorID:   o.UserID,
+		CreatedAt: o.AddedAt,
+	}
+}
+
+func insertStatusEvent(ctx context.Context, tx *sqlx.Tx, event order.StatusEvent) error {
+	query := fmt.Sprintf(`INSERT INTO %s (order_id, from_status, to_status, actor_id, reason, created_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6)`, statusEventTable)
+
+	if _, err := tx.ExecContext(ctx, query, event.OrderID, event.From, event.To, event.ActorID, event.Reason, event.CreatedAt); err != nil {
+		return fmt.Errorf("insert status event - %w", err)
+	}
+
+	return nil
+}
+
+func insertItems(ctx context.Context, tx *sqlx.Tx, orderID uint64, items []order.OrderItem) error {
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (:order_id, :book_id, :title, :quantity, :unit_price)", orderItemTable, orderItemColumns)
+
+	for _, item := range items {
+		item.OrderID = orderID
+		if _, err := tx.NamedExecContext(ctx, query, item); err != nil {
+			return fmt.Errorf("insert items - %w", err)
+		}
+	}
+
+	return nil
+}
+
+// selectItems loads the items of all the orders with a single query.
+func selectItems(ctx context.Context, tx *sqlx.Tx, orders []order.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	positions := make(map[uint64]int, len(orders))
+	orderIDs := make([]uint64, 0, len(orders))
+	for i, o := range orders {
+		positions[o.ID] = i
+		orderIDs = append(orderIDs, o.ID)
+	}
+
+	query, args, err := sqlx.In(fmt.Sprintf("SELECT %s FROM %s WHERE order_id IN (?) ORDER BY order_id, book_id", orderItemColumns, orderItemTable), orderIDs)
+	if err != nil {
+		return fmt.Errorf("select items - %w", err)
+	}
+
+	var items []order.OrderItem
+	if err := tx.SelectContext(ctx, &items, tx.Rebind(query), args...); err != nil {
+		return fmt.Errorf("select items - %w", err)
+	}
+
+	for _, item := range items {
+		i := positions[item.OrderID]
+		orders[i].Items = append(orders[i].Items, item)
+	}
+
+	return nil
 }
@@ -1,28 +1,136 @@
 package order
 
-import "context"
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Levap123/order_service/internal/domain"
+)
 
 type OrderService struct {
-	repo IOrderRepo
+	repo    IOrderRepo
+	catalog IBookCatalog
 }
 
-func NewService(repo IOrderRepo) *OrderService {
+func NewService(repo IOrderRepo, catalog IBookCatalog) *OrderService {
 	return &OrderService{
-		repo: repo,
+		repo:    repo,
+		catalog: catalog,
 	}
 }
 
 type IOrderRepo interface {
-	Create(ctx context.Context, order Order) (uint64, error)
+	Create(ctx context.Context, order Order, key IdempotencyKey) (uint64, error)
+	GetIdempotencyKey(ctx context.Context, userID uint64, key string) (IdempotencyKey, error)
 	GetByID(ctx context.Context, ID uint64) (Order, error)
 	GetByUserID(ctx context.Context, userID uint64) ([]Order, error)
-	GetByUserIDAndStatus(ctx context.Context, userID uint64, status string) ([]Order, error)
-	ChangeOrderStatus(ctx context.Context, ID uint64, status string) (uint64, error)
+	GetByUserIDAndStatus(ctx context.Context, userID uint64, status Status) ([]Order, error)
+	ChangeOrderStatus(ctx context.Context, event StatusEvent, version uint64) (Order, error)
+	GetHistory(ctx context.Context, orderID uint64) ([]StatusEvent, error)
+	CancelOrder(ctx context.Context, event StatusEvent, version uint64, refund *Refund) (Order, error)
+	UpdateItems(ctx context.Context, order Order, version uint64) (Order, error)
+}
+
+// IBookCatalog is the book service as seen by orders.
+type IBookCatalog interface {
+	GetBook(ctx context.Context, bookID string) (CatalogBook, error)
+	Reserve(ctx context.Context, reservationID string, items []OrderItem) error
+	Extend(ctx context.Context, reservationID string, items []OrderItem) error
+	Release(ctx context.Context, reservationID string, items []OrderItem) error
+	Commit(ctx context.Context, reservationID string) error
 }
 
+// Create checks that every book exists and can be sold in the order currency,
+// copies its title and price into the order and reserves the stock. The
+// reservation is released again if the order can't be saved.
+//
+// A request with an idempotency key the user already used makes no new order
+// and returns the one made for the key.
 func (os *OrderService) Create(ctx context.Context, dto CreateOrderDTO) (uint64, error) {
-	order := createOrderDTOToOrder(dto)
-	return os.repo.Create(ctx, order)
+	var key IdempotencyKey
+	if dto.IdempotencyKey != "" {
+		key = IdempotencyKey{Key: dto.IdempotencyKey, RequestHash: requestHash(dto)}
+
+		ID, err := os.replay(ctx, dto.UserID, key)
+		if err != nil || ID != 0 {
+			return ID, err
+		}
+	}
+
+	books := make([]CatalogBook, 0, len(dto.Items))
+	for _, item := range dto.Items {
+		book, err := os.catalog.GetBook(ctx, item.BookID)
+		if err != nil {
+			return 0, fmt.Errorf("order service - create - %w", err)
+		}
+		books = append(books, book)
+	}
+
+	dto, err := snapshotItems(dto, books)
+	if err != nil {
+		return 0, fmt.Errorf("order service - create - %w", err)
+	}
+
+	reservationID, err := newReservationID()
+	if err != nil {
+		return 0, fmt.Errorf("order service - create - %w", err)
+	}
+
+	if err := os.catalog.Reserve(ctx, reservationID, dto.Items); err != nil {
+		return 0, fmt.Errorf("order service - create - %w", err)
+	}
+
+	ID, err := os.repo.Create(ctx, createOrderDTOToOrder(dto, reservationID), key)
+	if err != nil {
+		if releaseErr := os.catalog.Release(ctx, reservationID, nil); releaseErr != nil {
+			return 0, fmt.Errorf("order service - create - %w", errors.Join(err, releaseErr))
+		}
+
+		// A retry running at the same time took the key first.
+		if errors.Is(err, domain.ErrIdempotencyKeyTaken) {
+			return os.replay(ctx, dto.UserID, key)
+		}
+		return 0, fmt.Errorf("order service - create - %w", err)
+	}
+
+	return ID, nil
+}
+
+// replay returns the order made for the idempotency key, or 0 if the key is
+// not used yet.
+func (os *OrderService) replay(ctx context.Context, userID uint64, key IdempotencyKey) (uint64, error) {
+	stored, err := os.repo.GetIdempotencyKey(ctx, userID, key.Key)
+	if err != nil {
+		return 0, fmt.Errorf("order service - create - %w", err)
+	}
+
+	if stored.OrderID != 0 && stored.RequestHash != key.RequestHash {
+		return 0, fmt.Errorf("order service - create - key %q - %w", key.Key, domain.ErrIdempotencyConflict)
+	}
+
+	return stored.OrderID, nil
+}
+
+// OrderForKey returns the order the user made with the idempotency key, or 0
+// if the key is not used yet.
+func (os *OrderService) OrderForKey(ctx context.Context, userID uint64, key string) (uint64, error) {
+	stored, err := os.repo.GetIdempotencyKey(ctx, userID, key)
+	if err != nil {
+		return 0, fmt.Errorf("order service - order for key - %w", err)
+	}
+	return stored.OrderID, nil
+}
+
+func newReservationID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("new reservation id - %w", err)
+	}
+	return "order-" + hex.EncodeToString(b), nil
 }
 
 func (os *OrderService) GetByID(ctx context.Context, ID uint64) (Order, error) {
@@ -33,14 +141,266 @@ func (os *OrderService) GetByUserID(ctx context.Context, userID uint64) ([]Order
 	return os.repo.GetByUserID(ctx, userID)
 }
 
-func (os *OrderService) GetByUserIDAndStatus(ctx context.Context, userID uint64, status string) ([]Order, error) {
+func (os *OrderService) GetByUserIDAndStatus(ctx context.Context, userID uint64, status Status) ([]Order, error) {
 	return os.repo.GetByUserIDAndStatus(ctx, userID, status)
 }
 
-func (os *OrderService) ChangeOrderStatus(ctx context.Context, ID uint64, status string) (uint64, error) {
-	if status == "завершен" {
-		// TODO: send msg
+// ChangeOrderStatus moves the order to the status if the transition is
+// allowed and records the transition in the order history. The update only
+// applies to the version of the order that was checked, so of two concurrent
+// changes only one can win.
+//
+// Shipping commits the reservation of the order: the books leave the stock
+// for good. The commit goes first and can be repeated, so a failed status
+// update is retried without losing the sale.
+func (os *OrderService) ChangeOrderStatus(ctx context.Context, change StatusChange) (Order, error) {
+	order, err := os.repo.GetByID(ctx, change.OrderID)
+	if err != nil {
+		return Order{}, err
+	}
+
+	if change.ExpectedVersion != 0 && change.ExpectedVersion != order.Version {
+		return Order{}, fmt.Errorf("order service - change order status - order is at version %d - %w", order.Version, domain.ErrVersionConflict)
+	}
+
+	if !order.Status.CanMoveTo(change.Status) {
+		return Order{}, fmt.Errorf("order service - change order status - %s to %s - %w", order.Status, change.Status, domain.ErrIllegalTransition)
+	}
+
+	// Only the money of an order that was paid can be returned.
+	if order.Status == StatusCancelled && change.Status == StatusRefunded {
+		paid, err := os.wasPaid(ctx, order.ID)
+		if err != nil {
+			return Order{}, fmt.Errorf("order service - change order status - %w", err)
+		}
+		if !paid {
+			return Order{}, fmt.Errorf("order service - change order status - order was never paid - %w", domain.ErrIllegalTransition)
+		}
+	}
+
+	// Orders made before stock was reserved hold no reservation.
+	if change.Status == StatusShipped && order.ReservationID != "" {
+		if err := os.catalog.Commit(ctx, order.ReservationID); err != nil {
+			return Order{}, fmt.Errorf("order service - change order status - %w", err)
+		}
+	}
+
+	event := StatusEvent{
+		OrderID:   order.ID,
+		From:      order.Status,
+		To:        change.Status,
+		ActorID:   change.ActorID,
+		Reason:    change.Reason,
+		CreatedAt: time.Now(),
+	}
+
+	return os.repo.ChangeOrderStatus(ctx, event, order.Version)
+}
+
+// wasPaid tells whether the order history shows the order paid.
+func (os *OrderService) wasPaid(ctx context.Context, orderID uint64) (bool, error) {
+	events, err := os.repo.GetHistory(ctx, orderID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, event := range events {
+		if event.To == StatusPaid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (os *OrderService) GetOrderHistory(ctx context.Context, orderID uint64) ([]StatusEvent, error) {
+	return os.repo.GetHistory(ctx, orderID)
+}
+
+// UpdateOrderItems replaces the items of an order that is not paid yet. Only
+// the difference to the old items is reserved or released: the extra books
+// are reserved before the order is saved and the spare ones released after,
+// so the order never holds fewer books than it lists.
+func (os *OrderService) UpdateOrderItems(ctx context.Context, update ItemsUpdate) (Order, error) {
+	order, err := os.repo.GetByID(ctx, update.OrderID)
+	if err != nil {
+		return Order{}, err
+	}
+
+	if order.UserID != update.UserID {
+		return Order{}, domain.ErrOrderNotFound
+	}
+
+	if update.ExpectedVersion != order.Version {
+		return Order{}, fmt.Errorf("order service - update order items - order is at version %d - %w", order.Version, domain.ErrVersionConflict)
+	}
+
+	if order.Status != StatusCreated {
+		return Order{}, fmt.Errorf("order service - update order items - order is %s - %w", order.Status, domain.ErrOrderNotEditable)
+	}
+
+	kept, added, err := keepOrderedItems(order.Items, update.Items)
+	if err != nil {
+		return Order{}, fmt.Errorf("order service - update order items - %w", err)
+	}
+
+	if len(added) != 0 {
+		books := make([]CatalogBook, 0, len(added))
+		for _, item := range added {
+			book, err := os.catalog.GetBook(ctx, item.BookID)
+			if err != nil {
+				return Order{}, fmt.Errorf("order service - update order items - %w", err)
+			}
+			books = append(books, book)
+		}
+
+		dto, err := snapshotItems(CreateOrderDTO{Currency: order.Currency, Items: added}, books)
+		if err != nil {
+			return Order{}, fmt.Errorf("order service - update order items - %w", err)
+		}
+		order.Currency = dto.Currency
+		added = dto.Items
+	}
+
+	// Orders made before stock was reserved hold none of their books.
+	reserved := order.Items
+	if order.ReservationID == "" {
+		if order.ReservationID, err = newReservationID(); err != nil {
+			return Order{}, fmt.Errorf("order service - update order items - %w", err)
+		}
+		reserved = nil
+	}
+
+	order.Items = append(kept, added...)
+	more, less := stockDelta(reserved, order.Items)
+
+	if len(more) != 0 {
+		if err := os.catalog.Extend(ctx, order.ReservationID, more); err != nil {
+			return Order{}, fmt.Errorf("order service - update order items - %w", err)
+		}
+	}
+
+	updated, err := os.repo.UpdateItems(ctx, order, order.Version)
+	if err != nil {
+		if len(more) != 0 {
+			if releaseErr := os.catalog.Release(ctx, order.ReservationID, more); releaseErr != nil {
+				err = errors.Join(err, releaseErr)
+			}
+		}
+		return Order{}, fmt.Errorf("order service - update order items - %w", err)
+	}
+
+	if len(less) != 0 {
+		if err := os.catalog.Release(ctx, order.ReservationID, less); err != nil {
+			return Order{}, fmt.Errorf("order service - update order items - items are updated but spare stock is not released - %w", err)
+		}
+	}
+
+	return updated, nil
+}
+
+// CancelOrder cancels the order and makes up for it: the books of an order
+// that still holds them go back to stock, and a paid order gets a pending
+// refund. The refund is saved with the cancellation, while the stock is
+// released after it, so a failed release leaves books reserved rather than an
+// order without them. Cancelling the order again retries the release.
+func (os *OrderService) CancelOrder(ctx context.Context, c Cancellation) (CancelResult, error) {
+	order, err := os.repo.GetByID(ctx, c.OrderID)
+	if err != nil {
+		return CancelResult{}, err
+	}
+
+	if order.UserID != c.UserID && !c.Admin {
+		return CancelResult{}, domain.ErrOrderNotFound
+	}
+
+	if order.Status == StatusCancelled {
+		return os.retryCancel(ctx, order)
+	}
+
+	if c.ExpectedVersion != 0 && c.ExpectedVersion != order.Version {
+		return CancelResult{}, fmt.Errorf("order service - cancel order - order is at version %d - %w", order.Version, domain.ErrVersionConflict)
+	}
+
+	if !canCancel(order.Status, c.Admin) {
+		return CancelResult{}, fmt.Errorf("order service - cancel order - order is %s - %w", order.Status, domain.ErrCancelNotAllowed)
+	}
+
+	now := time.Now()
+	event := StatusEvent{
+		OrderID:   order.ID,
+		From:      order.Status,
+		To:        StatusCancelled,
+		ActorID:   c.UserID,
+		Reason:    c.eventReason(),
+		CreatedAt: now,
+	}
+
+	var refund *Refund
+	if order.Status.isPaid() {
+		refund = &Refund{
+			OrderID:   order.ID,
+			Amount:    order.Total(),
+			Currency:  order.Currency,
+			Status:    RefundPending,
+			Reason:    c.Reason,
+			CreatedAt: now,
+		}
+	}
+
+	cancelled, err := os.repo.CancelOrder(ctx, event, order.Version, refund)
+	if err != nil {
+		return CancelResult{}, err
+	}
+
+	result := CancelResult{
+		Order:  cancelled,
+		Refund: refund,
+	}
+
+	if order.Status.holdsStock() {
+		if result.StockReleased, err = os.releaseStock(ctx, order); err != nil {
+			return CancelResult{}, fmt.Errorf("order service - cancel order - order is cancelled but its stock is not released - %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// retryCancel releases the stock of an order that was cancelled while it
+// still held it.
+func (os *OrderService) retryCancel(ctx context.Context, order Order) (CancelResult, error) {
+	events, err := os.repo.GetHistory(ctx, order.ID)
+	if err != nil {
+		return CancelResult{}, fmt.Errorf("order service - cancel order - %w", err)
+	}
+
+	result := CancelResult{Order: order}
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].To != StatusCancelled {
+			continue
+		}
+
+		if events[i].From.holdsStock() {
+			if result.StockReleased, err = os.releaseStock(ctx, order); err != nil {
+				return CancelResult{}, fmt.Errorf("order service - cancel order - %w", err)
+			}
+		}
+		break
+	}
+
+	return result, nil
+}
+
+// releaseStock returns the books of the order to stock and tells whether
+// there were any. Orders made before stock was reserved have none.
+func (os *OrderService) releaseStock(ctx context.Context, order Order) (bool, error) {
+	if order.ReservationID == "" {
+		return false, nil
+	}
+
+	if err := os.catalog.Release(ctx, order.ReservationID, nil); err != nil {
+		return false, err
 	}
 
-	return os.repo.ChangeOrderStatus(ctx, ID, status)
+	return true, nil
 }
@@ -0,0 +1,166 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Levap123/order_service/internal/domain"
+)
+
+// memoryRepo keeps one order. Methods the tests don't use are left to the
+// embedded interface.
+type memoryRepo struct {
+	IOrderRepo
+	order   Order
+	history []StatusEvent
+}
+
+func (m *memoryRepo) GetByID(ctx context.Context, ID uint64) (Order, error) {
+	return m.order, nil
+}
+
+func (m *memoryRepo) ChangeOrderStatus(ctx context.Context, event StatusEvent, version uint64) (Order, error) {
+	m.order.Status = event.To
+	m.order.Version++
+	m.history = append(m.history, event)
+	return m.order, nil
+}
+
+func (m *memoryRepo) CancelOrder(ctx context.Context, event StatusEvent, version uint64, refund *Refund) (Order, error) {
+	return m.ChangeOrderStatus(ctx, event, version)
+}
+
+func (m *memoryRepo) GetHistory(ctx context.Context, orderID uint64) ([]StatusEvent, error) {
+	return m.history, nil
+}
+
+// fakeCatalog records the reservations released and committed.
+type fakeCatalog struct {
+	IBookCatalog
+	released  []string
+	committed []string
+}
+
+func (f *fakeCatalog) Release(ctx context.Context, reservationID string, items []OrderItem) error {
+	f.released = append(f.released, reservationID)
+	return nil
+}
+
+func (f *fakeCatalog) Commit(ctx context.Context, reservationID string) error {
+	f.committed = append(f.committed, reservationID)
+	return nil
+}
+
+func TestOrderService_ChangeOrderStatusCommitsStock(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryRepo{order: Order{ID: 1, Status: StatusCreated, Version: 1, ReservationID: "order-1"}}
+	catalog := &fakeCatalog{}
+	service := NewService(repo, catalog)
+
+	if _, err := service.ChangeOrderStatus(ctx, StatusChange{OrderID: 1, Status: StatusPaid}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(catalog.committed) != 0 {
+		t.Errorf("paying committed %v, want nothing", catalog.committed)
+	}
+
+	if _, err := service.ChangeOrderStatus(ctx, StatusChange{OrderID: 1, Status: StatusShipped}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(catalog.committed) != 1 || catalog.committed[0] != "order-1" {
+		t.Errorf("shipping committed %v, want [order-1]", catalog.committed)
+	}
+
+	if _, err := service.ChangeOrderStatus(ctx, StatusChange{OrderID: 1, Status: StatusDelivered}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(catalog.committed) != 1 {
+		t.Errorf("delivering committed again: %v", catalog.committed)
+	}
+}
+
+func TestOrderService_ShipOrderWithoutReservation(t *testing.T) {
+	repo := &memoryRepo{order: Order{ID: 1, Status: StatusPaid, Version: 1}}
+	catalog := &fakeCatalog{}
+
+	if _, err := NewService(repo, catalog).ChangeOrderStatus(context.Background(), StatusChange{OrderID: 1, Status: StatusShipped}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(catalog.committed) != 0 {
+		t.Errorf("committed %v for an order without a reservation", catalog.committed)
+	}
+}
+
+func TestOrderService_CancelOrderReleasesHeldStock(t *testing.T) {
+	tests := []struct {
+		status   Status
+		released bool
+		refund   bool
+	}{
+		{StatusCreated, true, false},
+		{StatusPaid, true, true},
+		{StatusShipped, false, true},
+		{StatusDelivered, false, true},
+	}
+
+	for _, tt := range tests {
+		repo := &memoryRepo{order: Order{ID: 1, Status: tt.status, Version: 1, ReservationID: "order-1"}}
+		catalog := &fakeCatalog{}
+
+		result, err := NewService(repo, catalog).CancelOrder(context.Background(), Cancellation{OrderID: 1, Admin: true, Reason: CancelReasonOther})
+		if err != nil {
+			t.Fatalf("cancelling %s: unexpected error: %v", tt.status, err)
+		}
+
+		if result.StockReleased != tt.released || (len(catalog.released) == 1) != tt.released {
+			t.Errorf("cancelling %s: released %v, want %v", tt.status, catalog.released, tt.released)
+		}
+		if (result.Refund != nil) != tt.refund {
+			t.Errorf("cancelling %s: refund %+v, want %v", tt.status, result.Refund, tt.refund)
+		}
+	}
+}
+
+func TestOrderService_ChangeOrderStatusDoesNotCancel(t *testing.T) {
+	for _, status := range []Status{StatusCreated, StatusPaid} {
+		repo := &memoryRepo{order: Order{ID: 1, Status: status, Version: 1, ReservationID: "order-1"}}
+
+		_, err := NewService(repo, &fakeCatalog{}).ChangeOrderStatus(context.Background(), StatusChange{OrderID: 1, Status: StatusCancelled})
+		if !errors.Is(err, domain.ErrIllegalTransition) {
+			t.Errorf("%s to cancelled: got %v, want %v", status, err, domain.ErrIllegalTransition)
+		}
+	}
+}
+
+func TestOrderService_RefundOnlyPaidCancelledOrders(t *testing.T) {
+	ctx := context.Background()
+
+	unpaid := &memoryRepo{order: Order{ID: 1, Status: StatusCreated, Version: 1}}
+	service := NewService(unpaid, &fakeCatalog{})
+	if _, err := service.CancelOrder(ctx, Cancellation{OrderID: 1, Admin: true, Reason: CancelReasonOther}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.ChangeOrderStatus(ctx, StatusChange{OrderID: 1, Status: StatusRefunded}); !errors.Is(err, domain.ErrIllegalTransition) {
+		t.Errorf("refunding an unpaid order: got %v, want %v", err, domain.ErrIllegalTransition)
+	}
+
+	paid := &memoryRepo{order: Order{ID: 1, Status: StatusCreated, Version: 1, ReservationID: "order-1"}}
+	catalog := &fakeCatalog{}
+	service = NewService(paid, catalog)
+	if _, err := service.ChangeOrderStatus(ctx, StatusChange{OrderID: 1, Status: StatusPaid}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.ChangeOrderStatus(ctx, StatusChange{OrderID: 1, Status: StatusRefunded}); !errors.Is(err, domain.ErrIllegalTransition) {
+		t.Errorf("refunding a paid order without cancelling it: got %v, want %v", err, domain.ErrIllegalTransition)
+	}
+	if _, err := service.CancelOrder(ctx, Cancellation{OrderID: 1, Admin: true, Reason: CancelReasonOther}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.ChangeOrderStatus(ctx, StatusChange{OrderID: 1, Status: StatusRefunded}); err != nil {
+		t.Errorf("refunding a paid order: unexpected error: %v", err)
+	}
+	if len(catalog.released) != 1 || catalog.released[0] != "order-1" {
+		t.Errorf("refunding a paid order released %v, want [order-1]", catalog.released)
+	}
+}
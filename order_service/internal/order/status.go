@@ -0,0 +1,70 @@
+package order
+
+import (
+	"fmt"
+
+	"github.com/Levap123/order_service/internal/domain"
+	"github.com/Levap123/order_service/proto"
+)
+
+// Status is the state of an order as stored in the database.
+type Status string
+
+const (
+	StatusCreated   Status = "created"
+	StatusPaid      Status = "paid"
+	StatusShipped   Status = "shipped"
+	StatusDelivered Status = "delivered"
+	StatusCancelled Status = "cancelled"
+	StatusRefunded  Status = "refunded"
+)
+
+// transitions lists the statuses an order can move to from each status.
+// Cancelling is not among them: it goes through CancelOrder, which releases
+// the stock and opens the refund. A cancelled order moves on to refunded once
+// the money of a paid order is returned. A paid order still holds its stock,
+// so it is refunded by cancelling it first rather than directly.
+var transitions = map[Status][]Status{
+	StatusCreated:   {StatusPaid},
+	StatusPaid:      {StatusShipped},
+	StatusShipped:   {StatusDelivered},
+	StatusDelivered: {StatusRefunded},
+	StatusCancelled: {StatusRefunded},
+	StatusRefunded:  {},
+}
+
+// CanMoveTo tells whether an order in status s may move to the next status.
+func (s Status) CanMoveTo(next Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+var statusesFromProto = map[proto.OrderStatus]Status{
+	proto.OrderStatus_ORDER_STATUS_CREATED:   StatusCreated,
+	proto.OrderStatus_ORDER_STATUS_PAID:      StatusPaid,
+	proto.OrderStatus_ORDER_STATUS_SHIPPED:   StatusShipped,
+	proto.OrderStatus_ORDER_STATUS_DELIVERED: StatusDelivered,
+	proto.OrderStatus_ORDER_STATUS_CANCELLED: StatusCancelled,
+	proto.OrderStatus_ORDER_STATUS_REFUNDED:  StatusRefunded,
+}
+
+func statusFromProto(status proto.OrderStatus) (Status, error) {
+	s, ok := statusesFromProto[status]
+	if !ok {
+		return "", fmt.Errorf("status %s - %w", status, domain.ErrInvalidStatus)
+	}
+	return s, nil
+}
+
+func statusToProto(status Status) proto.OrderStatus {
+	for p, s := range statusesFromProto {
+		if s == status {
+			return p
+		}
+	}
+	return proto.OrderStatus_ORDER_STATUS_UNSPECIFIED
+}
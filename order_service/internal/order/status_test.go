@@ -0,0 +1,45 @@
+package order
+
+import (
+	"testing"
+
+	"github.com/Levap123/order_service/proto"
+)
+
+func TestStatusCanMoveTo(t *testing.T) {
+	tests := []struct {
+		from, to Status
+		allowed  bool
+	}{
+		{StatusCreated, StatusPaid, true},
+		{StatusCreated, StatusCancelled, false},
+		{StatusPaid, StatusCancelled, false},
+		{StatusPaid, StatusRefunded, false},
+		{StatusCancelled, StatusRefunded, true},
+		{StatusCreated, StatusShipped, false},
+		{StatusPaid, StatusShipped, true},
+		{StatusShipped, StatusCancelled, false},
+		{StatusDelivered, StatusRefunded, true},
+		{StatusCancelled, StatusPaid, false},
+		{StatusRefunded, StatusCreated, false},
+		{StatusPaid, StatusPaid, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.from.CanMoveTo(tt.to); got != tt.allowed {
+			t.Errorf("%s -> %s: got %v, want %v", tt.from, tt.to, got, tt.allowed)
+		}
+	}
+}
+
+func TestStatusProto(t *testing.T) {
+	for p, s := range statusesFromProto {
+		if got := statusToProto(s); got != p {
+			t.Errorf("got %s for %s, want %s", got, s, p)
+		}
+	}
+
+	if _, err := statusFromProto(proto.OrderStatus_ORDER_STATUS_UNSPECIFIED); err == nil {
+		t.Errorf("unspecified status should be rejected")
+	}
+}
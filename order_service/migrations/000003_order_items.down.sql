@@ -0,0 +1,13 @@
+ALTER TABLE orders ADD COLUMN book_id VARCHAR(255);
+
+UPDATE orders SET book_id = (
+    SELECT MIN(book_id) FROM order_items WHERE order_items.order_id = orders.id
+);
+
+UPDATE orders SET book_id = '' WHERE book_id IS NULL;
+
+ALTER TABLE orders ALTER COLUMN book_id SET NOT NULL;
+
+ALTER TABLE orders DROP COLUMN currency;
+
+DROP TABLE order_items;
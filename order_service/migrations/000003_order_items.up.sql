@@ -0,0 +1,15 @@
+CREATE TABLE order_items (
+    order_id INTEGER NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    book_id VARCHAR(255) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price BIGINT NOT NULL CHECK (unit_price >= 0),
+    PRIMARY KEY (order_id, book_id)
+);
+
+ALTER TABLE orders ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT '';
+
+-- Orders so far held a single book with no price.
+INSERT INTO order_items (order_id, book_id, quantity, unit_price)
+SELECT id, book_id, 1, 0 FROM orders;
+
+ALTER TABLE orders DROP COLUMN book_id;
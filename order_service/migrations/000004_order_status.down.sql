@@ -0,0 +1,14 @@
+DROP INDEX orders_user_id_status_idx;
+
+ALTER TABLE orders DROP CONSTRAINT orders_status_check;
+
+UPDATE orders SET status = CASE status
+    WHEN 'created' THEN 'создан'
+    WHEN 'paid' THEN 'оплачен'
+    WHEN 'shipped' THEN 'отправлен'
+    WHEN 'delivered' THEN 'завершен'
+    WHEN 'cancelled' THEN 'отменен'
+    WHEN 'refunded' THEN 'возвращен'
+END;
+
+ALTER TABLE orders DROP COLUMN version;
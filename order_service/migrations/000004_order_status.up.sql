@@ -0,0 +1,19 @@
+ALTER TABLE orders ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+UPDATE orders SET status = CASE status
+    WHEN 'создан' THEN 'created'
+    WHEN 'оплачен' THEN 'paid'
+    WHEN 'отправлен' THEN 'shipped'
+    WHEN 'доставлен' THEN 'delivered'
+    WHEN 'завершен' THEN 'delivered'
+    WHEN 'отменен' THEN 'cancelled'
+    WHEN 'возвращен' THEN 'refunded'
+    ELSE status
+END;
+
+-- Any status left unmapped makes the constraint fail, so it has to be fixed
+-- by hand rather than silently turned into another one.
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('created', 'paid', 'shipped', 'delivered', 'cancelled', 'refunded'));
+
+CREATE INDEX orders_user_id_status_idx ON orders (user_id, status);
@@ -0,0 +1,15 @@
+CREATE TABLE order_status_events (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    from_status VARCHAR(32),
+    to_status VARCHAR(32) NOT NULL,
+    actor_id INTEGER NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX order_status_events_order_id_idx ON order_status_events (order_id, id);
+
+-- The history of existing orders is unknown, only where they are now.
+INSERT INTO order_status_events (order_id, from_status, to_status, actor_id, reason, created_at)
+SELECT id, NULL, status, user_id, 'recorded before status history', added_at FROM orders;
@@ -0,0 +1,3 @@
+ALTER TABLE orders DROP COLUMN reservation_id;
+
+ALTER TABLE order_items DROP COLUMN title;
@@ -0,0 +1,4 @@
+ALTER TABLE order_items ADD COLUMN title VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Orders made before stock was reserved have no reservation.
+ALTER TABLE orders ADD COLUMN reservation_id VARCHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+CREATE TABLE refunds (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    amount BIGINT NOT NULL CHECK (amount >= 0),
+    currency VARCHAR(3) NOT NULL DEFAULT '',
+    status VARCHAR(32) NOT NULL CHECK (status IN ('pending', 'completed', 'failed')),
+    reason VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX refunds_order_id_idx ON refunds (order_id);
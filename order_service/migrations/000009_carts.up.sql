@@ -0,0 +1,7 @@
+CREATE TABLE cart_items (
+    user_id INTEGER NOT NULL,
+    book_id VARCHAR(255) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    added_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, book_id)
+);
@@ -0,0 +1,7 @@
+CREATE TABLE guest_cart_items (
+    guest_id VARCHAR(64) NOT NULL,
+    book_id VARCHAR(255) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    added_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (guest_id, book_id)
+);
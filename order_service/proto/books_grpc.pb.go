@@ -0,0 +1,815 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.15.8
+// source: proto/books.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// BookClient is the client API for Book service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BookClient interface {
+	Create(ctx context.Context, in *BookInfo, opts ...grpc.CallOption) (*CreateBookResponse, error)
+	Delete(ctx context.Context, in *DeleteBookRequestResponse, opts ...grpc.CallOption) (*DeleteBookRequestResponse, error)
+	Update(ctx context.Context, in *UpdateBookRequest, opts ...grpc.CallOption) (*BookInfo, error)
+	GetAll(ctx context.Context, in *PageRequest, opts ...grpc.CallOption) (*BookInfoArray, error)
+	GetByID(ctx context.Context, in *GetBookRequset, opts ...grpc.CallOption) (*BookInfo, error)
+	GetByISBN(ctx context.Context, in *GetByISBNRequest, opts ...grpc.CallOption) (*BookInfo, error)
+	GetByAuthor(ctx context.Context, in *GetByAuthorRequest, opts ...grpc.CallOption) (*BookInfoArray, error)
+	GetByPublisher(ctx context.Context, in *GetByPublisherRequest, opts ...grpc.CallOption) (*BookInfoArray, error)
+	GetByGenre(ctx context.Context, in *GetByGenreRequest, opts ...grpc.CallOption) (*BookInfoArray, error)
+	GetByLanguage(ctx context.Context, in *GetByLanguageRequest, opts ...grpc.CallOption) (*BookInfoArray, error)
+	GetWithFilter(ctx context.Context, in *Filter, opts ...grpc.CallOption) (*BookInfoArray, error)
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*BookInfoArray, error)
+	SetStock(ctx context.Context, in *SetStockRequest, opts ...grpc.CallOption) (*BookInfo, error)
+	Reserve(ctx context.Context, in *ReserveRequest, opts ...grpc.CallOption) (*Reservation, error)
+	Release(ctx context.Context, in *ReleaseRequest, opts ...grpc.CallOption) (*Reservation, error)
+	Commit(ctx context.Context, in *CommitRequest, opts ...grpc.CallOption) (*Reservation, error)
+	GetPriceAt(ctx context.Context, in *PriceAtRequest, opts ...grpc.CallOption) (*Price, error)
+	ImportBooks(ctx context.Context, opts ...grpc.CallOption) (Book_ImportBooksClient, error)
+	ExportBooks(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (Book_ExportBooksClient, error)
+}
+
+type bookClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBookClient(cc grpc.ClientConnInterface) BookClient {
+	return &bookClient{cc}
+}
+
+func (c *bookClient) Create(ctx context.Context, in *BookInfo, opts ...grpc.CallOption) (*CreateBookResponse, error) {
+	out := new(CreateBookResponse)
+	err := c.cc.Invoke(ctx, "/proto.Book/Create", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookClient) Delete(ctx context.Context, in *DeleteBookRequestResponse, opts ...grpc.CallOption) (*DeleteBookRequestResponse, error) {
+	out := new(DeleteBookRequestResponse)
+	err := c.cc.Invoke(ctx, "/proto.Book/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookClient) Update(ctx context.Context, in *UpdateBookRequest, opts ...grpc.CallOption) (*BookInfo, error) {
+	out := new(BookInfo)
+	err := c.cc.Invoke(ctx, "/proto.Book/Update", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookClient) GetAll(ctx context.Context, in *PageRequest, opts ...grpc.CallOption) (*BookInfoArray, error) {
+	out := new(BookInfoArray)
+	err := c.cc.Invoke(ctx, "/proto.Book/GetAll", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookClient) GetByID(ctx context.Context, in *GetBookRequset, opts ...grpc.CallOption) (*BookInfo, error) {
+	out := new(BookInfo)
+	err := c.cc.Invoke(ctx, "/proto.Book/GetByID", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookClient) GetByISBN(ctx context.Context, in *GetByISBNRequest, opts ...grpc.CallOption) (*BookInfo, error) {
+	out := new(BookInfo)
+	err := c.cc.Invoke(ctx, "/proto.Book/GetByISBN", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookClient) GetByAuthor(ctx context.Context, in *GetByAuthorRequest, opts ...grpc.CallOption) (*BookInfoArray, error) {
+	out := new(BookInfoArray)
+	err := c.cc.Invoke(ctx, "/proto.Book/GetByAuthor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookClient) GetByPublisher(ctx context.Context, in *GetByPublisherRequest, opts ...grpc.CallOption) (*BookInfoArray, error) {
+	out := new(BookInfoArray)
+	err := c.cc.Invoke(ctx, "/proto.Book/GetByPublisher", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookClient) GetByGenre(ctx context.Context, in *GetByGenreRequest, opts ...grpc.CallOption) (*BookInfoArray, error) {
+	out := new(BookInfoArray)
+	err := c.cc.Invoke(ctx, "/proto.Book/GetByGenre", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookClient) GetByLanguage(ctx context.Context, in *GetByLanguageRequest, opts ...grpc.CallOption) (*BookInfoArray, error) {
+	out := new(BookInfoArray)
+	err := c.cc.Invoke(ctx, "/proto.Book/GetByLanguage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookClient) GetWithFilter(ctx context.Context, in *Filter, opts ...grpc.CallOption) (*BookInfoArray, error) {
+	out := new(BookInfoArray)
+	err := c.cc.Invoke(ctx, "/proto.Book/GetWithFilter", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*BookInfoArray, error) {
+	out := new(BookInfoArray)
+	err := c.cc.Invoke(ctx, "/proto.Book/Search", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookClient) SetStock(ctx context.Context, in *SetStockRequest, opts ...grpc.CallOption) (*BookInfo, error) {
+	out := new(BookInfo)
+	err := c.cc.Invoke(ctx, "/proto.Book/SetStock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookClient) Reserve(ctx context.Context, in *ReserveRequest, opts ...grpc.CallOption) (*Reservation, error) {
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, "/proto.Book/Reserve", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookClient) Release(ctx context.Context, in *ReleaseRequest, opts ...grpc.CallOption) (*Reservation, error) {
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, "/proto.Book/Release", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookClient) Commit(ctx context.Context, in *CommitRequest, opts ...grpc.CallOption) (*Reservation, error) {
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, "/proto.Book/Commit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookClient) GetPriceAt(ctx context.Context, in *PriceAtRequest, opts ...grpc.CallOption) (*Price, error) {
+	out := new(Price)
+	err := c.cc.Invoke(ctx, "/proto.Book/GetPriceAt", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookClient) ImportBooks(ctx context.Context, opts ...grpc.CallOption) (Book_ImportBooksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Book_ServiceDesc.Streams[0], "/proto.Book/ImportBooks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bookImportBooksClient{stream}
+	return x, nil
+}
+
+type Book_ImportBooksClient interface {
+	Send(*ImportBookRequest) error
+	CloseAndRecv() (*ImportReport, error)
+	grpc.ClientStream
+}
+
+type bookImportBooksClient struct {
+	grpc.ClientStream
+}
+
+func (x *bookImportBooksClient) Send(m *ImportBookRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *bookImportBooksClient) CloseAndRecv() (*ImportReport, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportReport)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *bookClient) ExportBooks(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (Book_ExportBooksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Book_ServiceDesc.Streams[1], "/proto.Book/ExportBooks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bookExportBooksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Book_ExportBooksClient interface {
+	Recv() (*BookInfo, error)
+	grpc.ClientStream
+}
+
+type bookExportBooksClient struct {
+	grpc.ClientStream
+}
+
+func (x *bookExportBooksClient) Recv() (*BookInfo, error) {
+	m := new(BookInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// BookServer is the server API for Book service.
+// All implementations must embed UnimplementedBookServer
+// for forward compatibility
+type BookServer interface {
+	Create(context.Context, *BookInfo) (*CreateBookResponse, error)
+	Delete(context.Context, *DeleteBookRequestResponse) (*DeleteBookRequestResponse, error)
+	Update(context.Context, *UpdateBookRequest) (*BookInfo, error)
+	GetAll(context.Context, *PageRequest) (*BookInfoArray, error)
+	GetByID(context.Context, *GetBookRequset) (*BookInfo, error)
+	GetByISBN(context.Context, *GetByISBNRequest) (*BookInfo, error)
+	GetByAuthor(context.Context, *GetByAuthorRequest) (*BookInfoArray, error)
+	GetByPublisher(context.Context, *GetByPublisherRequest) (*BookInfoArray, error)
+	GetByGenre(context.Context, *GetByGenreRequest) (*BookInfoArray, error)
+	GetByLanguage(context.Context, *GetByLanguageRequest) (*BookInfoArray, error)
+	GetWithFilter(context.Context, *Filter) (*BookInfoArray, error)
+	Search(context.Context, *SearchRequest) (*BookInfoArray, error)
+	SetStock(context.Context, *SetStockRequest) (*BookInfo, error)
+	Reserve(context.Context, *ReserveRequest) (*Reservation, error)
+	Release(context.Context, *ReleaseRequest) (*Reservation, error)
+	Commit(context.Context, *CommitRequest) (*Reservation, error)
+	GetPriceAt(context.Context, *PriceAtRequest) (*Price, error)
+	ImportBooks(Book_ImportBooksServer) error
+	ExportBooks(*ExportRequest, Book_ExportBooksServer) error
+	mustEmbedUnimplementedBookServer()
+}
+
+// UnimplementedBookServer must be embedded to have forward compatible implementations.
+type UnimplementedBookServer struct {
+}
+
+func (UnimplementedBookServer) Create(context.Context, *BookInfo) (*CreateBookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedBookServer) Delete(context.Context, *DeleteBookRequestResponse) (*DeleteBookRequestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedBookServer) Update(context.Context, *UpdateBookRequest) (*BookInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
+func (UnimplementedBookServer) GetAll(context.Context, *PageRequest) (*BookInfoArray, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAll not implemented")
+}
+func (UnimplementedBookServer) GetByID(context.Context, *GetBookRequset) (*BookInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetByID not implemented")
+}
+func (UnimplementedBookServer) GetByISBN(context.Context, *GetByISBNRequest) (*BookInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetByISBN not implemented")
+}
+func (UnimplementedBookServer) GetByAuthor(context.Context, *GetByAuthorRequest) (*BookInfoArray, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetByAuthor not implemented")
+}
+func (UnimplementedBookServer) GetByPublisher(context.Context, *GetByPublisherRequest) (*BookInfoArray, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetByPublisher not implemented")
+}
+func (UnimplementedBookServer) GetByGenre(context.Context, *GetByGenreRequest) (*BookInfoArray, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetByGenre not implemented")
+}
+func (UnimplementedBookServer) GetByLanguage(context.Context, *GetByLanguageRequest) (*BookInfoArray, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetByLanguage not implemented")
+}
+func (UnimplementedBookServer) GetWithFilter(context.Context, *Filter) (*BookInfoArray, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWithFilter not implemented")
+}
+func (UnimplementedBookServer) Search(context.Context, *SearchRequest) (*BookInfoArray, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedBookServer) SetStock(context.Context, *SetStockRequest) (*BookInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetStock not implemented")
+}
+func (UnimplementedBookServer) Reserve(context.Context, *ReserveRequest) (*Reservation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reserve not implemented")
+}
+func (UnimplementedBookServer) Release(context.Context, *ReleaseRequest) (*Reservation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Release not implemented")
+}
+func (UnimplementedBookServer) Commit(context.Context, *CommitRequest) (*Reservation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Commit not implemented")
+}
+func (UnimplementedBookServer) GetPriceAt(context.Context, *PriceAtRequest) (*Price, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPriceAt not implemented")
+}
+func (UnimplementedBookServer) ImportBooks(Book_ImportBooksServer) error {
+	return status.Errorf(codes.Unimplemented, "method ImportBooks not implemented")
+}
+func (UnimplementedBookServer) ExportBooks(*ExportRequest, Book_ExportBooksServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportBooks not implemented")
+}
+func (UnimplementedBookServer) mustEmbedUnimplementedBookServer() {}
+
+// UnsafeBookServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BookServer will
+// result in compilation errors.
+type UnsafeBookServer interface {
+	mustEmbedUnimplementedBookServer()
+}
+
+func RegisterBookServer(s grpc.ServiceRegistrar, srv BookServer) {
+	s.RegisterService(&Book_ServiceDesc, srv)
+}
+
+func _Book_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BookInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Book/Create",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServer).Create(ctx, req.(*BookInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Book_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteBookRequestResponse)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Book/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServer).Delete(ctx, req.(*DeleteBookRequestResponse))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Book_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Book/Update",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServer).Update(ctx, req.(*UpdateBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Book_GetAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServer).GetAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Book/GetAll",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServer).GetAll(ctx, req.(*PageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Book_GetByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBookRequset)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServer).GetByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Book/GetByID",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServer).GetByID(ctx, req.(*GetBookRequset))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Book_GetByISBN_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetByISBNRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServer).GetByISBN(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Book/GetByISBN",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServer).GetByISBN(ctx, req.(*GetByISBNRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Book_GetByAuthor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetByAuthorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServer).GetByAuthor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Book/GetByAuthor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServer).GetByAuthor(ctx, req.(*GetByAuthorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Book_GetByPublisher_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetByPublisherRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServer).GetByPublisher(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Book/GetByPublisher",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServer).GetByPublisher(ctx, req.(*GetByPublisherRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Book_GetByGenre_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetByGenreRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServer).GetByGenre(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Book/GetByGenre",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServer).GetByGenre(ctx, req.(*GetByGenreRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Book_GetByLanguage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetByLanguageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServer).GetByLanguage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Book/GetByLanguage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServer).GetByLanguage(ctx, req.(*GetByLanguageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Book_GetWithFilter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Filter)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServer).GetWithFilter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Book/GetWithFilter",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServer).GetWithFilter(ctx, req.(*Filter))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Book_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Book/Search",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Book_SetStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetStockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServer).SetStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Book/SetStock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServer).SetStock(ctx, req.(*SetStockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Book_Reserve_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReserveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServer).Reserve(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Book/Reserve",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServer).Reserve(ctx, req.(*ReserveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Book_Release_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServer).Release(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Book/Release",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServer).Release(ctx, req.(*ReleaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Book_Commit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServer).Commit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Book/Commit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServer).Commit(ctx, req.(*CommitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Book_GetPriceAt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PriceAtRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServer).GetPriceAt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Book/GetPriceAt",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServer).GetPriceAt(ctx, req.(*PriceAtRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Book_ImportBooks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BookServer).ImportBooks(&bookImportBooksServer{stream})
+}
+
+type Book_ImportBooksServer interface {
+	SendAndClose(*ImportReport) error
+	Recv() (*ImportBookRequest, error)
+	grpc.ServerStream
+}
+
+type bookImportBooksServer struct {
+	grpc.ServerStream
+}
+
+func (x *bookImportBooksServer) SendAndClose(m *ImportReport) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *bookImportBooksServer) Recv() (*ImportBookRequest, error) {
+	m := new(ImportBookRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Book_ExportBooks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BookServer).ExportBooks(m, &bookExportBooksServer{stream})
+}
+
+type Book_ExportBooksServer interface {
+	Send(*BookInfo) error
+	grpc.ServerStream
+}
+
+type bookExportBooksServer struct {
+	grpc.ServerStream
+}
+
+func (x *bookExportBooksServer) Send(m *BookInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Book_ServiceDesc is the grpc.ServiceDesc for Book service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Book_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.Book",
+	HandlerType: (*BookServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _Book_Create_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _Book_Delete_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _Book_Update_Handler,
+		},
+		{
+			MethodName: "GetAll",
+			Handler:    _Book_GetAll_Handler,
+		},
+		{
+			MethodName: "GetByID",
+			Handler:    _Book_GetByID_Handler,
+		},
+		{
+			MethodName: "GetByISBN",
+			Handler:    _Book_GetByISBN_Handler,
+		},
+		{
+			MethodName: "GetByAuthor",
+			Handler:    _Book_GetByAuthor_Handler,
+		},
+		{
+			MethodName: "GetByPublisher",
+			Handler:    _Book_GetByPublisher_Handler,
+		},
+		{
+			MethodName: "GetByGenre",
+			Handler:    _Book_GetByGenre_Handler,
+		},
+		{
+			MethodName: "GetByLanguage",
+			Handler:    _Book_GetByLanguage_Handler,
+		},
+		{
+			MethodName: "GetWithFilter",
+			Handler:    _Book_GetWithFilter_Handler,
+		},
+		{
+			MethodName: "Search",
+			Handler:    _Book_Search_Handler,
+		},
+		{
+			MethodName: "SetStock",
+			Handler:    _Book_SetStock_Handler,
+		},
+		{
+			MethodName: "Reserve",
+			Handler:    _Book_Reserve_Handler,
+		},
+		{
+			MethodName: "Release",
+			Handler:    _Book_Release_Handler,
+		},
+		{
+			MethodName: "Commit",
+			Handler:    _Book_Commit_Handler,
+		},
+		{
+			MethodName: "GetPriceAt",
+			Handler:    _Book_GetPriceAt_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ImportBooks",
+			Handler:       _Book_ImportBooks_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ExportBooks",
+			Handler:       _Book_ExportBooks_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/books.proto",
+}
@@ -10,12 +10,13 @@ import (
 	"time"
 
 	"github.com/Levap123/user_service/internal/configs"
+	"github.com/Levap123/user_service/internal/notifier"
+	"github.com/Levap123/user_service/internal/token"
 	"github.com/Levap123/user_service/internal/user"
 	"github.com/Levap123/user_service/internal/user/postgres"
 	"github.com/Levap123/user_service/internal/validator"
 	"github.com/Levap123/user_service/proto"
 
-	"github.com/Levap123/utils/jwt"
 	"github.com/Levap123/utils/lg"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
@@ -47,8 +48,31 @@ func main() {
 
 	repo := postgres.NewUserRepo(DB, lg)
 
-	jwt := jwt.NewJWT(cfg.JWTSign)
-	service := user.NewUserService(repo, jwt)
+	jwt := token.NewJWT(cfg.JWTSign)
+	notifier, err := notifier.New(cfg.Notifier.Kind, cfg.Notifier.File, lg)
+	if err != nil {
+		lg.Fatalf("error in creating notifier: %v", err)
+	}
+
+	service := user.NewUserService(repo, jwt, notifier, user.ServiceConfig{
+		AdminEmail:       cfg.Admin.Email,
+		PasswordResetTTL: cfg.PasswordReset.TTL,
+		VerificationLink: cfg.Verification.Link,
+		VerificationTTL:  cfg.Verification.TTL,
+	})
+
+	ctxAdmin, cancelAdmin := context.WithTimeout(context.Background(), time.Second*1)
+	defer cancelAdmin()
+
+	granted, err := service.BootstrapAdmin(ctxAdmin)
+	if err != nil {
+		lg.Fatalf("error in bootstrapping admin: %v", err)
+	}
+	if granted {
+		lg.Infof("%s is made admin", cfg.Admin.Email)
+	} else if cfg.Admin.Email != "" {
+		lg.Infof("%s is not made admin: there is an admin already, or the user has not verified the email", cfg.Admin.Email)
+	}
 
 	validator := validator.NewValidator(cfg)
 	handler := user.NewUserHandler(service, lg, validator)
@@ -63,7 +87,7 @@ func main() {
 	reflection.Register(srv)
 
 	lg.Info("server is started")
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
 	
 	go func() {
@@ -1,6 +1,10 @@
 package configs
 
-import "github.com/ilyakaznacheev/cleanenv"
+import (
+	"time"
+
+	"github.com/ilyakaznacheev/cleanenv"
+)
 
 type Configs struct {
 	Postgres struct {
@@ -17,6 +21,30 @@ type Configs struct {
 
 	JWTSign string `yaml:"jwt_sign"`
 
+	// Admin.Email names the user made admin at startup while there is no
+	// admin yet. The user must have signed up and verified the email; the
+	// old default admin@bookstore.com is refused.
+	Admin struct {
+		Email string `yaml:"email"`
+	} `yaml:"admin"`
+
+	PasswordReset struct {
+		TTL time.Duration `yaml:"ttl"`
+	} `yaml:"password_reset"`
+
+	// Verification.Link is where the verification token is sent to, with %s
+	// in place of the token.
+	Verification struct {
+		Link string        `yaml:"link"`
+		TTL  time.Duration `yaml:"ttl"`
+	} `yaml:"verification"`
+
+	// Notifier.Kind is log or file; file writes messages to Notifier.File.
+	Notifier struct {
+		Kind string `yaml:"kind"`
+		File string `yaml:"file"`
+	} `yaml:"notifier"`
+
 	Validator struct {
 		PasswordMin int `yaml:"password_min"`
 		PasswordMax int `yaml:"password_max"`
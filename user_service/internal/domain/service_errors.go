@@ -3,7 +3,18 @@ package domain
 import "errors"
 
 var (
-	ErrIncorrectPassword  = errors.New("user password incorrect")
-	ErrIncorrectTokenType = errors.New("token type incorrect")
-	ErrTokensMissmatched  = errors.New("this token belongs to different users")
+	ErrIncorrectPassword   = errors.New("user password incorrect")
+	ErrIncorrectTokenType  = errors.New("token type incorrect")
+	ErrTokensMissmatched   = errors.New("this token belongs to different users")
+	ErrInvalidToken        = errors.New("token is invalid")
+	ErrPermissionDenied    = errors.New("permission denied")
+	ErrUnknownRole         = errors.New("unknown role")
+	ErrLastAdmin           = errors.New("the last admin can't lose the admin role")
+	ErrTokenRevoked        = errors.New("token is revoked")
+	ErrTokenReused         = errors.New("refresh token is used twice, its family is revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrResetTokenInvalid   = errors.New("password reset token is invalid or expired")
+	ErrVerificationInvalid = errors.New("email verification token is invalid or expired")
+	ErrEmailVerified       = errors.New("email is already verified")
+	ErrDefaultAdminEmail   = errors.New("admin email is left at the default, set your own")
 )
@@ -0,0 +1,97 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Message is something the user service tells a user, like a password
+// reset token.
+type Message struct {
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// Notifier delivers messages to users. Real delivery, e.g. by email, plugs in
+// here; LogNotifier and FileNotifier are for local runs.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+const (
+	KindLog  = "log"
+	KindFile = "file"
+)
+
+// New returns the notifier of the kind: log, or file writing to path.
+func New(kind, path string, log *logrus.Logger) (Notifier, error) {
+	switch kind {
+	case KindLog, "":
+		return NewLogNotifier(log), nil
+	case KindFile:
+		return NewFileNotifier(path), nil
+	default:
+		return nil, fmt.Errorf("notifier - unknown kind %q", kind)
+	}
+}
+
+// LogNotifier writes messages to the log.
+type LogNotifier struct {
+	log *logrus.Logger
+}
+
+func NewLogNotifier(log *logrus.Logger) *LogNotifier {
+	return &LogNotifier{
+		log: log,
+	}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, msg Message) error {
+	n.log.Infof("notification to %s - %s: %s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileNotifier appends messages to a file, one JSON object per line.
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{
+		path: path,
+	}
+}
+
+func (n *FileNotifier) Notify(ctx context.Context, msg Message) error {
+	if msg.SentAt.IsZero() {
+		msg.SentAt = time.Now()
+	}
+
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("file notifier - marshal - %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("file notifier - open - %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("file notifier - write - %w", err)
+	}
+	return nil
+}
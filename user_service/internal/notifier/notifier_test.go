@@ -0,0 +1,47 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.log")
+	n := NewFileNotifier(path)
+
+	for _, to := range []string{"a@mail.ru", "b@mail.ru"} {
+		if err := n.Notify(context.Background(), Message{To: to, Subject: "subject", Body: "body"}); err != nil {
+			t.Fatalf("FileNotifier.Notify() error = %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var got []Message
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("line %q is not a message: %v", scanner.Text(), err)
+		}
+		got = append(got, msg)
+	}
+
+	if len(got) != 2 || got[0].To != "a@mail.ru" || got[1].To != "b@mail.ru" || got[0].SentAt.IsZero() {
+		t.Errorf("unexpected messages: %+v", got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New("pigeon", "", nil); err == nil {
+		t.Error("New() should fail on unknown kinds")
+	}
+}
@@ -0,0 +1,60 @@
+package token
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Claims are what user service puts into the tokens it issues. The JTI is
+// kept in StandardClaims.Id; FamilyID names the sign-in the token comes from.
+type Claims struct {
+	jwt.StandardClaims
+	UserID    int      `json:"user_id"`
+	TokenType string   `json:"token_type"`
+	FamilyID  string   `json:"fid,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+}
+
+type JWT struct {
+	sign []byte
+}
+
+func NewJWT(sign string) *JWT {
+	return &JWT{
+		sign: []byte(sign),
+	}
+}
+
+// GenerateJwt signs the claims with HS256. The token lives for the given
+// number of days.
+func (j *JWT) GenerateJwt(claims Claims, days int) (string, error) {
+	now := time.Now()
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.AddDate(0, 0, days).Unix()
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.sign)
+	if err != nil {
+		return "", fmt.Errorf("token - generate - %w", err)
+	}
+	return signed, nil
+}
+
+// ParseToken checks the signature and the expiry of the token and returns
+// its claims.
+func (j *JWT) ParseToken(signed string) (*Claims, error) {
+	claims := &Claims{}
+
+	_, err := jwt.ParseWithClaims(signed, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return j.sign, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("token - parse - %w", err)
+	}
+
+	return claims, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/Levap123/user_service/internal/domain"
+	"github.com/Levap123/user_service/internal/token"
 	"github.com/Levap123/user_service/internal/validator"
 	"github.com/Levap123/user_service/proto"
 	"google.golang.org/grpc/codes"
@@ -31,11 +32,22 @@ func NewUserHandler(service IUserService, logger *logrus.Logger, validator *vali
 
 type IUserService interface {
 	Create(ctx context.Context, user *CreateUserDTO) (uint64, error)
-	GenerateTokens(ctx context.Context, dto *GetUserDTO) (string, string, error)
-	Validate(ctx context.Context, accessToken string) (int, error)
+	GenerateTokens(ctx context.Context, dto *GetUserDTO, client ClientInfo) (string, string, error)
+	Validate(ctx context.Context, accessToken string) (*token.Claims, error)
 	GetByID(ctx context.Context, userID uint64) (*User, error)
+	GetRoles(ctx context.Context, userID uint64) ([]Role, error)
 	UpdateUser(ctx context.Context, dto *UpdateUserDTO) (int, error)
 	RefreshTokens(ctx context.Context, accessToken, refreshToken string) (string, string, error)
+	Logout(ctx context.Context, refreshToken string) error
+	ListSessions(ctx context.Context, accessToken string) ([]Session, error)
+	RequestPasswordReset(ctx context.Context, email string) error
+	ConfirmPasswordReset(ctx context.Context, resetToken, newPassword string) error
+	SendVerification(ctx context.Context, userID uint64) error
+	ResendVerification(ctx context.Context, accessToken string) error
+	VerifyEmail(ctx context.Context, verificationToken string) error
+	RevokeSession(ctx context.Context, accessToken, sessionID string) error
+	AssignRole(ctx context.Context, accessToken string, userID uint64, role string) ([]Role, error)
+	RevokeRole(ctx context.Context, accessToken string, userID uint64, role string) ([]Role, error)
 }
 
 func (uh *UserHandler) SignUp(ctx context.Context, req *proto.SignUpRequest) (*proto.SignUpResponse, error) {
@@ -66,6 +78,11 @@ func (uh *UserHandler) SignUp(ctx context.Context, req *proto.SignUpRequest) (*p
 		return nil, fmt.Errorf("user handler - signup - %w", err)
 	}
 
+	// The account is there already; the user can ask for another link.
+	if err := uh.service.SendVerification(ctx, userID); err != nil {
+		uh.logger.Errorf("error in sending email verification: %v", err)
+	}
+
 	return &proto.SignUpResponse{
 		UserID: uint64(userID),
 	}, nil
@@ -75,7 +92,7 @@ func (uh *UserHandler) SignIn(ctx context.Context, req *proto.SignInRequest) (*p
 	uh.logger.Debugln("signin user")
 	dto := NewGetUserDTO(req)
 
-	accessToken, refreshToken, err := uh.service.GenerateTokens(ctx, dto)
+	accessToken, refreshToken, err := uh.service.GenerateTokens(ctx, dto, clientFromContext(ctx))
 	if err != nil {
 		uh.logger.Errorf("error in signin: %v", err)
 
@@ -97,26 +114,48 @@ func (uh *UserHandler) SignIn(ctx context.Context, req *proto.SignInRequest) (*p
 func (uh *UserHandler) ValidateUser(ctx context.Context, req *proto.ValidateRequest) (*proto.ValidateResponse, error) {
 	uh.logger.Debugln("valivate user access token")
 
-	userID, err := uh.service.Validate(ctx, req.Access)
+	claims, err := uh.service.Validate(ctx, req.Access)
 	if err != nil {
 		uh.logger.Errorf("error in parse user token: %v", err)
 		return nil, status.Errorf(codes.Unauthenticated, "error in validating user token")
 	}
+
+	user, err := uh.service.GetByID(ctx, uint64(claims.UserID))
+	if err != nil {
+		uh.logger.Errorf("error in get user by id: %v", err)
+
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, status.Errorf(codes.Unauthenticated, "error in validating user token")
+		}
+		return nil, err
+	}
+
+	// Roles are read again rather than taken from the token, so a revoked
+	// role stops working at once.
+	roles, err := uh.service.GetRoles(ctx, user.ID)
+	if err != nil {
+		uh.logger.Errorf("error in get user roles: %v", err)
+		return nil, err
+	}
+
 	return &proto.ValidateResponse{
-		UserID: uint64(userID),
+		UserID:        user.ID,
+		Roles:         roleNames(roles),
+		Permissions:   Permissions(roleNames(roles)),
+		EmailVerified: user.EmailVerified,
 	}, nil
 }
 
 func (uh *UserHandler) GetMe(ctx context.Context, req *proto.ValidateRequest) (*proto.GetResponse, error) {
 	uh.logger.Debugln("get user info by access token")
 
-	userID, err := uh.service.Validate(ctx, req.Access)
+	claims, err := uh.service.Validate(ctx, req.Access)
 	if err != nil {
 		uh.logger.Errorf("error in parse user token: %v", err)
 		return nil, status.Errorf(codes.Unauthenticated, "error in validating user token")
 	}
 
-	user, err := uh.service.GetByID(ctx, uint64(userID))
+	user, err := uh.service.GetByID(ctx, uint64(claims.UserID))
 	if err != nil {
 		uh.logger.Errorf("error in get user by id: %v", err)
 
@@ -193,6 +232,9 @@ func (uh *UserHandler) Refresh(ctx context.Context, req *proto.RefreshRequestRes
 	if err != nil {
 		uh.logger.Errorf("error in refreshing tokens: %v", err)
 
+		if errors.Is(err, domain.ErrTokenReused) {
+			return nil, status.Errorf(codes.Unauthenticated, "refresh token is already used, sign in again")
+		}
 		return nil, status.Errorf(codes.Unauthenticated, "error in refreshing")
 	}
 
@@ -201,3 +243,172 @@ func (uh *UserHandler) Refresh(ctx context.Context, req *proto.RefreshRequestRes
 		Refresh: refreshToken,
 	}, nil
 }
+
+func (uh *UserHandler) Logout(ctx context.Context, req *proto.LogoutRequest) (*proto.LogoutResponse, error) {
+	uh.logger.Debugln("logout")
+
+	if err := uh.service.Logout(ctx, req.Refresh); err != nil {
+		uh.logger.Errorf("error in logging out: %v", err)
+
+		switch {
+		case errors.Is(err, domain.ErrInvalidToken), errors.Is(err, domain.ErrIncorrectTokenType):
+			return nil, status.Errorf(codes.Unauthenticated, "error in validating refresh token")
+		default:
+			return nil, err
+		}
+	}
+
+	return &proto.LogoutResponse{}, nil
+}
+
+func (uh *UserHandler) RequestPasswordReset(ctx context.Context, req *proto.PasswordResetRequest) (*proto.PasswordResetResponse, error) {
+	uh.logger.Debugln("request password reset")
+
+	if !uh.validator.IsEmailCorrect(req.Email) {
+		return nil, status.Errorf(codes.InvalidArgument, domain.ErrIncorrectEmail.Error())
+	}
+
+	if err := uh.service.RequestPasswordReset(ctx, req.Email); err != nil {
+		uh.logger.Errorf("error in requesting password reset: %v", err)
+		return nil, err
+	}
+
+	return &proto.PasswordResetResponse{}, nil
+}
+
+func (uh *UserHandler) ConfirmPasswordReset(ctx context.Context, req *proto.ConfirmPasswordResetRequest) (*proto.PasswordResetResponse, error) {
+	uh.logger.Debugln("confirm password reset")
+
+	if !uh.validator.IsPasswordLenghtCorrect(req.NewPassword) {
+		return nil, status.Errorf(codes.InvalidArgument, "password length should be from %d to %d",
+			uh.validator.PasswordMin, uh.validator.PasswordMax)
+	}
+
+	if err := uh.service.ConfirmPasswordReset(ctx, req.Token, req.NewPassword); err != nil {
+		uh.logger.Errorf("error in confirming password reset: %v", err)
+
+		if errors.Is(err, domain.ErrResetTokenInvalid) {
+			return nil, status.Errorf(codes.InvalidArgument, domain.ErrResetTokenInvalid.Error())
+		}
+		return nil, err
+	}
+
+	return &proto.PasswordResetResponse{}, nil
+}
+
+func (uh *UserHandler) VerifyEmail(ctx context.Context, req *proto.VerifyEmailRequest) (*proto.VerificationResponse, error) {
+	uh.logger.Debugln("verify email")
+
+	if err := uh.service.VerifyEmail(ctx, req.Token); err != nil {
+		uh.logger.Errorf("error in verifying email: %v", err)
+
+		if errors.Is(err, domain.ErrVerificationInvalid) {
+			return nil, status.Errorf(codes.InvalidArgument, domain.ErrVerificationInvalid.Error())
+		}
+		return nil, err
+	}
+
+	return &proto.VerificationResponse{}, nil
+}
+
+func (uh *UserHandler) ResendVerification(ctx context.Context, req *proto.ValidateRequest) (*proto.VerificationResponse, error) {
+	uh.logger.Debugln("resend email verification")
+
+	if err := uh.service.ResendVerification(ctx, req.Access); err != nil {
+		uh.logger.Errorf("error in resending email verification: %v", err)
+
+		switch {
+		case errors.Is(err, domain.ErrEmailVerified):
+			return nil, status.Errorf(codes.FailedPrecondition, domain.ErrEmailVerified.Error())
+		case errors.Is(err, domain.ErrInvalidToken), errors.Is(err, domain.ErrIncorrectTokenType),
+			errors.Is(err, domain.ErrTokenRevoked):
+			return nil, status.Errorf(codes.Unauthenticated, "error in validating user token")
+		}
+		return nil, err
+	}
+
+	return &proto.VerificationResponse{}, nil
+}
+
+func (uh *UserHandler) ListSessions(ctx context.Context, req *proto.ValidateRequest) (*proto.SessionsResponse, error) {
+	uh.logger.Debugln("list sessions")
+
+	sessions, err := uh.service.ListSessions(ctx, req.Access)
+	if err != nil {
+		uh.logger.Errorf("error in listing sessions: %v", err)
+		return nil, sessionError(err)
+	}
+
+	return fromSessionsToResp(sessions), nil
+}
+
+func (uh *UserHandler) RevokeSession(ctx context.Context, req *proto.RevokeSessionRequest) (*proto.RevokeSessionResponse, error) {
+	uh.logger.Debugln("revoke session")
+
+	if err := uh.service.RevokeSession(ctx, req.Access, req.SessionId); err != nil {
+		uh.logger.Errorf("error in revoking session: %v", err)
+		return nil, sessionError(err)
+	}
+
+	return &proto.RevokeSessionResponse{}, nil
+}
+
+func sessionError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidToken), errors.Is(err, domain.ErrIncorrectTokenType),
+		errors.Is(err, domain.ErrTokenRevoked):
+		return status.Errorf(codes.Unauthenticated, "error in validating user token")
+	case errors.Is(err, domain.ErrSessionNotFound):
+		return status.Errorf(codes.NotFound, domain.ErrSessionNotFound.Error())
+	default:
+		return err
+	}
+}
+
+func (uh *UserHandler) AssignRole(ctx context.Context, req *proto.RoleRequest) (*proto.RolesResponse, error) {
+	uh.logger.Debugln("assign role")
+
+	roles, err := uh.service.AssignRole(ctx, req.Access, req.UserID, req.Role)
+	if err != nil {
+		uh.logger.Errorf("error in assigning role: %v", err)
+		return nil, roleError(err)
+	}
+
+	return &proto.RolesResponse{
+		UserID: req.UserID,
+		Roles:  roleNames(roles),
+	}, nil
+}
+
+func (uh *UserHandler) RevokeRole(ctx context.Context, req *proto.RoleRequest) (*proto.RolesResponse, error) {
+	uh.logger.Debugln("revoke role")
+
+	roles, err := uh.service.RevokeRole(ctx, req.Access, req.UserID, req.Role)
+	if err != nil {
+		uh.logger.Errorf("error in revoking role: %v", err)
+		return nil, roleError(err)
+	}
+
+	return &proto.RolesResponse{
+		UserID: req.UserID,
+		Roles:  roleNames(roles),
+	}, nil
+}
+
+func roleError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidToken), errors.Is(err, domain.ErrIncorrectTokenType),
+		errors.Is(err, domain.ErrTokenRevoked):
+		return status.Errorf(codes.Unauthenticated, "error in validating user token")
+	case errors.Is(err, domain.ErrPermissionDenied):
+		return status.Errorf(codes.PermissionDenied, domain.ErrPermissionDenied.Error())
+	case errors.Is(err, domain.ErrUnknownRole):
+		return status.Errorf(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrUserNotFound):
+		return status.Errorf(codes.NotFound, "user with this id not found")
+	case errors.Is(err, domain.ErrLastAdmin):
+		return status.Errorf(codes.FailedPrecondition, domain.ErrLastAdmin.Error())
+	default:
+		return err
+	}
+}
@@ -2,6 +2,7 @@ package mock
 
 import (
 	"context"
+	"time"
 
 	"github.com/Levap123/user_service/internal/domain"
 	"github.com/Levap123/user_service/internal/user"
@@ -34,15 +35,16 @@ var users = []*user.User{
 	},
 }
 
-func (ur *UserRepo) Create(ctx context.Context, user *user.User) (uint64, error) {
+func (ur *UserRepo) Create(ctx context.Context, u *user.User) (uint64, error) {
 	for _, userIn := range users {
-		if userIn.Email == user.Email || userIn.Username == user.Username {
+		if userIn.Email == u.Email || userIn.Username == u.Username {
 			return 0, domain.ErrUnique
 		}
 	}
-	user.ID = uint64(len(users) + 1)
-	users = append(users, user)
-	return user.ID, nil
+	u.ID = uint64(len(users) + 1)
+	users = append(users, u)
+	roles[u.ID] = []user.Role{user.RoleCustomer}
+	return u.ID, nil
 }
 
 func (ur *UserRepo) GetByEmail(ctx context.Context, email string) (*user.User, error) {
@@ -73,3 +75,199 @@ func (ur *UserRepo) UpdateInfo(ctx context.Context, user *user.User) (int, error
 	}
 	return 0, domain.ErrUserNotFound
 }
+
+var roles = map[uint64][]user.Role{
+	1: {user.RoleAdmin, user.RoleCustomer},
+	2: {user.RoleCustomer},
+	3: {user.RoleCustomer},
+}
+
+func (ur *UserRepo) GetRoles(ctx context.Context, userID uint64) ([]user.Role, error) {
+	return roles[userID], nil
+}
+
+func (ur *UserRepo) AssignRole(ctx context.Context, userID uint64, role user.Role) error {
+	if _, err := ur.GetByID(ctx, userID); err != nil {
+		return err
+	}
+	for _, r := range roles[userID] {
+		if r == role {
+			return nil
+		}
+	}
+	roles[userID] = append(roles[userID], role)
+	return nil
+}
+
+func (ur *UserRepo) RevokeRole(ctx context.Context, userID uint64, role user.Role) error {
+	if role == user.RoleAdmin {
+		var admins []uint64
+		for id, userRoles := range roles {
+			for _, r := range userRoles {
+				if r == user.RoleAdmin {
+					admins = append(admins, id)
+				}
+			}
+		}
+		if len(admins) == 1 && admins[0] == userID {
+			return domain.ErrLastAdmin
+		}
+	}
+
+	kept := []user.Role{}
+	for _, r := range roles[userID] {
+		if r != role {
+			kept = append(kept, r)
+		}
+	}
+	roles[userID] = kept
+	return nil
+}
+
+func (ur *UserRepo) BootstrapAdmin(ctx context.Context, email string) (bool, error) {
+	for _, userRoles := range roles {
+		for _, r := range userRoles {
+			if r == user.RoleAdmin {
+				return false, nil
+			}
+		}
+	}
+
+	u, err := ur.GetByEmail(ctx, email)
+	if err != nil || !u.EmailVerified {
+		return false, nil
+	}
+	return true, ur.AssignRole(ctx, u.ID, user.RoleAdmin)
+}
+
+var (
+	families      = map[string]user.TokenFamily{}
+	refreshTokens = map[string]user.RefreshToken{}
+	usedTokens    = map[string]bool{}
+)
+
+func (ur *UserRepo) CreateTokenFamily(ctx context.Context, family user.TokenFamily, token user.RefreshToken) error {
+	family.CreatedAt = time.Now()
+	families[family.ID] = family
+	refreshTokens[token.JTIHash] = token
+	return nil
+}
+
+func (ur *UserRepo) GetTokenFamily(ctx context.Context, familyID string) (user.TokenFamily, error) {
+	family, ok := families[familyID]
+	if !ok {
+		return user.TokenFamily{}, domain.ErrInvalidToken
+	}
+	return family, nil
+}
+
+func (ur *UserRepo) RotateRefreshToken(ctx context.Context, jtiHash string, next user.RefreshToken) (user.TokenFamily, error) {
+	token, ok := refreshTokens[jtiHash]
+	if !ok || token.FamilyID != next.FamilyID {
+		return user.TokenFamily{}, domain.ErrInvalidToken
+	}
+
+	family := families[token.FamilyID]
+	if family.Revoked() {
+		return user.TokenFamily{}, domain.ErrTokenRevoked
+	}
+	if usedTokens[jtiHash] {
+		ur.RevokeTokenFamily(ctx, family.ID)
+		return user.TokenFamily{}, domain.ErrTokenReused
+	}
+
+	usedTokens[jtiHash] = true
+	refreshTokens[next.JTIHash] = next
+
+	now := time.Now()
+	family.LastRefreshedAt = &now
+	families[family.ID] = family
+	return family, nil
+}
+
+func (ur *UserRepo) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	family, ok := families[familyID]
+	if ok && !family.Revoked() {
+		now := time.Now()
+		family.RevokedAt = &now
+		families[familyID] = family
+	}
+	return nil
+}
+
+func (ur *UserRepo) ListTokenFamilies(ctx context.Context, userID uint64) ([]user.TokenFamily, error) {
+	list := []user.TokenFamily{}
+	for _, family := range families {
+		if family.UserID == userID && !family.Revoked() {
+			list = append(list, family)
+		}
+	}
+	return list, nil
+}
+
+func (ur *UserRepo) RevokeUserTokenFamily(ctx context.Context, userID uint64, familyID string) error {
+	family, ok := families[familyID]
+	if !ok || family.UserID != userID {
+		return domain.ErrSessionNotFound
+	}
+	return ur.RevokeTokenFamily(ctx, familyID)
+}
+
+var passwordResets = map[string]user.PasswordReset{}
+
+func (ur *UserRepo) CreatePasswordReset(ctx context.Context, reset user.PasswordReset) error {
+	for hash, old := range passwordResets {
+		if old.UserID == reset.UserID {
+			delete(passwordResets, hash)
+		}
+	}
+	passwordResets[reset.TokenHash] = reset
+	return nil
+}
+
+func (ur *UserRepo) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (uint64, error) {
+	reset, ok := passwordResets[tokenHash]
+	if !ok || time.Now().After(reset.ExpiresAt) {
+		return 0, domain.ErrResetTokenInvalid
+	}
+	delete(passwordResets, tokenHash)
+
+	for _, u := range users {
+		if u.ID == reset.UserID {
+			u.Password = passwordHash
+		}
+	}
+	for _, family := range families {
+		if family.UserID == reset.UserID {
+			ur.RevokeTokenFamily(ctx, family.ID)
+		}
+	}
+	return reset.UserID, nil
+}
+
+var emailVerifications = map[string]user.EmailVerification{}
+
+func (ur *UserRepo) CreateEmailVerification(ctx context.Context, verification user.EmailVerification) error {
+	for hash, old := range emailVerifications {
+		if old.UserID == verification.UserID {
+			delete(emailVerifications, hash)
+		}
+	}
+	emailVerifications[verification.TokenHash] = verification
+	return nil
+}
+
+func (ur *UserRepo) VerifyEmail(ctx context.Context, tokenHash string) (uint64, error) {
+	verification, ok := emailVerifications[tokenHash]
+	if !ok || time.Now().After(verification.ExpiresAt) {
+		return 0, domain.ErrVerificationInvalid
+	}
+	delete(emailVerifications, tokenHash)
+
+	for _, u := range users {
+		if u.ID == verification.UserID {
+			u.EmailVerified = true
+		}
+	}
+	return verification.UserID, nil
+}
@@ -1,16 +1,22 @@
 package user
 
 import (
+	"context"
+	"strings"
+
 	"github.com/Levap123/user_service/proto"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/Levap123/utils/crypt"
 )
 
 type User struct {
-	ID       uint64
-	Email    string
-	Username string
-	Password string
+	ID            uint64
+	Email         string
+	Username      string
+	Password      string
+	EmailVerified bool `db:"email_verified"`
 }
 
 type CreateUserDTO struct {
@@ -83,3 +89,50 @@ func (user *User) generatePasswordHash() error {
 func (user *User) PasswordCorrect(password string) bool {
 	return crypt.ComparePassword(password, user.Password) == nil
 }
+
+// Metadata keys the gateway describes its client with.
+const (
+	userAgentKey = "x-client-user-agent"
+	clientIPKey  = "x-client-ip"
+)
+
+func clientFromContext(ctx context.Context) ClientInfo {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ClientInfo{}
+	}
+
+	first := func(key string) string {
+		if values := md.Get(key); len(values) != 0 {
+			return strings.TrimSpace(values[0])
+		}
+		return ""
+	}
+
+	return ClientInfo{
+		UserAgent: first(userAgentKey),
+		IP:        first(clientIPKey),
+	}
+}
+
+func fromSessionsToResp(sessions []Session) *proto.SessionsResponse {
+	resp := &proto.SessionsResponse{
+		Sessions: make([]*proto.Session, 0, len(sessions)),
+	}
+
+	for _, session := range sessions {
+		s := &proto.Session{
+			Id:        session.ID,
+			UserAgent: session.UserAgent,
+			Ip:        session.IP,
+			CreatedAt: timestamppb.New(session.CreatedAt),
+			Current:   session.Current,
+		}
+		if session.LastRefreshedAt != nil {
+			s.LastRefreshedAt = timestamppb.New(*session.LastRefreshedAt)
+		}
+		resp.Sessions = append(resp.Sessions, s)
+	}
+
+	return resp
+}
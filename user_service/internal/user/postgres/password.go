@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Levap123/user_service/internal/domain"
+	"github.com/Levap123/user_service/internal/user"
+)
+
+const passwordResetTable = "password_resets"
+
+// CreatePasswordReset stores the reset token in place of the unused ones of
+// the user.
+func (ur *UserRepo) CreatePasswordReset(ctx context.Context, reset user.PasswordReset) error {
+	tx, err := ur.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		ur.lg.Error(err)
+		return fmt.Errorf("user repo - create password reset - start tx - %w", err)
+	}
+	defer tx.Rollback()
+
+	deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1 AND used_at IS NULL", passwordResetTable)
+	if _, err := tx.ExecContext(ctx, deleteQuery, reset.UserID); err != nil {
+		return fmt.Errorf("user repo - create password reset - delete - %w", err)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s(token_hash, user_id, expires_at) VALUES ($1, $2, $3)", passwordResetTable)
+	if _, err := tx.ExecContext(ctx, query, reset.TokenHash, reset.UserID, reset.ExpiresAt); err != nil {
+		return fmt.Errorf("user repo - create password reset - insert - %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		ur.lg.Error(err)
+		return fmt.Errorf("user repo - create password reset - commit tx - %w", err)
+	}
+	return nil
+}
+
+// ResetPassword uses up the reset token, sets the password of its user and
+// revokes all their token families, all at once. It returns the user ID.
+func (ur *UserRepo) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (uint64, error) {
+	tx, err := ur.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		ur.lg.Error(err)
+		return 0, fmt.Errorf("user repo - reset password - start tx - %w", err)
+	}
+	defer tx.Rollback()
+
+	useQuery := fmt.Sprintf(`UPDATE %s SET used_at = now()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+		RETURNING user_id`, passwordResetTable)
+
+	var userID uint64
+	if err := tx.GetContext(ctx, &userID, useQuery, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("user repo - reset password - %w", domain.ErrResetTokenInvalid)
+		}
+		return 0, fmt.Errorf("user repo - reset password - use token - %w", err)
+	}
+
+	passwordQuery := fmt.Sprintf("UPDATE %s SET password = $1 WHERE id = $2", userTable)
+	if _, err := tx.ExecContext(ctx, passwordQuery, passwordHash, userID); err != nil {
+		return 0, fmt.Errorf("user repo - reset password - update password - %w", err)
+	}
+
+	revokeQuery := fmt.Sprintf("UPDATE %s SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL", tokenFamilyTable)
+	if _, err := tx.ExecContext(ctx, revokeQuery, userID); err != nil {
+		return 0, fmt.Errorf("user repo - reset password - revoke sessions - %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		ur.lg.Error(err)
+		return 0, fmt.Errorf("user repo - reset password - commit tx - %w", err)
+	}
+	return userID, nil
+}
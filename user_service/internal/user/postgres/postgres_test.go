@@ -33,12 +33,23 @@ func run(m *testing.M) (int, error) {
 		id SERIAL PRIMARY KEY,
 		email TEXT UNIQUE NOT NULL,
 		username TEXT UNIQUE NOT NULL,
-		password TEXT NOT NULL
+		password TEXT NOT NULL,
+		email_verified BOOLEAN NOT NULL DEFAULT false
 	);`); err != nil {
 		return -1, err
 	}
 	defer DB.Exec("DROP TABLE users")
 
+	if _, err := DB.Exec(`CREATE TABLE IF NOT EXISTS user_roles (
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		role TEXT NOT NULL,
+		granted_at TIMESTAMP NOT NULL DEFAULT now(),
+		PRIMARY KEY (user_id, role)
+	);`); err != nil {
+		return -1, err
+	}
+	defer DB.Exec("DROP TABLE user_roles")
+
 	defer DB.Close()
 
 	return m.Run(), nil
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Levap123/user_service/internal/domain"
+	"github.com/Levap123/user_service/internal/user"
+)
+
+func (ur *UserRepo) GetRoles(ctx context.Context, userID uint64) ([]user.Role, error) {
+	query := fmt.Sprintf("SELECT role FROM %s WHERE user_id = $1 ORDER BY role", userRoleTable)
+
+	roles := []user.Role{}
+	if err := ur.DB.SelectContext(ctx, &roles, query, userID); err != nil {
+		return nil, fmt.Errorf("user repo - get roles - %w", err)
+	}
+	return roles, nil
+}
+
+// AssignRole grants the role. Granting a role the user has is a no-op.
+func (ur *UserRepo) AssignRole(ctx context.Context, userID uint64, role user.Role) error {
+	query := fmt.Sprintf("INSERT INTO %s(user_id, role) VALUES ($1, $2) ON CONFLICT DO NOTHING", userRoleTable)
+
+	if _, err := ur.DB.ExecContext(ctx, query, userID, role); err != nil {
+		if strings.Contains(err.Error(), "violates foreign key constraint") {
+			return fmt.Errorf("user repo - assign role - %w", domain.ErrUserNotFound)
+		}
+		return fmt.Errorf("user repo - assign role - %w", err)
+	}
+	return nil
+}
+
+// RevokeRole takes the role away unless it is the admin role of the last
+// admin. Admin rows are locked while counting, so two admins can't revoke
+// each other at once.
+func (ur *UserRepo) RevokeRole(ctx context.Context, userID uint64, role user.Role) error {
+	tx, err := ur.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		ur.lg.Error(err)
+		return fmt.Errorf("user repo - revoke role - start tx - %w", err)
+	}
+	defer tx.Rollback()
+
+	if role == user.RoleAdmin {
+		query := fmt.Sprintf("SELECT user_id FROM %s WHERE role = $1 FOR UPDATE", userRoleTable)
+
+		var admins []uint64
+		if err := tx.SelectContext(ctx, &admins, query, user.RoleAdmin); err != nil {
+			return fmt.Errorf("user repo - revoke role - select admins - %w", err)
+		}
+		if len(admins) == 1 && admins[0] == userID {
+			return fmt.Errorf("user repo - revoke role - %w", domain.ErrLastAdmin)
+		}
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1 AND role = $2", userRoleTable)
+	if _, err := tx.ExecContext(ctx, query, userID, role); err != nil {
+		return fmt.Errorf("user repo - revoke role - delete - %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		ur.lg.Error(err)
+		return fmt.Errorf("user repo - revoke role - commit tx - %w", err)
+	}
+	return nil
+}
+
+// BootstrapAdmin makes the user with the email an admin if nobody is one yet
+// and the user verified the email.
+func (ur *UserRepo) BootstrapAdmin(ctx context.Context, email string) (bool, error) {
+	query := fmt.Sprintf(`INSERT INTO %[1]s(user_id, role)
+		SELECT id, $1 FROM %[2]s WHERE lower(email) = lower($2) AND email_verified
+			AND NOT EXISTS (SELECT 1 FROM %[1]s WHERE role = $1)
+		ON CONFLICT DO NOTHING`, userRoleTable, userTable)
+
+	res, err := ur.DB.ExecContext(ctx, query, user.RoleAdmin, email)
+	if err != nil {
+		return false, fmt.Errorf("user repo - bootstrap admin - %w", err)
+	}
+
+	granted, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("user repo - bootstrap admin - %w", err)
+	}
+	return granted > 0, nil
+}
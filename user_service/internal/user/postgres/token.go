@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Levap123/user_service/internal/domain"
+	"github.com/Levap123/user_service/internal/user"
+)
+
+const (
+	tokenFamilyTable   = "token_families"
+	tokenFamilyColumns = "id, user_id, user_agent, ip, created_at, last_refreshed_at, revoked_at"
+	refreshTokenTable  = "refresh_tokens"
+)
+
+func (ur *UserRepo) CreateTokenFamily(ctx context.Context, family user.TokenFamily, token user.RefreshToken) error {
+	tx, err := ur.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		ur.lg.Error(err)
+		return fmt.Errorf("user repo - create token family - start tx - %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf("INSERT INTO %s(id, user_id, user_agent, ip) VALUES ($1, $2, $3, $4)", tokenFamilyTable)
+	if _, err := tx.ExecContext(ctx, query, family.ID, family.UserID, family.UserAgent, family.IP); err != nil {
+		return fmt.Errorf("user repo - create token family - insert family - %w", err)
+	}
+
+	if err := insertRefreshToken(ctx, tx, token); err != nil {
+		return fmt.Errorf("user repo - create token family - %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		ur.lg.Error(err)
+		return fmt.Errorf("user repo - create token family - commit tx - %w", err)
+	}
+	return nil
+}
+
+func (ur *UserRepo) GetTokenFamily(ctx context.Context, familyID string) (user.TokenFamily, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1", tokenFamilyColumns, tokenFamilyTable)
+
+	var family user.TokenFamily
+	if err := ur.DB.GetContext(ctx, &family, query, familyID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user.TokenFamily{}, fmt.Errorf("user repo - get token family - %w", domain.ErrInvalidToken)
+		}
+		return user.TokenFamily{}, fmt.Errorf("user repo - get token family - %w", err)
+	}
+	return family, nil
+}
+
+// RotateRefreshToken uses up the refresh token and stores the next one of its
+// family. A token used before makes the whole family revoked, and the
+// revocation is kept although ErrTokenReused is returned.
+func (ur *UserRepo) RotateRefreshToken(ctx context.Context, jtiHash string, next user.RefreshToken) (user.TokenFamily, error) {
+	tx, err := ur.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		ur.lg.Error(err)
+		return user.TokenFamily{}, fmt.Errorf("user repo - rotate refresh token - start tx - %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`SELECT f.id, f.user_id, f.user_agent, f.ip, f.created_at, f.last_refreshed_at, f.revoked_at, t.used_at
+		FROM %s t JOIN %s f ON f.id = t.family_id
+		WHERE t.jti_hash = $1 AND t.family_id = $2 AND t.expires_at > now()
+		FOR UPDATE`, refreshTokenTable, tokenFamilyTable)
+
+	var row struct {
+		user.TokenFamily
+		UsedAt *time.Time `db:"used_at"`
+	}
+	if err := tx.GetContext(ctx, &row, query, jtiHash, next.FamilyID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user.TokenFamily{}, fmt.Errorf("user repo - rotate refresh token - %w", domain.ErrInvalidToken)
+		}
+		return user.TokenFamily{}, fmt.Errorf("user repo - rotate refresh token - select - %w", err)
+	}
+
+	if row.Revoked() {
+		return user.TokenFamily{}, fmt.Errorf("user repo - rotate refresh token - %w", domain.ErrTokenRevoked)
+	}
+
+	if row.UsedAt != nil {
+		if err := revokeTokenFamily(ctx, tx, row.ID); err != nil {
+			return user.TokenFamily{}, fmt.Errorf("user repo - rotate refresh token - %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			ur.lg.Error(err)
+			return user.TokenFamily{}, fmt.Errorf("user repo - rotate refresh token - commit tx - %w", err)
+		}
+		return user.TokenFamily{}, fmt.Errorf("user repo - rotate refresh token - %w", domain.ErrTokenReused)
+	}
+
+	useQuery := fmt.Sprintf("UPDATE %s SET used_at = now() WHERE jti_hash = $1", refreshTokenTable)
+	if _, err := tx.ExecContext(ctx, useQuery, jtiHash); err != nil {
+		return user.TokenFamily{}, fmt.Errorf("user repo - rotate refresh token - use - %w", err)
+	}
+
+	if err := insertRefreshToken(ctx, tx, next); err != nil {
+		return user.TokenFamily{}, fmt.Errorf("user repo - rotate refresh token - %w", err)
+	}
+
+	refreshQuery := fmt.Sprintf("UPDATE %s SET last_refreshed_at = now() WHERE id = $1", tokenFamilyTable)
+	if _, err := tx.ExecContext(ctx, refreshQuery, row.ID); err != nil {
+		return user.TokenFamily{}, fmt.Errorf("user repo - rotate refresh token - refresh family - %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		ur.lg.Error(err)
+		return user.TokenFamily{}, fmt.Errorf("user repo - rotate refresh token - commit tx - %w", err)
+	}
+	return row.TokenFamily, nil
+}
+
+func (ur *UserRepo) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	if err := revokeTokenFamily(ctx, ur.DB, familyID); err != nil {
+		return fmt.Errorf("user repo - revoke token family - %w", err)
+	}
+	return nil
+}
+
+// ListTokenFamilies returns the families of the user that are not revoked
+// and still have a refresh token to use.
+func (ur *UserRepo) ListTokenFamilies(ctx context.Context, userID uint64) ([]user.TokenFamily, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s f
+		WHERE f.user_id = $1 AND f.revoked_at IS NULL
+			AND EXISTS (SELECT 1 FROM %s t WHERE t.family_id = f.id AND t.used_at IS NULL AND t.expires_at > now())
+		ORDER BY f.created_at DESC`, tokenFamilyColumns, tokenFamilyTable, refreshTokenTable)
+
+	families := []user.TokenFamily{}
+	if err := ur.DB.SelectContext(ctx, &families, query, userID); err != nil {
+		return nil, fmt.Errorf("user repo - list token families - %w", err)
+	}
+	return families, nil
+}
+
+// RevokeUserTokenFamily revokes the family if it belongs to the user.
+// Revoking a revoked family again is not an error.
+func (ur *UserRepo) RevokeUserTokenFamily(ctx context.Context, userID uint64, familyID string) error {
+	query := fmt.Sprintf("UPDATE %s SET revoked_at = COALESCE(revoked_at, now()) WHERE id = $1 AND user_id = $2", tokenFamilyTable)
+
+	res, err := ur.DB.ExecContext(ctx, query, familyID, userID)
+	if err != nil {
+		return fmt.Errorf("user repo - revoke user token family - %w", err)
+	}
+
+	revoked, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("user repo - revoke user token family - %w", err)
+	}
+	if revoked == 0 {
+		return fmt.Errorf("user repo - revoke user token family - %w", domain.ErrSessionNotFound)
+	}
+	return nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func insertRefreshToken(ctx context.Context, db execer, token user.RefreshToken) error {
+	query := fmt.Sprintf("INSERT INTO %s(jti_hash, family_id, expires_at) VALUES ($1, $2, $3)", refreshTokenTable)
+	if _, err := db.ExecContext(ctx, query, token.JTIHash, token.FamilyID, token.ExpiresAt); err != nil {
+		return fmt.Errorf("insert refresh token - %w", err)
+	}
+	return nil
+}
+
+// revokeTokenFamily keeps the first revocation time of a family.
+func revokeTokenFamily(ctx context.Context, db execer, familyID string) error {
+	query := fmt.Sprintf("UPDATE %s SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL", tokenFamilyTable)
+	if _, err := db.ExecContext(ctx, query, familyID); err != nil {
+		return fmt.Errorf("revoke - %w", err)
+	}
+	return nil
+}
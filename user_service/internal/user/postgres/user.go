@@ -26,9 +26,12 @@ func NewUserRepo(DB *sqlx.DB, lg *logrus.Logger) *UserRepo {
 	}
 }
 
-const userTable = "users"
+const (
+	userTable     = "users"
+	userRoleTable = "user_roles"
+)
 
-func (ur *UserRepo) Create(ctx context.Context, user *user.User) (uint64, error) {
+func (ur *UserRepo) Create(ctx context.Context, u *user.User) (uint64, error) {
 	tx, err := ur.DB.BeginTxx(ctx, nil)
 	if err != nil {
 		ur.lg.Error(err)
@@ -38,7 +41,7 @@ func (ur *UserRepo) Create(ctx context.Context, user *user.User) (uint64, error)
 
 	query := fmt.Sprintf("INSERT INTO %s(email, username, password) VALUES ($1, $2, $3) RETURNING id", userTable)
 	var userID uint64
-	if err := tx.Get(&userID, query, user.Email, user.Username, user.Password); err != nil {
+	if err := tx.Get(&userID, query, u.Email, u.Username, u.Password); err != nil {
 		ur.lg.Error(err)
 		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
 			return 0, fmt.Errorf("user repo create - insert - %w", domain.ErrUnique)
@@ -46,6 +49,12 @@ func (ur *UserRepo) Create(ctx context.Context, user *user.User) (uint64, error)
 		return 0, fmt.Errorf("user repo create - insert - %w", err)
 	}
 
+	roleQuery := fmt.Sprintf("INSERT INTO %s(user_id, role) VALUES ($1, $2)", userRoleTable)
+	if _, err := tx.Exec(roleQuery, userID, user.RoleCustomer); err != nil {
+		ur.lg.Error(err)
+		return 0, fmt.Errorf("user repo create - insert role - %w", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		ur.lg.Error(err)
 		return 0, fmt.Errorf("user repo create - commit tx - %w", err)
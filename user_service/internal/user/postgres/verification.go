@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Levap123/user_service/internal/domain"
+	"github.com/Levap123/user_service/internal/user"
+)
+
+const emailVerificationTable = "email_verifications"
+
+// CreateEmailVerification stores the verification token in place of the
+// unused ones of the user.
+func (ur *UserRepo) CreateEmailVerification(ctx context.Context, verification user.EmailVerification) error {
+	tx, err := ur.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		ur.lg.Error(err)
+		return fmt.Errorf("user repo - create email verification - start tx - %w", err)
+	}
+	defer tx.Rollback()
+
+	deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1 AND used_at IS NULL", emailVerificationTable)
+	if _, err := tx.ExecContext(ctx, deleteQuery, verification.UserID); err != nil {
+		return fmt.Errorf("user repo - create email verification - delete - %w", err)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s(token_hash, user_id, expires_at) VALUES ($1, $2, $3)", emailVerificationTable)
+	if _, err := tx.ExecContext(ctx, query, verification.TokenHash, verification.UserID, verification.ExpiresAt); err != nil {
+		return fmt.Errorf("user repo - create email verification - insert - %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		ur.lg.Error(err)
+		return fmt.Errorf("user repo - create email verification - commit tx - %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail uses up the verification token and marks the email of its user
+// as verified. It returns the user ID.
+func (ur *UserRepo) VerifyEmail(ctx context.Context, tokenHash string) (uint64, error) {
+	tx, err := ur.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		ur.lg.Error(err)
+		return 0, fmt.Errorf("user repo - verify email - start tx - %w", err)
+	}
+	defer tx.Rollback()
+
+	useQuery := fmt.Sprintf(`UPDATE %s SET used_at = now()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+		RETURNING user_id`, emailVerificationTable)
+
+	var userID uint64
+	if err := tx.GetContext(ctx, &userID, useQuery, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("user repo - verify email - %w", domain.ErrVerificationInvalid)
+		}
+		return 0, fmt.Errorf("user repo - verify email - use token - %w", err)
+	}
+
+	verifyQuery := fmt.Sprintf("UPDATE %s SET email_verified = true WHERE id = $1", userTable)
+	if _, err := tx.ExecContext(ctx, verifyQuery, userID); err != nil {
+		return 0, fmt.Errorf("user repo - verify email - update user - %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		ur.lg.Error(err)
+		return 0, fmt.Errorf("user repo - verify email - commit tx - %w", err)
+	}
+	return userID, nil
+}
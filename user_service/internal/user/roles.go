@@ -0,0 +1,78 @@
+package user
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Levap123/user_service/internal/domain"
+)
+
+type Role string
+
+const (
+	RoleCustomer       Role = "customer"
+	RoleCatalogManager Role = "catalog_manager"
+	RoleOrderManager   Role = "order_manager"
+	RoleAdmin          Role = "admin"
+)
+
+// Permission is what the gateway checks before letting a request through.
+type Permission string
+
+const (
+	PermissionManageCatalog Permission = "catalog:manage"
+	PermissionManageOrders  Permission = "orders:manage"
+	PermissionManageRoles   Permission = "roles:manage"
+)
+
+// rolePermissions grants permissions to roles. Every user is a customer,
+// which needs no permission beyond being signed in.
+var rolePermissions = map[Role][]Permission{
+	RoleCustomer:       {},
+	RoleCatalogManager: {PermissionManageCatalog},
+	RoleOrderManager:   {PermissionManageOrders},
+	RoleAdmin:          {PermissionManageCatalog, PermissionManageOrders, PermissionManageRoles},
+}
+
+func ParseRole(name string) (Role, error) {
+	role := Role(name)
+	if _, ok := rolePermissions[role]; !ok {
+		return "", fmt.Errorf("role %q - %w", name, domain.ErrUnknownRole)
+	}
+	return role, nil
+}
+
+// Permissions returns the permissions the roles grant together, sorted.
+func Permissions(roles []string) []string {
+	seen := map[Permission]bool{}
+	for _, role := range roles {
+		for _, permission := range rolePermissions[Role(role)] {
+			seen[permission] = true
+		}
+	}
+
+	permissions := make([]string, 0, len(seen))
+	for permission := range seen {
+		permissions = append(permissions, string(permission))
+	}
+	sort.Strings(permissions)
+
+	return permissions
+}
+
+func hasRole(roles []string, role Role) bool {
+	for _, r := range roles {
+		if Role(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+func roleNames(roles []Role) []string {
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, string(role))
+	}
+	return names
+}
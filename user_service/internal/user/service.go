@@ -2,22 +2,43 @@ package user
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Levap123/user_service/internal/domain"
-
-	"github.com/Levap123/utils/jwt"
+	"github.com/Levap123/user_service/internal/notifier"
+	"github.com/Levap123/user_service/internal/token"
 )
 
 type UserService struct {
-	repo IUserRepo
-	j    *jwt.JWT
+	repo     IUserRepo
+	j        *token.JWT
+	notifier notifier.Notifier
+	cfg      ServiceConfig
+}
+
+// ServiceConfig is what UserService takes from the config file.
+type ServiceConfig struct {
+	// AdminEmail names the user made the first admin once signed up. It is
+	// left alone if admins already exist.
+	AdminEmail string
+
+	PasswordResetTTL time.Duration
+
+	// VerificationLink is the link sent to verify the email, with %s in place
+	// of the token. Without it the bare token is sent.
+	VerificationLink string
+	VerificationTTL  time.Duration
 }
 
-func NewUserService(repo IUserRepo, j *jwt.JWT) *UserService {
+func NewUserService(repo IUserRepo, j *token.JWT, notifier notifier.Notifier, cfg ServiceConfig) *UserService {
 	return &UserService{
-		repo: repo,
-		j:    j,
+		repo:     repo,
+		j:        j,
+		notifier: notifier,
+		cfg:      cfg,
 	}
 }
 
@@ -26,6 +47,20 @@ type IUserRepo interface {
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	GetByID(ctx context.Context, ID uint64) (*User, error)
 	UpdateInfo(ctx context.Context, user *User) (int, error)
+	GetRoles(ctx context.Context, userID uint64) ([]Role, error)
+	AssignRole(ctx context.Context, userID uint64, role Role) error
+	RevokeRole(ctx context.Context, userID uint64, role Role) error
+	BootstrapAdmin(ctx context.Context, email string) (bool, error)
+	CreateTokenFamily(ctx context.Context, family TokenFamily, token RefreshToken) error
+	GetTokenFamily(ctx context.Context, familyID string) (TokenFamily, error)
+	RotateRefreshToken(ctx context.Context, jtiHash string, next RefreshToken) (TokenFamily, error)
+	RevokeTokenFamily(ctx context.Context, familyID string) error
+	ListTokenFamilies(ctx context.Context, userID uint64) ([]TokenFamily, error)
+	RevokeUserTokenFamily(ctx context.Context, userID uint64, familyID string) error
+	CreatePasswordReset(ctx context.Context, reset PasswordReset) error
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (uint64, error)
+	CreateEmailVerification(ctx context.Context, verification EmailVerification) error
+	VerifyEmail(ctx context.Context, tokenHash string) (uint64, error)
 }
 
 func (us *UserService) Create(ctx context.Context, dto *CreateUserDTO) (uint64, error) {
@@ -38,15 +73,36 @@ func (us *UserService) Create(ctx context.Context, dto *CreateUserDTO) (uint64,
 	if err != nil {
 		return 0, fmt.Errorf("user service - repo create - %w", err)
 	}
+
 	return userID, nil
 }
 
-const (
-	accessType  = "access"
-	refreshType = "refresh"
-)
+// defaultAdminEmail is the admin email the config used to ship with. Anybody
+// could sign up with it, so it is refused.
+const defaultAdminEmail = "admin@bookstore.com"
 
-func (us *UserService) GenerateTokens(ctx context.Context, dto *GetUserDTO) (string, string, error) {
+// BootstrapAdmin grants the admin role to the signed up user with the
+// configured admin email unless there is an admin already. The user must have
+// verified the email, so signing up with the address is not enough to become
+// admin. It runs at startup only and tells whether the role was granted.
+func (us *UserService) BootstrapAdmin(ctx context.Context) (bool, error) {
+	if us.cfg.AdminEmail == "" {
+		return false, nil
+	}
+	if strings.EqualFold(us.cfg.AdminEmail, defaultAdminEmail) {
+		return false, fmt.Errorf("user service - bootstrap admin - %w", domain.ErrDefaultAdminEmail)
+	}
+
+	granted, err := us.repo.BootstrapAdmin(ctx, us.cfg.AdminEmail)
+	if err != nil {
+		return false, fmt.Errorf("user service - bootstrap admin - %w", err)
+	}
+	return granted, nil
+}
+
+// GenerateTokens signs the user in, which starts a new token family recorded
+// as a session of the client.
+func (us *UserService) GenerateTokens(ctx context.Context, dto *GetUserDTO, client ClientInfo) (string, string, error) {
 	user, err := us.repo.GetByEmail(ctx, dto.Email)
 	if err != nil {
 		return "", "", fmt.Errorf("user service - %w", err)
@@ -55,35 +111,110 @@ func (us *UserService) GenerateTokens(ctx context.Context, dto *GetUserDTO) (str
 		return "", "", domain.ErrIncorrectPassword
 	}
 
-	accessToken, err := us.j.GenerateJwt(int(user.ID), 2, accessType)
+	familyID, err := newTokenID()
 	if err != nil {
-		return "", "", fmt.Errorf("user service - generate access token - %w", err)
+		return "", "", fmt.Errorf("user service - generate tokens - %w", err)
 	}
 
-	refreshToken, err := us.j.GenerateJwt(int(user.ID), 30, refreshType)
+	accessToken, refreshToken, stored, err := us.generateTokens(ctx, user.ID, familyID)
 	if err != nil {
-		return "", "", fmt.Errorf("user service - generate refresh token - %w", err)
+		return "", "", fmt.Errorf("user service - generate tokens - %w", err)
+	}
+
+	client = client.normalized()
+	family := TokenFamily{
+		ID:        familyID,
+		UserID:    user.ID,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+	}
+	if err := us.repo.CreateTokenFamily(ctx, family, stored); err != nil {
+		return "", "", fmt.Errorf("user service - generate tokens - %w", err)
 	}
 
 	return accessToken, refreshToken, nil
 }
 
-func (us *UserService) Validate(ctx context.Context, accessToken string) (int, error) {
+// generateTokens issues a pair of tokens of the family carrying the current
+// roles of the user. The refresh token is returned also as it is to be
+// stored.
+func (us *UserService) generateTokens(ctx context.Context, userID uint64, familyID string) (string, string, RefreshToken, error) {
+	roles, err := us.repo.GetRoles(ctx, userID)
+	if err != nil {
+		return "", "", RefreshToken{}, fmt.Errorf("get roles - %w", err)
+	}
+
+	claims := token.Claims{
+		UserID:   int(userID),
+		FamilyID: familyID,
+		Roles:    roleNames(roles),
+	}
+
+	claims.TokenType = accessType
+	if claims.Id, err = newTokenID(); err != nil {
+		return "", "", RefreshToken{}, err
+	}
+	accessToken, err := us.j.GenerateJwt(claims, accessDays)
+	if err != nil {
+		return "", "", RefreshToken{}, fmt.Errorf("generate access token - %w", err)
+	}
+
+	claims.TokenType = refreshType
+	if claims.Id, err = newTokenID(); err != nil {
+		return "", "", RefreshToken{}, err
+	}
+	refreshToken, err := us.j.GenerateJwt(claims, refreshDays)
+	if err != nil {
+		return "", "", RefreshToken{}, fmt.Errorf("generate refresh token - %w", err)
+	}
+
+	stored := RefreshToken{
+		JTIHash:   hashJTI(claims.Id),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().AddDate(0, 0, refreshDays),
+	}
+
+	return accessToken, refreshToken, stored, nil
+}
+
+// Validate returns the claims of an access token unless its family is
+// revoked. Roles in the claims are the ones the user had when the token was
+// issued.
+func (us *UserService) Validate(ctx context.Context, accessToken string) (*token.Claims, error) {
 	claims, err := us.j.ParseToken(accessToken)
 	if err != nil {
-		return 0, err
+		return nil, fmt.Errorf("user service - validate - %v - %w", err, domain.ErrInvalidToken)
 	}
 
 	if claims.TokenType != accessType {
-		return 0, domain.ErrIncorrectTokenType
+		return nil, domain.ErrIncorrectTokenType
 	}
-	return claims.UserID, nil
+
+	family, err := us.repo.GetTokenFamily(ctx, claims.FamilyID)
+	if err != nil {
+		return nil, fmt.Errorf("user service - validate - %w", err)
+	}
+	if family.Revoked() {
+		return nil, fmt.Errorf("user service - validate - %w", domain.ErrTokenRevoked)
+	}
+
+	return claims, nil
 }
 
 func (us *UserService) GetByID(ctx context.Context, userID uint64) (*User, error) {
 	return us.repo.GetByID(ctx, userID)
 }
 
+// GetRoles returns the roles the user has now, which may differ from the
+// roles in their tokens.
+func (us *UserService) GetRoles(ctx context.Context, userID uint64) ([]Role, error) {
+	roles, err := us.repo.GetRoles(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user service - get roles - %w", err)
+	}
+	return roles, nil
+}
+
 func (us *UserService) UpdateUser(ctx context.Context, dto *UpdateUserDTO) (int, error) {
 	user, err := us.repo.GetByEmail(ctx, dto.Email)
 	if err != nil {
@@ -106,6 +237,10 @@ func (us *UserService) UpdateUser(ctx context.Context, dto *UpdateUserDTO) (int,
 	return userID, nil
 }
 
+// RefreshTokens rotates the refresh token: the presented one is used up and
+// a new one of the same family is issued. A refresh token presented a second
+// time is taken as stolen, and its whole family is revoked, signing out both
+// whoever stole it and the user.
 func (us *UserService) RefreshTokens(ctx context.Context, accessToken, refreshToken string) (string, string, error) {
 	claimsAccess, err := us.j.ParseToken(accessToken)
 	if err != nil {
@@ -125,19 +260,242 @@ func (us *UserService) RefreshTokens(ctx context.Context, accessToken, refreshTo
 		return "", "", fmt.Errorf("user service - refresh tokens - %w", domain.ErrIncorrectTokenType)
 	}
 
-	if claimsRefresh.UserID != claimsAccess.UserID {
+	if claimsRefresh.UserID != claimsAccess.UserID || claimsRefresh.FamilyID != claimsAccess.FamilyID {
 		return "", "", fmt.Errorf("user service - refresh tokens - %w", domain.ErrTokensMissmatched)
 	}
 
-	newAccessToken, err := us.j.GenerateJwt(claimsAccess.UserID, 2, accessType)
+	newAccessToken, newRefreshToken, stored, err := us.generateTokens(ctx, uint64(claimsRefresh.UserID), claimsRefresh.FamilyID)
 	if err != nil {
 		return "", "", fmt.Errorf("user service - refresh tokens - %w", err)
 	}
 
-	newRefreshToken, err := us.j.GenerateJwt(claimsAccess.UserID, 30, refreshType)
-	if err != nil {
+	if _, err := us.repo.RotateRefreshToken(ctx, hashJTI(claimsRefresh.Id), stored); err != nil {
 		return "", "", fmt.Errorf("user service - refresh tokens - %w", err)
 	}
 
 	return newAccessToken, newRefreshToken, nil
 }
+
+// Logout revokes the family of the refresh token, so neither its access nor
+// its refresh tokens work any more.
+func (us *UserService) Logout(ctx context.Context, refreshToken string) error {
+	claims, err := us.j.ParseToken(refreshToken)
+	if err != nil {
+		return fmt.Errorf("user service - logout - %v - %w", err, domain.ErrInvalidToken)
+	}
+
+	if claims.TokenType != refreshType {
+		return fmt.Errorf("user service - logout - %w", domain.ErrIncorrectTokenType)
+	}
+
+	if err := us.repo.RevokeTokenFamily(ctx, claims.FamilyID); err != nil {
+		return fmt.Errorf("user service - logout - %w", err)
+	}
+	return nil
+}
+
+// ListSessions returns the sessions of the user owning the access token that
+// can still be refreshed, the newest first.
+func (us *UserService) ListSessions(ctx context.Context, accessToken string) ([]Session, error) {
+	claims, err := us.Validate(ctx, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("user service - list sessions - %w", err)
+	}
+
+	families, err := us.repo.ListTokenFamilies(ctx, uint64(claims.UserID))
+	if err != nil {
+		return nil, fmt.Errorf("user service - list sessions - %w", err)
+	}
+
+	sessions := make([]Session, 0, len(families))
+	for _, family := range families {
+		sessions = append(sessions, Session{
+			TokenFamily: family,
+			Current:     family.ID == claims.FamilyID,
+		})
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs the user owning the access token out of one of their
+// sessions, which may be the current one. Its tokens stop working at once.
+func (us *UserService) RevokeSession(ctx context.Context, accessToken, sessionID string) error {
+	claims, err := us.Validate(ctx, accessToken)
+	if err != nil {
+		return fmt.Errorf("user service - revoke session - %w", err)
+	}
+
+	if err := us.repo.RevokeUserTokenFamily(ctx, uint64(claims.UserID), sessionID); err != nil {
+		return fmt.Errorf("user service - revoke session - %w", err)
+	}
+	return nil
+}
+
+// AssignRole grants the role to the user on behalf of the admin owning the
+// access token and returns the roles the user has now. The user gets the role
+// in tokens issued from then on.
+func (us *UserService) AssignRole(ctx context.Context, accessToken string, userID uint64, roleName string) ([]Role, error) {
+	role, err := us.authorizeRoleChange(ctx, accessToken, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("user service - assign role - %w", err)
+	}
+
+	if err := us.repo.AssignRole(ctx, userID, role); err != nil {
+		return nil, fmt.Errorf("user service - assign role - %w", err)
+	}
+
+	return us.repo.GetRoles(ctx, userID)
+}
+
+// RevokeRole takes the role away from the user. The last admin keeps the
+// admin role, so roles can always be managed.
+func (us *UserService) RevokeRole(ctx context.Context, accessToken string, userID uint64, roleName string) ([]Role, error) {
+	role, err := us.authorizeRoleChange(ctx, accessToken, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("user service - revoke role - %w", err)
+	}
+
+	if err := us.repo.RevokeRole(ctx, userID, role); err != nil {
+		return nil, fmt.Errorf("user service - revoke role - %w", err)
+	}
+
+	return us.repo.GetRoles(ctx, userID)
+}
+
+func (us *UserService) authorizeRoleChange(ctx context.Context, accessToken, roleName string) (Role, error) {
+	claims, err := us.Validate(ctx, accessToken)
+	if err != nil {
+		return "", err
+	}
+
+	// Roles are read again rather than taken from the token, so an admin who
+	// lost the role can't use a token issued before.
+	roles, err := us.repo.GetRoles(ctx, uint64(claims.UserID))
+	if err != nil {
+		return "", err
+	}
+	if !hasRole(roleNames(roles), RoleAdmin) {
+		return "", domain.ErrPermissionDenied
+	}
+
+	return ParseRole(roleName)
+}
+
+// RequestPasswordReset sends the user with the email a token to set a new
+// password with. Only the hash of the token is stored, and a new request
+// replaces the token sent before. Unknown emails are not reported, so the
+// request can't be used to find out who is signed up.
+func (us *UserService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := us.repo.GetByEmail(ctx, email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("user service - request password reset - %w", err)
+	}
+
+	resetToken, err := newSecretToken()
+	if err != nil {
+		return fmt.Errorf("user service - request password reset - %w", err)
+	}
+
+	reset := PasswordReset{
+		TokenHash: hashJTI(resetToken),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(us.cfg.PasswordResetTTL),
+	}
+	if err := us.repo.CreatePasswordReset(ctx, reset); err != nil {
+		return fmt.Errorf("user service - request password reset - %w", err)
+	}
+
+	msg := notifier.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Use this token to set a new password: %s. It expires at %s.",
+			resetToken, reset.ExpiresAt.Format(time.RFC1123)),
+	}
+	if err := us.notifier.Notify(ctx, msg); err != nil {
+		return fmt.Errorf("user service - request password reset - notify - %w", err)
+	}
+	return nil
+}
+
+// ConfirmPasswordReset sets the new password of the user the reset token was
+// sent to. The token works once, and every session of the user is revoked, so
+// whoever knew the old password is signed out.
+func (us *UserService) ConfirmPasswordReset(ctx context.Context, resetToken, newPassword string) error {
+	user := &User{Password: newPassword}
+	if err := user.generatePasswordHash(); err != nil {
+		return fmt.Errorf("user service - confirm password reset - generate password hash - %w", err)
+	}
+
+	if _, err := us.repo.ResetPassword(ctx, hashJTI(resetToken), user.Password); err != nil {
+		return fmt.Errorf("user service - confirm password reset - %w", err)
+	}
+	return nil
+}
+
+// SendVerification sends the user a link to verify their email with. A new
+// link replaces the one sent before.
+func (us *UserService) SendVerification(ctx context.Context, userID uint64) error {
+	user, err := us.repo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user service - send verification - %w", err)
+	}
+	if user.EmailVerified {
+		return fmt.Errorf("user service - send verification - %w", domain.ErrEmailVerified)
+	}
+
+	verificationToken, err := newSecretToken()
+	if err != nil {
+		return fmt.Errorf("user service - send verification - %w", err)
+	}
+
+	verification := EmailVerification{
+		TokenHash: hashJTI(verificationToken),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(us.cfg.VerificationTTL),
+	}
+	if err := us.repo.CreateEmailVerification(ctx, verification); err != nil {
+		return fmt.Errorf("user service - send verification - %w", err)
+	}
+
+	link := verificationToken
+	if us.cfg.VerificationLink != "" {
+		link = fmt.Sprintf(us.cfg.VerificationLink, verificationToken)
+	}
+
+	msg := notifier.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Open this link to verify your email: %s. It expires at %s.",
+			link, verification.ExpiresAt.Format(time.RFC1123)),
+	}
+	if err := us.notifier.Notify(ctx, msg); err != nil {
+		return fmt.Errorf("user service - send verification - notify - %w", err)
+	}
+	return nil
+}
+
+// ResendVerification sends a new verification link to the owner of the
+// access token, e.g. when the first one expired.
+func (us *UserService) ResendVerification(ctx context.Context, accessToken string) error {
+	claims, err := us.Validate(ctx, accessToken)
+	if err != nil {
+		return fmt.Errorf("user service - resend verification - %w", err)
+	}
+
+	if err := us.SendVerification(ctx, uint64(claims.UserID)); err != nil {
+		return fmt.Errorf("user service - resend verification - %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail marks the email of the user the token was sent to as verified.
+// The token works once.
+func (us *UserService) VerifyEmail(ctx context.Context, verificationToken string) error {
+	if _, err := us.repo.VerifyEmail(ctx, hashJTI(verificationToken)); err != nil {
+		return fmt.Errorf("user service - verify email - %w", err)
+	}
+	return nil
+}
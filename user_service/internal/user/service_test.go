@@ -2,15 +2,48 @@ package user_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
 	"testing"
+	"time"
 
+	"github.com/Levap123/user_service/internal/domain"
+	"github.com/Levap123/user_service/internal/notifier"
+	"github.com/Levap123/user_service/internal/token"
 	"github.com/Levap123/user_service/internal/user"
 	"github.com/Levap123/user_service/internal/user/mock"
-
-	"github.com/Levap123/utils/jwt"
 )
 
-var us = user.NewUserService(mock.NewUserRepo(), jwt.NewJWT("testingSign"))
+var notifications = &recordingNotifier{}
+
+var us = user.NewUserService(mock.NewUserRepo(), token.NewJWT("testingSign"), notifications,
+	user.ServiceConfig{PasswordResetTTL: time.Hour, VerificationTTL: time.Hour})
+
+type recordingNotifier struct {
+	sent []notifier.Message
+}
+
+func (rn *recordingNotifier) Notify(ctx context.Context, msg notifier.Message) error {
+	rn.sent = append(rn.sent, msg)
+	return nil
+}
+
+var secretTokenRe = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// lastToken returns the token in the last message sent to the email.
+func (rn *recordingNotifier) lastToken(t *testing.T, email string) string {
+	t.Helper()
+
+	for i := len(rn.sent) - 1; i >= 0; i-- {
+		if rn.sent[i].To == email {
+			return secretTokenRe.FindString(rn.sent[i].Body)
+		}
+	}
+	t.Fatalf("no message sent to %s", email)
+	return ""
+}
 
 var userCreateDTOs = []*user.CreateUserDTO{
 	{
@@ -111,7 +144,7 @@ func TestUserService_GenerateTokens(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, _, err := us.GenerateTokens(tt.args.ctx, tt.args.dto)
+			got, _, err := us.GenerateTokens(tt.args.ctx, tt.args.dto, user.ClientInfo{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("UserService.GenerateTokens() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -185,3 +218,264 @@ func TestUserService_UpdateUser(t *testing.T) {
 		})
 	}
 }
+
+func accessToken(t *testing.T, userID int) string {
+	t.Helper()
+
+	familyID := fmt.Sprintf("family-%d", userID)
+	mock.NewUserRepo().CreateTokenFamily(context.Background(), user.TokenFamily{ID: familyID, UserID: uint64(userID)}, user.RefreshToken{})
+
+	claims := token.Claims{UserID: userID, TokenType: "access", FamilyID: familyID}
+	signed, err := token.NewJWT("testingSign").GenerateJwt(claims, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return signed
+}
+
+func TestUserService_Roles(t *testing.T) {
+	ctx := context.Background()
+	admin, customer := accessToken(t, 1), accessToken(t, 2)
+
+	roles, err := us.AssignRole(ctx, admin, 2, "catalog_manager")
+	if err != nil {
+		t.Fatalf("UserService.AssignRole() error = %v", err)
+	}
+	if len(roles) != 2 || roles[1] != user.RoleCatalogManager {
+		t.Errorf("UserService.AssignRole() = %v, want customer and catalog_manager", roles)
+	}
+
+	if _, err := us.AssignRole(ctx, customer, 3, "admin"); !errors.Is(err, domain.ErrPermissionDenied) {
+		t.Errorf("UserService.AssignRole() error = %v, want %v", err, domain.ErrPermissionDenied)
+	}
+	if _, err := us.AssignRole(ctx, admin, 2, "owner"); !errors.Is(err, domain.ErrUnknownRole) {
+		t.Errorf("UserService.AssignRole() error = %v, want %v", err, domain.ErrUnknownRole)
+	}
+	if _, err := us.RevokeRole(ctx, admin, 1, "admin"); !errors.Is(err, domain.ErrLastAdmin) {
+		t.Errorf("UserService.RevokeRole() error = %v, want %v", err, domain.ErrLastAdmin)
+	}
+
+	roles, err = us.RevokeRole(ctx, admin, 2, "catalog_manager")
+	if err != nil {
+		t.Fatalf("UserService.RevokeRole() error = %v", err)
+	}
+	if len(roles) != 1 || roles[0] != user.RoleCustomer {
+		t.Errorf("UserService.RevokeRole() = %v, want customer", roles)
+	}
+	if roles, err := us.GetRoles(ctx, 2); err != nil || len(roles) != 1 {
+		t.Errorf("UserService.GetRoles() = %v, %v, want customer only", roles, err)
+	}
+}
+
+func TestUserService_BootstrapAdmin(t *testing.T) {
+	ctx := context.Background()
+	service := user.NewUserService(mock.NewUserRepo(), token.NewJWT("testingSign"), notifications,
+		user.ServiceConfig{AdminEmail: "boss"})
+
+	userID, err := service.Create(ctx, &user.CreateUserDTO{Email: "boss", Username: "boss", Password: "password"})
+	if err != nil {
+		t.Fatalf("UserService.Create() error = %v", err)
+	}
+	if roles, _ := service.GetRoles(ctx, userID); len(roles) != 1 || roles[0] != user.RoleCustomer {
+		t.Errorf("signing up with the admin email granted %v", roles)
+	}
+
+	defaults := user.NewUserService(mock.NewUserRepo(), token.NewJWT("testingSign"), notifications,
+		user.ServiceConfig{AdminEmail: "Admin@bookstore.com"})
+	if _, err := defaults.BootstrapAdmin(ctx); !errors.Is(err, domain.ErrDefaultAdminEmail) {
+		t.Errorf("UserService.BootstrapAdmin() error = %v, want %v", err, domain.ErrDefaultAdminEmail)
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	got := user.Permissions([]string{"catalog_manager", "admin", "unknown"})
+	want := []string{"catalog:manage", "orders:manage", "roles:manage"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Permissions() = %v, want %v", got, want)
+	}
+}
+
+func TestUserService_RefreshTokens(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := us.Create(ctx, &user.CreateUserDTO{Email: "refresh", Username: "refresh", Password: "password"}); err != nil {
+		t.Fatalf("UserService.Create() error = %v", err)
+	}
+	access, refresh, err := us.GenerateTokens(ctx, &user.GetUserDTO{Email: "refresh", Password: "password"}, user.ClientInfo{})
+	if err != nil {
+		t.Fatalf("UserService.GenerateTokens() error = %v", err)
+	}
+
+	newAccess, newRefresh, err := us.RefreshTokens(ctx, access, refresh)
+	if err != nil {
+		t.Fatalf("UserService.RefreshTokens() error = %v", err)
+	}
+	if _, err := us.Validate(ctx, newAccess); err != nil {
+		t.Fatalf("UserService.Validate() error = %v", err)
+	}
+
+	if _, _, err := us.RefreshTokens(ctx, access, refresh); !errors.Is(err, domain.ErrTokenReused) {
+		t.Errorf("UserService.RefreshTokens() error = %v, want %v", err, domain.ErrTokenReused)
+	}
+	if _, _, err := us.RefreshTokens(ctx, newAccess, newRefresh); !errors.Is(err, domain.ErrTokenRevoked) {
+		t.Errorf("reuse should revoke the family, got error = %v", err)
+	}
+	if _, err := us.Validate(ctx, newAccess); !errors.Is(err, domain.ErrTokenRevoked) {
+		t.Errorf("UserService.Validate() error = %v, want %v", err, domain.ErrTokenRevoked)
+	}
+}
+
+func TestUserService_Logout(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := us.Create(ctx, &user.CreateUserDTO{Email: "logout", Username: "logout", Password: "password"}); err != nil {
+		t.Fatalf("UserService.Create() error = %v", err)
+	}
+	access, refresh, err := us.GenerateTokens(ctx, &user.GetUserDTO{Email: "logout", Password: "password"}, user.ClientInfo{})
+	if err != nil {
+		t.Fatalf("UserService.GenerateTokens() error = %v", err)
+	}
+
+	if err := us.Logout(ctx, access); !errors.Is(err, domain.ErrIncorrectTokenType) {
+		t.Errorf("UserService.Logout() error = %v, want %v", err, domain.ErrIncorrectTokenType)
+	}
+	if err := us.Logout(ctx, refresh); err != nil {
+		t.Fatalf("UserService.Logout() error = %v", err)
+	}
+
+	if _, err := us.Validate(ctx, access); !errors.Is(err, domain.ErrTokenRevoked) {
+		t.Errorf("UserService.Validate() error = %v, want %v", err, domain.ErrTokenRevoked)
+	}
+	if _, _, err := us.RefreshTokens(ctx, access, refresh); !errors.Is(err, domain.ErrTokenRevoked) {
+		t.Errorf("UserService.RefreshTokens() error = %v, want %v", err, domain.ErrTokenRevoked)
+	}
+}
+
+func TestUserService_Sessions(t *testing.T) {
+	ctx := context.Background()
+	signIn := &user.GetUserDTO{Email: "sessions", Password: "password"}
+
+	if _, err := us.Create(ctx, &user.CreateUserDTO{Email: "sessions", Username: "sessions", Password: "password"}); err != nil {
+		t.Fatalf("UserService.Create() error = %v", err)
+	}
+	phone, phoneRefresh, err := us.GenerateTokens(ctx, signIn, user.ClientInfo{UserAgent: "phone", IP: "10.0.0.1"})
+	if err != nil {
+		t.Fatalf("UserService.GenerateTokens() error = %v", err)
+	}
+	laptop, _, err := us.GenerateTokens(ctx, signIn, user.ClientInfo{UserAgent: "laptop", IP: "10.0.0.2"})
+	if err != nil {
+		t.Fatalf("UserService.GenerateTokens() error = %v", err)
+	}
+
+	sessions, err := us.ListSessions(ctx, laptop)
+	if err != nil {
+		t.Fatalf("UserService.ListSessions() error = %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("UserService.ListSessions() = %+v, want 2 sessions", sessions)
+	}
+
+	var phoneSession string
+	for _, session := range sessions {
+		if session.Current != (session.UserAgent == "laptop") {
+			t.Errorf("only the laptop session should be current: %+v", session)
+		}
+		if session.UserAgent == "phone" {
+			phoneSession = session.ID
+		}
+	}
+
+	if err := us.RevokeSession(ctx, accessToken(t, 2), phoneSession); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Errorf("UserService.RevokeSession() error = %v, want %v", err, domain.ErrSessionNotFound)
+	}
+	if err := us.RevokeSession(ctx, laptop, phoneSession); err != nil {
+		t.Fatalf("UserService.RevokeSession() error = %v", err)
+	}
+
+	if _, _, err := us.RefreshTokens(ctx, phone, phoneRefresh); !errors.Is(err, domain.ErrTokenRevoked) {
+		t.Errorf("UserService.RefreshTokens() error = %v, want %v", err, domain.ErrTokenRevoked)
+	}
+	if sessions, _ := us.ListSessions(ctx, laptop); len(sessions) != 1 || !sessions[0].Current {
+		t.Errorf("UserService.ListSessions() = %+v, want the laptop session only", sessions)
+	}
+}
+
+func TestUserService_PasswordReset(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := us.Create(ctx, &user.CreateUserDTO{Email: "reset", Username: "reset", Password: "password"}); err != nil {
+		t.Fatalf("UserService.Create() error = %v", err)
+	}
+	access, _, err := us.GenerateTokens(ctx, &user.GetUserDTO{Email: "reset", Password: "password"}, user.ClientInfo{})
+	if err != nil {
+		t.Fatalf("UserService.GenerateTokens() error = %v", err)
+	}
+
+	if err := us.RequestPasswordReset(ctx, "nobody"); err != nil {
+		t.Errorf("UserService.RequestPasswordReset() error = %v for an unknown email", err)
+	}
+	if err := us.RequestPasswordReset(ctx, "reset"); err != nil {
+		t.Fatalf("UserService.RequestPasswordReset() error = %v", err)
+	}
+	resetToken := notifications.lastToken(t, "reset")
+
+	if err := us.ConfirmPasswordReset(ctx, "wrong", "new password"); !errors.Is(err, domain.ErrResetTokenInvalid) {
+		t.Errorf("UserService.ConfirmPasswordReset() error = %v, want %v", err, domain.ErrResetTokenInvalid)
+	}
+	if err := us.ConfirmPasswordReset(ctx, resetToken, "new password"); err != nil {
+		t.Fatalf("UserService.ConfirmPasswordReset() error = %v", err)
+	}
+	if err := us.ConfirmPasswordReset(ctx, resetToken, "other password"); !errors.Is(err, domain.ErrResetTokenInvalid) {
+		t.Errorf("UserService.ConfirmPasswordReset() error = %v on reuse, want %v", err, domain.ErrResetTokenInvalid)
+	}
+
+	if _, err := us.Validate(ctx, access); !errors.Is(err, domain.ErrTokenRevoked) {
+		t.Errorf("UserService.Validate() error = %v, want %v", err, domain.ErrTokenRevoked)
+	}
+	if _, _, err := us.GenerateTokens(ctx, &user.GetUserDTO{Email: "reset", Password: "password"}, user.ClientInfo{}); err == nil {
+		t.Errorf("UserService.GenerateTokens() signed in with the old password")
+	}
+	if _, _, err := us.GenerateTokens(ctx, &user.GetUserDTO{Email: "reset", Password: "new password"}, user.ClientInfo{}); err != nil {
+		t.Errorf("UserService.GenerateTokens() error = %v with the new password", err)
+	}
+}
+
+func TestUserService_EmailVerification(t *testing.T) {
+	ctx := context.Background()
+
+	userID, err := us.Create(ctx, &user.CreateUserDTO{Email: "verify", Username: "verify", Password: "password"})
+	if err != nil {
+		t.Fatalf("UserService.Create() error = %v", err)
+	}
+	access, _, err := us.GenerateTokens(ctx, &user.GetUserDTO{Email: "verify", Password: "password"}, user.ClientInfo{})
+	if err != nil {
+		t.Fatalf("UserService.GenerateTokens() error = %v", err)
+	}
+
+	if err := us.SendVerification(ctx, userID); err != nil {
+		t.Fatalf("UserService.SendVerification() error = %v", err)
+	}
+	expired := notifications.lastToken(t, "verify")
+	if err := us.ResendVerification(ctx, access); err != nil {
+		t.Fatalf("UserService.ResendVerification() error = %v", err)
+	}
+	verificationToken := notifications.lastToken(t, "verify")
+
+	if err := us.VerifyEmail(ctx, expired); !errors.Is(err, domain.ErrVerificationInvalid) {
+		t.Errorf("UserService.VerifyEmail() error = %v with a replaced token, want %v", err, domain.ErrVerificationInvalid)
+	}
+	if err := us.VerifyEmail(ctx, verificationToken); err != nil {
+		t.Fatalf("UserService.VerifyEmail() error = %v", err)
+	}
+	if err := us.VerifyEmail(ctx, verificationToken); !errors.Is(err, domain.ErrVerificationInvalid) {
+		t.Errorf("UserService.VerifyEmail() error = %v on reuse, want %v", err, domain.ErrVerificationInvalid)
+	}
+
+	if u, err := us.GetByID(ctx, userID); err != nil || !u.EmailVerified {
+		t.Errorf("UserService.GetByID() = %+v, %v, want a verified email", u, err)
+	}
+	if err := us.ResendVerification(ctx, access); !errors.Is(err, domain.ErrEmailVerified) {
+		t.Errorf("UserService.ResendVerification() error = %v, want %v", err, domain.ErrEmailVerified)
+	}
+}
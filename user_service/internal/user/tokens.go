@@ -0,0 +1,105 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+const (
+	accessType  = "access"
+	refreshType = "refresh"
+
+	accessDays  = 2
+	refreshDays = 30
+)
+
+// TokenFamily is the chain of refresh tokens issued from one sign-in, which
+// users see as a session. Each refresh replaces the token of the family with
+// a new one, and revoking the family signs the user out of that sign-in.
+type TokenFamily struct {
+	ID              string     `db:"id"`
+	UserID          uint64     `db:"user_id"`
+	UserAgent       string     `db:"user_agent"`
+	IP              string     `db:"ip"`
+	CreatedAt       time.Time  `db:"created_at"`
+	LastRefreshedAt *time.Time `db:"last_refreshed_at"`
+	RevokedAt       *time.Time `db:"revoked_at"`
+}
+
+func (f TokenFamily) Revoked() bool {
+	return f.RevokedAt != nil
+}
+
+// PasswordReset is a password reset token as stored. It is single-use and
+// expires at ExpiresAt.
+type PasswordReset struct {
+	TokenHash string    `db:"token_hash"`
+	UserID    uint64    `db:"user_id"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// EmailVerification is an email verification token as stored. Like a
+// password reset token, it is single-use and expires at ExpiresAt.
+type EmailVerification struct {
+	TokenHash string    `db:"token_hash"`
+	UserID    uint64    `db:"user_id"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// Session is a token family as shown to its user. Current is set for the
+// session of the access token the sessions are listed with.
+type Session struct {
+	TokenFamily
+	Current bool
+}
+
+// ClientInfo is where a sign-in comes from, as the gateway tells.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// maxUserAgentLen bounds the user agents stored with sessions.
+const maxUserAgentLen = 512
+
+func (c ClientInfo) normalized() ClientInfo {
+	if len(c.UserAgent) > maxUserAgentLen {
+		c.UserAgent = c.UserAgent[:maxUserAgentLen]
+	}
+	return c
+}
+
+// RefreshToken is a refresh token as stored: only the hash of its JTI is
+// kept, so a leaked table can't be turned into tokens.
+type RefreshToken struct {
+	JTIHash   string    `db:"jti_hash"`
+	FamilyID  string    `db:"family_id"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+func newTokenID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("new token ID - %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// newSecretToken returns a token that is sent to the user, like a password
+// reset token.
+func newSecretToken() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("new secret token - %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// hashJTI hashes token IDs and secret tokens to be stored.
+func hashJTI(jti string) string {
+	sum := sha256.Sum256([]byte(jti))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS user_roles (
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	role TEXT NOT NULL CHECK (role IN ('customer', 'catalog_manager', 'order_manager', 'admin')),
+	granted_at TIMESTAMP NOT NULL DEFAULT now(),
+	PRIMARY KEY (user_id, role)
+);
+
+INSERT INTO user_roles(user_id, role) SELECT id, 'customer' FROM users;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS token_families (
+	id TEXT PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT now(),
+	revoked_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	jti_hash TEXT PRIMARY KEY,
+	family_id TEXT NOT NULL REFERENCES token_families(id) ON DELETE CASCADE,
+	expires_at TIMESTAMP NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT now(),
+	used_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS token_families_user_id_idx ON token_families(user_id);
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON refresh_tokens(family_id);
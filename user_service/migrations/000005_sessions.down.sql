@@ -0,0 +1,4 @@
+ALTER TABLE token_families
+	DROP COLUMN IF EXISTS user_agent,
+	DROP COLUMN IF EXISTS ip,
+	DROP COLUMN IF EXISTS last_refreshed_at;
@@ -0,0 +1,4 @@
+ALTER TABLE token_families
+	ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS ip TEXT NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS last_refreshed_at TIMESTAMP;